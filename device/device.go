@@ -0,0 +1,172 @@
+// Package device defines the drawing surface shared by every print backend.
+//
+// The GDI printer in package printer implements Device, as do the portable
+// backends, so documents such as ticket.Ticket can be drawn once and sent to
+// any of them.
+package device
+
+import (
+	"errors"
+	"image"
+	"image/color"
+)
+
+// ErrUnknownCap is returned by GetDeviceCaps for indexes a backend does not
+// know about.
+var ErrUnknownCap = errors.New("device: unknown capability index")
+
+// Cap is a device capability index, numbered like the GetDeviceCaps() indexes
+// of win32.PropType so that the GDI backend can pass it through unchanged.
+type Cap uint32
+
+const (
+	HorzSize        Cap = 4   // Horizontal size in millimeters
+	VertSize        Cap = 6   // Vertical size in millimeters
+	HorzRes         Cap = 8   // Horizontal width in pixels
+	VertRes         Cap = 10  // Vertical height in pixels
+	BitsPixel       Cap = 12  // Number of bits per pixel
+	LogPixelsX      Cap = 88  // Logical pixels/inch in X
+	LogPixelsY      Cap = 90  // Logical pixels/inch in Y
+	PhysicalWidth   Cap = 110 // Physical width in device units
+	PhysicalHeight  Cap = 111 // Physical height in device units
+	PhysicalOffsetX Cap = 112 // Physical printable area x margin
+	PhysicalOffsetY Cap = 113 // Physical printable area y margin
+)
+
+// Font is the logical font selected on a Canvas, the portable counterpart of
+// win32.LOGFONT. Height is the character height in pixels.
+type Font struct {
+	Name   string
+	Height int32
+	Bold   bool
+	Italic bool
+}
+
+// DefaultFontName is the face selected until SetFont is called.
+const DefaultFontName = "Arial"
+
+// DefaultTextHeight is the character height in points selected until
+// SetTextSize is called.
+const DefaultTextHeight = 12
+
+// DefaultFont returns the font a backend at dpi dots per inch starts its
+// pages with.
+func DefaultFont(dpi int) Font {
+	return Font{Name: DefaultFontName, Height: int32(DefaultTextHeight * dpi / 72)}
+}
+
+// PageSize is a paper size in millimeters.
+type PageSize struct {
+	Width  float64
+	Height float64
+}
+
+var (
+	A4     = PageSize{Width: 210, Height: 297}
+	A5     = PageSize{Width: 148, Height: 210}
+	Letter = PageSize{Width: 215.9, Height: 279.4}
+)
+
+// Pixels returns the page size in pixels at dpi dots per inch.
+func (s PageSize) Pixels(dpi int) (width, height int) {
+	return MMToPixels(s.Width, dpi), MMToPixels(s.Height, dpi)
+}
+
+// Caps answers GetDeviceCaps for a page of size s printed at dpi dots per
+// inch without unprintable margins, as the portable backends do.
+func (s PageSize) Caps(dpi int, index Cap) (uint32, error) {
+	width, height := s.Pixels(dpi)
+	switch index {
+	case HorzSize:
+		return uint32(s.Width), nil
+	case VertSize:
+		return uint32(s.Height), nil
+	case HorzRes, PhysicalWidth:
+		return uint32(width), nil
+	case VertRes, PhysicalHeight:
+		return uint32(height), nil
+	case BitsPixel:
+		return 24, nil
+	case LogPixelsX, LogPixelsY:
+		return uint32(dpi), nil
+	case PhysicalOffsetX, PhysicalOffsetY:
+		return 0, nil
+	}
+	return 0, ErrUnknownCap
+}
+
+// MMToPixels converts a length in millimeters to pixels at dpi dots per inch.
+func MMToPixels(mm float64, dpi int) int {
+	return int(mm*float64(dpi)/25.4 + 0.5)
+}
+
+// Canvas is the set of drawing operations available inside a page.
+// Coordinates are in device pixels with the origin at the top left corner.
+type Canvas interface {
+	// TextOut draws text with its top left corner at x, y using the
+	// current font.
+	TextOut(x, y uint32, text string) error
+	// GetTextExtentPoint32 returns the width and height of text in the
+	// current font.
+	GetTextExtentPoint32(text string) (width, height uint32, err error)
+	// MoveTo sets the current position used by LineTo.
+	MoveTo(x, y uint32) error
+	// LineTo draws a line from the current position to x, y and makes x, y
+	// the current position. The line is black and one pixel wide, like
+	// with the default GDI pen, unless changed through Painter.
+	LineTo(x, y uint32) error
+	// DrawImage stretches img into the rectangle at x, y of size width x height.
+	DrawImage(x, y, width, height uint32, img image.Image) error
+
+	// SetFont selects the font face by name, see win32.Arial and friends.
+	SetFont(fontName string) error
+	// SetTextSize changes the text height in pixels and returns the
+	// previous one.
+	SetTextSize(size int32) (int32, error)
+	SetBoldFont(bold bool) error
+	SetItalicFont(italic bool) error
+	// SetTextColor changes the text color and returns the previous one.
+	SetTextColor(c color.Color) (color.Color, error)
+
+	GetDeviceCaps(index Cap) (uint32, error)
+}
+
+// Device is a Canvas with a document and page lifecycle.
+type Device interface {
+	Canvas
+	StartDoc(docName string) error
+	StartPage() error
+	EndPage() error
+	EndDoc() error
+}
+
+// Painter is implemented by devices able to honour GDI pens and brushes.
+// Players of foreign formats such as EMF use it when available, and fall back
+// to the default one pixel black pen of Canvas.LineTo otherwise.
+type Painter interface {
+	// SetPen sets the color and width in pixels of the lines drawn by
+	// LineTo.
+	SetPen(c color.Color, width uint32) error
+	// FillPolygon fills the polygon closed by points with c.
+	FillPolygon(points []image.Point, c color.Color) error
+}
+
+// Printable is a single page document that can draw itself on a Canvas.
+type Printable interface {
+	Print(Canvas)
+}
+
+// Print prints pt as a one page document named docName on d.
+func Print(d Device, docName string, pt Printable) (err error) {
+	if err = d.StartDoc(docName); err != nil {
+		return
+	}
+	if err = d.StartPage(); err != nil {
+		return
+	}
+	pt.Print(d)
+	if err = d.EndPage(); err != nil {
+		return
+	}
+	return d.EndDoc()
+}
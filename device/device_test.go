@@ -0,0 +1,72 @@
+package device
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+type fakeDevice struct {
+	calls  []string
+	failOn string
+}
+
+func (f *fakeDevice) call(name string) error {
+	f.calls = append(f.calls, name)
+	if name == f.failOn {
+		return errors.New(name + " failed")
+	}
+	return nil
+}
+
+func (f *fakeDevice) TextOut(x, y uint32, text string) error { return f.call("TextOut " + text) }
+func (f *fakeDevice) GetTextExtentPoint32(text string) (uint32, uint32, error) {
+	return uint32(len(text)), 1, nil
+}
+func (f *fakeDevice) MoveTo(x, y uint32) error { return f.call("MoveTo") }
+func (f *fakeDevice) LineTo(x, y uint32) error { return f.call("LineTo") }
+func (f *fakeDevice) DrawImage(x, y, width, height uint32, img image.Image) error {
+	return f.call("DrawImage")
+}
+func (f *fakeDevice) SetFont(fontName string) error         { return f.call("SetFont") }
+func (f *fakeDevice) SetTextSize(size int32) (int32, error) { return 0, f.call("SetTextSize") }
+func (f *fakeDevice) SetBoldFont(bold bool) error           { return f.call("SetBoldFont") }
+func (f *fakeDevice) SetItalicFont(italic bool) error       { return f.call("SetItalicFont") }
+func (f *fakeDevice) SetTextColor(c color.Color) (color.Color, error) {
+	return color.Black, f.call("SetTextColor")
+}
+func (f *fakeDevice) GetDeviceCaps(index Cap) (uint32, error) { return 0, nil }
+func (f *fakeDevice) StartDoc(docName string) error           { return f.call("StartDoc " + docName) }
+func (f *fakeDevice) StartPage() error                        { return f.call("StartPage") }
+func (f *fakeDevice) EndPage() error                          { return f.call("EndPage") }
+func (f *fakeDevice) EndDoc() error                           { return f.call("EndDoc") }
+
+type helloPrintable struct{}
+
+func (helloPrintable) Print(c Canvas) {
+	c.TextOut(0, 0, "hello")
+}
+
+func TestPrint(t *testing.T) {
+	d := &fakeDevice{}
+	if err := Print(d, "doc", helloPrintable{}); err != nil {
+		t.Fatalf("Print() error = %v", err)
+	}
+	want := []string{"StartDoc doc", "StartPage", "TextOut hello", "EndPage", "EndDoc"}
+	if !reflect.DeepEqual(d.calls, want) {
+		t.Errorf("Print() calls = %v, want %v", d.calls, want)
+	}
+}
+
+func TestPrintStopsOnError(t *testing.T) {
+	d := &fakeDevice{failOn: "StartPage"}
+	if err := Print(d, "doc", helloPrintable{}); err == nil {
+		t.Fatal("Print() error = nil, want StartPage failure")
+	}
+	want := []string{"StartDoc doc", "StartPage"}
+	if !reflect.DeepEqual(d.calls, want) {
+		t.Errorf("Print() calls = %v, want %v", d.calls, want)
+	}
+}
@@ -0,0 +1,187 @@
+package emf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"strings"
+	"unicode/utf16"
+)
+
+var errShortRecord = errors.New("record too short")
+
+// Header is the decoded EMR_HEADER record.
+type Header struct {
+	// Bounds is the inclusive rectangle drawn, in reference device pixels.
+	Bounds image.Rectangle
+	// Frame is the inclusive picture frame, in .01 millimeter units.
+	Frame   image.Rectangle
+	Version uint32
+	Bytes   uint32
+	Records uint32
+	Handles uint16
+	// Description holds the application and picture names, separated by
+	// a NUL.
+	Description string
+	// Device is the size of the reference device in pixels and
+	// Millimeters in millimeters.
+	Device      image.Point
+	Millimeters image.Point
+	// Micrometers is the size of the reference device in micrometers when
+	// the header has the second extension, zero otherwise.
+	Micrometers image.Point
+}
+
+// Record is a metafile record. Data excludes the type and size fields, so
+// that offsets found in records are 8 bytes past their index in Data.
+type Record struct {
+	Type RecordType
+	// Offset is the position of the record in the metafile.
+	Offset int
+	Data   []byte
+}
+
+// Metafile is a parsed enhanced metafile.
+type Metafile struct {
+	Header  Header
+	Records []Record
+}
+
+// Parse splits an enhanced metafile into its records and decodes its header.
+func Parse(b []byte) (*Metafile, error) {
+	m := &Metafile{}
+	for off := 0; off < len(b); {
+		if len(b)-off < 8 {
+			return nil, fmt.Errorf("emf: truncated record at %d", off)
+		}
+		t := RecordType(binary.LittleEndian.Uint32(b[off:]))
+		size := int(binary.LittleEndian.Uint32(b[off+4:]))
+		if size < 8 || size%4 != 0 || size > len(b)-off {
+			return nil, fmt.Errorf("emf: bad size %d of %s at %d", size, t, off)
+		}
+		if off == 0 && t != EMR_HEADER {
+			return nil, errors.New("emf: missing EMR_HEADER")
+		}
+		m.Records = append(m.Records, Record{Type: t, Offset: off, Data: b[off+8 : off+size]})
+		off += size
+		if t == EMR_EOF {
+			break
+		}
+	}
+	if len(m.Records) == 0 {
+		return nil, errors.New("emf: empty metafile")
+	}
+	if err := m.Header.decode(m.Records[0]); err != nil {
+		return nil, fmt.Errorf("emf: %w", err)
+	}
+	if m.Records[len(m.Records)-1].Type != EMR_EOF {
+		return nil, errors.New("emf: missing EMR_EOF")
+	}
+	return m, nil
+}
+
+func (h *Header) decode(rec Record) error {
+	r := reader{b: rec.Data}
+	h.Bounds = r.rectl()
+	h.Frame = r.rectl()
+	if r.u32() != ENHMETA_SIGNATURE {
+		return errors.New("bad signature")
+	}
+	h.Version = r.u32()
+	h.Bytes = r.u32()
+	h.Records = r.u32()
+	h.Handles = r.u16()
+	r.u16()
+	nDesc, offDesc := r.u32(), r.u32()
+	r.u32() // nPalEntries
+	h.Device = r.pointl()
+	h.Millimeters = r.pointl()
+	if r.err != nil {
+		return fmt.Errorf("EMR_HEADER: %w", r.err)
+	}
+	// The header extensions are present when the description, or the end
+	// of the record, leaves room for them.
+	end := len(rec.Data) + 8
+	if nDesc > 0 {
+		end = int(offDesc)
+	}
+	if end >= 100 {
+		r.u32() // cbPixelFormat
+		r.u32() // offPixelFormat
+		r.u32() // bOpenGL
+	}
+	if end >= 108 {
+		h.Micrometers = r.pointl()
+	}
+	if nDesc > 0 && offDesc >= 8 {
+		desc := r.utf16At(int(offDesc)-8, int(nDesc))
+		h.Description = strings.TrimRight(desc, "\x00")
+	}
+	return r.err
+}
+
+// reader decodes little endian fields, recording the first out of bounds
+// access in err.
+type reader struct {
+	b   []byte
+	off int
+	err error
+}
+
+func (r *reader) next(n int) []byte {
+	if r.err != nil || r.off+n > len(r.b) {
+		r.err = errShortRecord
+		return make([]byte, n)
+	}
+	b := r.b[r.off : r.off+n]
+	r.off += n
+	return b
+}
+
+func (r *reader) u16() uint16 { return binary.LittleEndian.Uint16(r.next(2)) }
+func (r *reader) u32() uint32 { return binary.LittleEndian.Uint32(r.next(4)) }
+func (r *reader) i16() int    { return int(int16(r.u16())) }
+func (r *reader) i32() int    { return int(int32(r.u32())) }
+
+func (r *reader) f32() float64 {
+	return float64(math.Float32frombits(r.u32()))
+}
+
+func (r *reader) pointl() image.Point {
+	x := r.i32()
+	return image.Point{X: x, Y: r.i32()}
+}
+
+func (r *reader) points() image.Point {
+	x := r.i16()
+	return image.Point{X: x, Y: r.i16()}
+}
+
+func (r *reader) rectl() image.Rectangle {
+	return image.Rectangle{Min: r.pointl(), Max: r.pointl()}
+}
+
+// at returns n bytes at the absolute offset off of the record data.
+func (r *reader) at(off, n int) []byte {
+	if r.err != nil || off < 0 || n < 0 || off+n > len(r.b) {
+		if r.err == nil {
+			r.err = errShortRecord
+		}
+		return nil
+	}
+	return r.b[off : off+n]
+}
+
+func (r *reader) utf16At(off, n int) string {
+	b := r.at(off, 2*n)
+	if b == nil {
+		return ""
+	}
+	u := make([]uint16, n)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(b[2*i:])
+	}
+	return string(utf16.Decode(u))
+}
@@ -0,0 +1,963 @@
+package emf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/sipkg/golang-win32-printer/device"
+	"github.com/sipkg/golang-win32-printer/device/fonts"
+)
+
+// Unsupported is a record Play could not render faithfully.
+type Unsupported struct {
+	// Index is the position of the record in Metafile.Records.
+	Index  int
+	Type   RecordType
+	Reason string
+}
+
+// UnsupportedError lists the records Play skipped or only approximated. It
+// is returned once every other record has been played.
+type UnsupportedError struct {
+	Records []Unsupported
+}
+
+func (e *UnsupportedError) Error() string {
+	first := e.Records[0]
+	return fmt.Sprintf("emf: %d unsupported records, first %s at %d: %s",
+		len(e.Records), first.Type, first.Index, first.Reason)
+}
+
+// Play draws the records of m onto the current page of c, mapping the
+// picture frame of the metafile onto dst. When dst is empty the picture is
+// drawn at its physical size, using the resolution given by the LogPixelsX
+// and LogPixelsY capabilities of c.
+//
+// Fills and pens other than the default one need c to implement
+// device.Painter. Records that cannot be rendered, such as paths and clipping,
+// are reported in an *UnsupportedError.
+func Play(c device.Canvas, m *Metafile, dst image.Rectangle) error {
+	p, err := newPlayer(c, m, dst)
+	if err != nil {
+		return err
+	}
+	for i, rec := range m.Records {
+		p.index, p.rec = i, rec
+		r := reader{b: rec.Data}
+		if err := p.play(&r); err != nil {
+			return fmt.Errorf("emf: play record %d (%s): %w", i, rec.Type, err)
+		}
+		if r.err != nil {
+			return fmt.Errorf("emf: record %d (%s): %w", i, rec.Type, r.err)
+		}
+	}
+	if len(p.unsupported) > 0 {
+		return &UnsupportedError{Records: p.unsupported}
+	}
+	return nil
+}
+
+type fpoint struct{ X, Y float64 }
+
+func (f fpoint) pt() image.Point {
+	return image.Point{X: int(math.Round(f.X)), Y: int(math.Round(f.Y))}
+}
+
+// xform is an XFORM world transform, applied to row vectors.
+type xform struct {
+	m11, m12, m21, m22, dx, dy float64
+}
+
+var identity = xform{m11: 1, m22: 1}
+
+func (a xform) apply(x, y float64) (float64, float64) {
+	return x*a.m11 + y*a.m21 + a.dx, x*a.m12 + y*a.m22 + a.dy
+}
+
+// mul returns the transform applying a, then b.
+func (a xform) mul(b xform) xform {
+	return xform{
+		m11: a.m11*b.m11 + a.m12*b.m21,
+		m12: a.m11*b.m12 + a.m12*b.m22,
+		m21: a.m21*b.m11 + a.m22*b.m21,
+		m22: a.m21*b.m12 + a.m22*b.m22,
+		dx:  a.dx*b.m11 + a.dy*b.m21 + b.dx,
+		dy:  a.dx*b.m12 + a.dy*b.m22 + b.dy,
+	}
+}
+
+type logicalFont struct {
+	height     int32
+	escapement int32
+	weight     uint32
+	italic     bool
+	face       string
+}
+
+type pen struct {
+	null  bool
+	color color.RGBA
+	// width is in logical units for geometric pens, in pixels otherwise.
+	width     float64
+	geometric bool
+}
+
+type brush struct {
+	null  bool
+	color color.RGBA
+}
+
+// patternBrush stands for the brushes made of a bitmap, which the player
+// cannot fill with.
+type patternBrush struct{}
+
+// dcState is the part of the playback device context saved by EMR_SAVEDC.
+type dcState struct {
+	mapMode        uint32
+	winOrg, winExt image.Point
+	vpOrg, vpExt   image.Point
+	world          xform
+	textAlign      uint32
+	textColor      color.RGBA
+	font           logicalFont
+	pen            pen
+	brush          any
+	// pos is the current position, in destination pixels.
+	pos fpoint
+}
+
+type player struct {
+	c       device.Canvas
+	painter device.Painter
+
+	// pxPerMM is the resolution of the reference device, and scale and
+	// offset map its pixels to the destination.
+	pxPerMM fpoint
+	scale   fpoint
+	offset  fpoint
+
+	dc      dcState
+	saved   []dcState
+	objects map[uint32]any
+
+	// The font, text color and pen last set on the canvas.
+	font      *device.Font
+	textColor *color.RGBA
+	pen       *pen
+
+	// maxTextSize is the size of the page, above which text is skipped.
+	maxTextSize float64
+
+	index       int
+	rec         Record
+	unsupported []Unsupported
+}
+
+func newPlayer(c device.Canvas, m *Metafile, dst image.Rectangle) (*player, error) {
+	h := m.Header
+	um := h.Micrometers
+	if um.X == 0 || um.Y == 0 {
+		um = h.Millimeters.Mul(1000)
+	}
+	if um.X <= 0 || um.Y <= 0 || h.Device.X <= 0 || h.Device.Y <= 0 {
+		return nil, errors.New("emf: header lacks the reference device size")
+	}
+	p := &player{
+		c: c,
+		pxPerMM: fpoint{
+			X: float64(h.Device.X) * 1000 / float64(um.X),
+			Y: float64(h.Device.Y) * 1000 / float64(um.Y),
+		},
+		dc: dcState{
+			mapMode:   MM_TEXT,
+			winExt:    image.Point{X: 1, Y: 1},
+			vpExt:     image.Point{X: 1, Y: 1},
+			world:     identity,
+			textColor: color.RGBA{A: 0xFF},
+			pen:       pen{color: color.RGBA{A: 0xFF}, width: 1},
+			brush:     brush{color: color.RGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}},
+		},
+		objects: map[uint32]any{},
+	}
+	p.painter, _ = c.(device.Painter)
+
+	// The frame, in .01 millimeters, is inclusive.
+	frameMM := fpoint{X: float64(h.Frame.Dx()+1) / 100, Y: float64(h.Frame.Dy()+1) / 100}
+	if dst.Empty() {
+		dpiX, err := c.GetDeviceCaps(device.LogPixelsX)
+		if err != nil {
+			return nil, err
+		}
+		dpiY, err := c.GetDeviceCaps(device.LogPixelsY)
+		if err != nil {
+			return nil, err
+		}
+		min := image.Point{
+			X: device.MMToPixels(float64(h.Frame.Min.X)/100, int(dpiX)),
+			Y: device.MMToPixels(float64(h.Frame.Min.Y)/100, int(dpiY)),
+		}
+		dst = image.Rectangle{Min: min, Max: min.Add(image.Point{
+			X: device.MMToPixels(frameMM.X, int(dpiX)),
+			Y: device.MMToPixels(frameMM.Y, int(dpiY)),
+		})}
+	}
+	horz, err := c.GetDeviceCaps(device.HorzRes)
+	if err != nil {
+		return nil, err
+	}
+	vert, err := c.GetDeviceCaps(device.VertRes)
+	if err != nil {
+		return nil, err
+	}
+	p.maxTextSize = float64(max(horz, vert))
+	p.scale = fpoint{
+		X: float64(dst.Dx()) / (frameMM.X * p.pxPerMM.X),
+		Y: float64(dst.Dy()) / (frameMM.Y * p.pxPerMM.Y),
+	}
+	p.offset = fpoint{
+		X: float64(dst.Min.X) - float64(h.Frame.Min.X)/100*p.pxPerMM.X*p.scale.X,
+		Y: float64(dst.Min.Y) - float64(h.Frame.Min.Y)/100*p.pxPerMM.Y*p.scale.Y,
+	}
+	return p, nil
+}
+
+// skip reports the current record as unsupported.
+func (p *player) skip(reason string) {
+	p.unsupported = append(p.unsupported, Unsupported{Index: p.index, Type: p.rec.Type, Reason: reason})
+}
+
+// viewScale returns the page to reference device scale of the mapping mode.
+func (p *player) viewScale() (float64, float64) {
+	unit := 0.0 // millimeters per logical unit
+	switch p.dc.mapMode {
+	case MM_LOMETRIC:
+		unit = 0.1
+	case MM_HIMETRIC:
+		unit = 0.01
+	case MM_LOENGLISH:
+		unit = 0.254
+	case MM_HIENGLISH:
+		unit = 0.0254
+	case MM_TWIPS:
+		unit = 25.4 / 1440
+	case MM_ISOTROPIC, MM_ANISOTROPIC:
+		if p.dc.winExt.X == 0 || p.dc.winExt.Y == 0 {
+			return 1, 1
+		}
+		sx := float64(p.dc.vpExt.X) / float64(p.dc.winExt.X)
+		sy := float64(p.dc.vpExt.Y) / float64(p.dc.winExt.Y)
+		if p.dc.mapMode == MM_ISOTROPIC {
+			s := math.Min(math.Abs(sx), math.Abs(sy))
+			sx, sy = math.Copysign(s, sx), math.Copysign(s, sy)
+		}
+		return sx, sy
+	default:
+		return 1, 1
+	}
+	// Metric and English modes have the y axis pointing up.
+	return p.pxPerMM.X * unit, -p.pxPerMM.Y * unit
+}
+
+// point maps logical coordinates to destination pixels.
+func (p *player) point(x, y float64) fpoint {
+	x, y = p.dc.world.apply(x, y)
+	sx, sy := p.viewScale()
+	x = (x-float64(p.dc.winOrg.X))*sx + float64(p.dc.vpOrg.X)
+	y = (y-float64(p.dc.winOrg.Y))*sy + float64(p.dc.vpOrg.Y)
+	return fpoint{X: x*p.scale.X + p.offset.X, Y: y*p.scale.Y + p.offset.Y}
+}
+
+func (p *player) points(pts []image.Point) []fpoint {
+	f := make([]fpoint, len(pts))
+	for i, pt := range pts {
+		f[i] = p.point(float64(pt.X), float64(pt.Y))
+	}
+	return f
+}
+
+// length returns the destination length of a vertical logical length.
+func (p *player) length(l float64) float64 {
+	a, b := p.point(0, 0), p.point(0, l)
+	return math.Hypot(b.X-a.X, b.Y-a.Y)
+}
+
+func (p *player) play(r *reader) error {
+	switch t := p.rec.Type; t {
+	case EMR_HEADER, EMR_EOF, EMR_COMMENT,
+		EMR_SETBKMODE, EMR_SETBKCOLOR, EMR_SETPOLYFILLMODE, EMR_SETROP2,
+		EMR_SETSTRETCHBLTMODE, EMR_SETMAPPERFLAGS, EMR_SETBRUSHORGEX,
+		EMR_SETMITERLIMIT, EMR_SETICMMODE, EMR_SETCOLORADJUSTMENT,
+		EMR_SETARCDIRECTION, EMR_SELECTPALETTE, EMR_CREATEPALETTE,
+		EMR_SETPALETTEENTRIES, EMR_RESIZEPALETTE, EMR_REALIZEPALETTE:
+		// Only set state the player does not use.
+	case EMR_SETMAPMODE:
+		p.dc.mapMode = r.u32()
+	case EMR_SETWINDOWORGEX:
+		p.dc.winOrg = r.pointl()
+	case EMR_SETWINDOWEXTEX:
+		p.dc.winExt = r.pointl()
+	case EMR_SETVIEWPORTORGEX:
+		p.dc.vpOrg = r.pointl()
+	case EMR_SETVIEWPORTEXTEX:
+		p.dc.vpExt = r.pointl()
+	case EMR_SCALEWINDOWEXTEX, EMR_SCALEVIEWPORTEXTEX:
+		xNum, xDenom, yNum, yDenom := r.i32(), r.i32(), r.i32(), r.i32()
+		ext := &p.dc.vpExt
+		if t == EMR_SCALEWINDOWEXTEX {
+			ext = &p.dc.winExt
+		}
+		if xDenom != 0 && yDenom != 0 {
+			*ext = image.Point{X: ext.X * xNum / xDenom, Y: ext.Y * yNum / yDenom}
+		}
+	case EMR_SETWORLDTRANSFORM:
+		p.dc.world = readXform(r)
+	case EMR_MODIFYWORLDTRANSFORM:
+		x := readXform(r)
+		switch r.u32() {
+		case MWT_IDENTITY:
+			p.dc.world = identity
+		case MWT_LEFTMULTIPLY:
+			p.dc.world = x.mul(p.dc.world)
+		case MWT_RIGHTMULTIPLY:
+			p.dc.world = p.dc.world.mul(x)
+		case MWT_SET:
+			p.dc.world = x
+		default:
+			p.skip("unknown transform mode")
+		}
+	case EMR_SAVEDC:
+		p.saved = append(p.saved, p.dc)
+	case EMR_RESTOREDC:
+		n := r.i32()
+		if n < 0 {
+			n += len(p.saved)
+		} else {
+			n--
+		}
+		if n < 0 || n >= len(p.saved) {
+			p.skip("no such saved state")
+			break
+		}
+		p.dc = p.saved[n]
+		p.saved = p.saved[:n]
+	case EMR_SETTEXTALIGN:
+		p.dc.textAlign = r.u32()
+	case EMR_SETTEXTCOLOR:
+		p.dc.textColor = readColor(r)
+	case EMR_EXTCREATEFONTINDIRECTW:
+		p.objects[r.u32()] = readLogFont(r)
+	case EMR_CREATEPEN:
+		index, style := r.u32(), r.u32()
+		width := r.pointl().X
+		p.objects[index] = pen{
+			null:      style&PS_STYLE_MASK == PS_NULL,
+			color:     readColor(r),
+			width:     float64(width),
+			geometric: true,
+		}
+	case EMR_EXTCREATEPEN:
+		index := r.u32()
+		r.u32() // offBmi
+		r.u32() // cbBmi
+		r.u32() // offBits
+		r.u32() // cbBits
+		style, width := r.u32(), r.u32()
+		r.u32() // brush style
+		p.objects[index] = pen{
+			null:      style&PS_STYLE_MASK == PS_NULL,
+			color:     readColor(r),
+			width:     float64(width),
+			geometric: style&PS_GEOMETRIC != 0,
+		}
+	case EMR_CREATEBRUSHINDIRECT:
+		index, style := r.u32(), r.u32()
+		c := readColor(r)
+		switch style {
+		case BS_SOLID, BS_HATCHED:
+			p.objects[index] = brush{color: c}
+		case BS_NULL:
+			p.objects[index] = brush{null: true}
+		default:
+			p.objects[index] = patternBrush{}
+			p.skip("pattern brush")
+		}
+	case EMR_CREATEMONOBRUSH, EMR_CREATEDIBPATTERNBRUSHPT:
+		p.objects[r.u32()] = patternBrush{}
+		p.skip("pattern brush")
+	case EMR_SELECTOBJECT:
+		p.selectObject(r.u32())
+	case EMR_DELETEOBJECT:
+		delete(p.objects, r.u32())
+	case EMR_EXTSELECTCLIPRGN:
+		size, mode := r.u32(), r.u32()
+		if size != 0 || mode != RGN_COPY {
+			p.skip("clipping")
+		}
+	case EMR_MOVETOEX:
+		pt := r.pointl()
+		p.dc.pos = p.point(float64(pt.X), float64(pt.Y))
+	case EMR_LINETO:
+		pt := r.pointl()
+		to := p.point(float64(pt.X), float64(pt.Y))
+		err := p.stroke([]fpoint{p.dc.pos, to})
+		p.dc.pos = to
+		return err
+	case EMR_POLYLINE, EMR_POLYLINE16, EMR_POLYLINETO, EMR_POLYLINETO16,
+		EMR_POLYBEZIER, EMR_POLYBEZIER16, EMR_POLYBEZIERTO, EMR_POLYBEZIERTO16:
+		r.rectl()
+		pts := p.points(readPoints(r, t))
+		to := t == EMR_POLYLINETO || t == EMR_POLYLINETO16 ||
+			t == EMR_POLYBEZIERTO || t == EMR_POLYBEZIERTO16
+		if to {
+			pts = append([]fpoint{p.dc.pos}, pts...)
+		}
+		if t == EMR_POLYBEZIER || t == EMR_POLYBEZIER16 || t == EMR_POLYBEZIERTO || t == EMR_POLYBEZIERTO16 {
+			pts = flatten(pts)
+		}
+		if to && len(pts) > 0 {
+			p.dc.pos = pts[len(pts)-1]
+		}
+		return p.stroke(pts)
+	case EMR_POLYPOLYLINE, EMR_POLYPOLYLINE16:
+		for _, pts := range readPolyPoints(r, t) {
+			if err := p.stroke(p.points(pts)); err != nil {
+				return err
+			}
+		}
+	case EMR_POLYGON, EMR_POLYGON16:
+		r.rectl()
+		return p.shape(p.points(readPoints(r, t)))
+	case EMR_POLYPOLYGON, EMR_POLYPOLYGON16:
+		for _, pts := range readPolyPoints(r, t) {
+			if err := p.shape(p.points(pts)); err != nil {
+				return err
+			}
+		}
+	case EMR_RECTANGLE:
+		b := r.rectl()
+		return p.shape(p.points([]image.Point{
+			b.Min, {X: b.Max.X, Y: b.Min.Y}, b.Max, {X: b.Min.X, Y: b.Max.Y},
+		}))
+	case EMR_ROUNDRECT:
+		b := r.rectl()
+		corner := r.pointl()
+		return p.shape(p.curve(roundRect(b, corner)))
+	case EMR_ELLIPSE:
+		b := r.rectl()
+		return p.shape(p.curve(roundRect(b, b.Size())))
+	case EMR_EXTTEXTOUTW, EMR_EXTTEXTOUTA:
+		r.rectl()
+		r.u32() // graphics mode
+		r.f32() // exScale
+		r.f32() // eyScale
+		ref := r.pointl()
+		n, off := int(r.u32()), int(r.u32())
+		var text string
+		if t == EMR_EXTTEXTOUTW {
+			text = r.utf16At(off-8, n)
+		} else {
+			// ANSI text is read as Latin-1.
+			var b strings.Builder
+			for _, c := range r.at(off-8, n) {
+				b.WriteRune(rune(c))
+			}
+			text = b.String()
+		}
+		if r.err != nil {
+			return nil
+		}
+		return p.text(p.point(float64(ref.X), float64(ref.Y)), text)
+	case EMR_STRETCHDIBITS:
+		return p.stretchDIBits(r)
+	case EMR_BEGINPATH, EMR_ENDPATH, EMR_CLOSEFIGURE, EMR_FILLPATH,
+		EMR_STROKEANDFILLPATH, EMR_STROKEPATH, EMR_FLATTENPATH,
+		EMR_WIDENPATH, EMR_ABORTPATH:
+		p.skip("paths")
+	case EMR_INTERSECTCLIPRECT, EMR_EXCLUDECLIPRECT, EMR_OFFSETCLIPRGN,
+		EMR_SELECTCLIPPATH, EMR_SETMETARGN:
+		p.skip("clipping")
+	default:
+		p.skip("record not implemented")
+	}
+	return nil
+}
+
+func (p *player) selectObject(index uint32) {
+	if index&STOCK_OBJECT != 0 {
+		gray := func(v uint8) color.RGBA { return color.RGBA{R: v, G: v, B: v, A: 0xFF} }
+		switch index {
+		case WHITE_BRUSH:
+			p.dc.brush = brush{color: gray(0xFF)}
+		case LTGRAY_BRUSH:
+			p.dc.brush = brush{color: gray(0xC0)}
+		case GRAY_BRUSH:
+			p.dc.brush = brush{color: gray(0x80)}
+		case DKGRAY_BRUSH:
+			p.dc.brush = brush{color: gray(0x40)}
+		case BLACK_BRUSH:
+			p.dc.brush = brush{color: gray(0)}
+		case NULL_BRUSH:
+			p.dc.brush = brush{null: true}
+		case WHITE_PEN:
+			p.dc.pen = pen{color: gray(0xFF), width: 1}
+		case BLACK_PEN:
+			p.dc.pen = pen{color: gray(0), width: 1}
+		case NULL_PEN:
+			p.dc.pen = pen{null: true}
+		default:
+			if index >= OEM_FIXED_FONT && index <= DEFAULT_GUI_FONT {
+				p.dc.font = logicalFont{}
+			} else {
+				p.skip("unknown stock object")
+			}
+		}
+		return
+	}
+	switch o := p.objects[index].(type) {
+	case logicalFont:
+		p.dc.font = o
+	case pen:
+		p.dc.pen = o
+	case brush, patternBrush:
+		p.dc.brush = o
+	default:
+		p.skip("unknown object")
+	}
+}
+
+// applyPen sets the current pen on the canvas, if it can change pens.
+func (p *player) applyPen() error {
+	if p.painter == nil || (p.pen != nil && *p.pen == p.dc.pen) {
+		return nil
+	}
+	width := p.dc.pen.width
+	if p.dc.pen.geometric {
+		width = p.length(width)
+	}
+	if err := p.painter.SetPen(p.dc.pen.color, uint32(math.Max(1, math.Round(width)))); err != nil {
+		return err
+	}
+	pen := p.dc.pen
+	p.pen = &pen
+	return nil
+}
+
+// stroke draws the lines joining pts with the current pen.
+func (p *player) stroke(pts []fpoint) error {
+	if p.dc.pen.null || len(pts) < 2 {
+		return nil
+	}
+	if err := p.applyPen(); err != nil {
+		return err
+	}
+	x, y := clamp(pts[0])
+	if err := p.c.MoveTo(x, y); err != nil {
+		return err
+	}
+	for _, pt := range pts[1:] {
+		x, y := clamp(pt)
+		if err := p.c.LineTo(x, y); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shape fills the polygon pts with the current brush and outlines it with
+// the current pen.
+func (p *player) shape(pts []fpoint) error {
+	if len(pts) < 2 {
+		return nil
+	}
+	switch b := p.dc.brush.(type) {
+	case brush:
+		if b.null {
+			break
+		}
+		if p.painter == nil {
+			p.skip("canvas cannot fill")
+			break
+		}
+		poly := make([]image.Point, len(pts))
+		for i, pt := range pts {
+			poly[i] = pt.pt()
+		}
+		if err := p.painter.FillPolygon(poly, b.color); err != nil {
+			return err
+		}
+	case patternBrush:
+		p.skip("pattern brush")
+	}
+	return p.stroke(append(pts, pts[0]))
+}
+
+// curve maps points computed in logical units.
+func (p *player) curve(pts []fpoint) []fpoint {
+	for i, pt := range pts {
+		pts[i] = p.point(pt.X, pt.Y)
+	}
+	return pts
+}
+
+// roundRect approximates the rectangle b with corners rounded by ellipses
+// of the given size, so that an ellipse is a rectangle with round corners
+// as large as itself.
+func roundRect(b image.Rectangle, corner image.Point) []fpoint {
+	const steps = 16 // per quarter
+	rx := math.Min(math.Abs(float64(corner.X)), math.Abs(float64(b.Dx()))) / 2
+	ry := math.Min(math.Abs(float64(corner.Y)), math.Abs(float64(b.Dy()))) / 2
+	centers := []fpoint{
+		{X: float64(b.Max.X) - rx, Y: float64(b.Min.Y) + ry},
+		{X: float64(b.Min.X) + rx, Y: float64(b.Min.Y) + ry},
+		{X: float64(b.Min.X) + rx, Y: float64(b.Max.Y) - ry},
+		{X: float64(b.Max.X) - rx, Y: float64(b.Max.Y) - ry},
+	}
+	var pts []fpoint
+	for q, c := range centers {
+		for i := 0; i <= steps; i++ {
+			a := (float64(q) + float64(i)/steps) * math.Pi / 2
+			pts = append(pts, fpoint{X: c.X + rx*math.Cos(a), Y: c.Y - ry*math.Sin(a)})
+		}
+	}
+	return pts
+}
+
+// flatten replaces the cubic Bézier curves of pts, a start point followed
+// by control, control and end point triples, with line segments.
+func flatten(pts []fpoint) []fpoint {
+	const steps = 16
+	if len(pts) == 0 {
+		return nil
+	}
+	out := []fpoint{pts[0]}
+	for i := 1; i+2 < len(pts); i += 3 {
+		p0, p1, p2, p3 := pts[i-1], pts[i], pts[i+1], pts[i+2]
+		for s := 1; s <= steps; s++ {
+			t := float64(s) / steps
+			u := 1 - t
+			a, b, c, d := u*u*u, 3*u*u*t, 3*u*t*t, t*t*t
+			out = append(out, fpoint{
+				X: a*p0.X + b*p1.X + c*p2.X + d*p3.X,
+				Y: a*p0.Y + b*p1.Y + c*p2.Y + d*p3.Y,
+			})
+		}
+	}
+	return out
+}
+
+// text draws text at the reference point ref, aligned as set by
+// EMR_SETTEXTALIGN.
+func (p *player) text(ref fpoint, text string) error {
+	if text == "" {
+		return nil
+	}
+	lf := p.dc.font
+	if lf.escapement != 0 {
+		p.skip("rotated text drawn horizontally")
+	}
+	f := device.Font{Name: lf.face, Bold: lf.weight >= 600, Italic: lf.italic}
+	if f.Name == "" {
+		f.Name = fonts.Default
+	}
+	ttf := fonts.Lookup(f.Name, f.Bold, f.Italic)
+	var size float64
+	switch {
+	case lf.height < 0:
+		size = p.length(float64(-lf.height))
+	case lf.height > 0:
+		// A positive height is the cell height, ascent plus descent.
+		_, cell, err := ttf.Metrics("", 1)
+		if err != nil {
+			return err
+		}
+		size = p.length(float64(lf.height)) / cell
+	default:
+		// GDI picks a 12 points font for a zero height.
+		size = 12 * 25.4 / 72 * p.pxPerMM.Y * p.scale.Y
+	}
+	if !(size <= p.maxTextSize) {
+		p.skip("font larger than the page")
+		return nil
+	}
+	f.Height = int32(math.Round(size))
+	if err := p.applyFont(f); err != nil {
+		return err
+	}
+	if p.textColor == nil || *p.textColor != p.dc.textColor {
+		if _, err := p.c.SetTextColor(p.dc.textColor); err != nil {
+			return err
+		}
+		c := p.dc.textColor
+		p.textColor = &c
+	}
+
+	w, h, err := p.c.GetTextExtentPoint32(text)
+	if err != nil {
+		return err
+	}
+	align := p.dc.textAlign
+	if align&TA_UPDATECP != 0 {
+		ref = p.dc.pos
+	}
+	pos := ref
+	advance := float64(w)
+	switch align & TA_CENTER {
+	case TA_RIGHT:
+		pos.X -= float64(w)
+		advance = -advance
+	case TA_CENTER:
+		pos.X -= float64(w) / 2
+		advance = 0
+	}
+	switch align & TA_BASELINE {
+	case TA_BASELINE:
+		ascent, err := ttf.Ascent(float64(f.Height))
+		if err != nil {
+			return err
+		}
+		pos.Y -= ascent
+	case TA_BOTTOM:
+		pos.Y -= float64(h)
+	}
+	if align&TA_UPDATECP != 0 {
+		p.dc.pos.X += advance
+	}
+	x, y := clamp(pos)
+	return p.c.TextOut(x, y, text)
+}
+
+// applyFont selects f on the canvas, changing only what differs.
+func (p *player) applyFont(f device.Font) error {
+	cur := p.font
+	if cur != nil && *cur == f {
+		return nil
+	}
+	if cur == nil || cur.Name != f.Name {
+		if err := p.c.SetFont(f.Name); err != nil {
+			return err
+		}
+	}
+	if cur == nil || cur.Height != f.Height {
+		if _, err := p.c.SetTextSize(f.Height); err != nil {
+			return err
+		}
+	}
+	if cur == nil || cur.Bold != f.Bold {
+		if err := p.c.SetBoldFont(f.Bold); err != nil {
+			return err
+		}
+	}
+	if cur == nil || cur.Italic != f.Italic {
+		if err := p.c.SetItalicFont(f.Italic); err != nil {
+			return err
+		}
+	}
+	p.font = &f
+	return nil
+}
+
+func (p *player) stretchDIBits(r *reader) error {
+	r.rectl()
+	dest, src, srcSize := r.pointl(), r.pointl(), r.pointl()
+	offBmi, cbBmi := int(r.u32()), int(r.u32())
+	offBits, cbBits := int(r.u32()), int(r.u32())
+	usage := r.u32()
+	r.u32() // raster operation
+	destSize := r.pointl()
+	if r.err != nil {
+		return nil
+	}
+	if cbBmi == 0 {
+		p.skip("pattern fill without bitmap")
+		return nil
+	}
+	if usage != DIB_RGB_COLORS {
+		p.skip("palette indexed colors")
+		return nil
+	}
+	img, bottomUp, err := decodeDIB(r.at(offBmi-8, cbBmi), r.at(offBits-8, cbBits))
+	if err != nil {
+		p.skip(err.Error())
+		return nil
+	}
+	y := src.Y
+	if bottomUp {
+		y = img.Bounds().Dy() - src.Y - srcSize.Y
+	}
+	sub := img.SubImage(image.Rect(src.X, y, src.X+srcSize.X, y+srcSize.Y))
+
+	a := p.point(float64(dest.X), float64(dest.Y))
+	b := p.point(float64(dest.X+destSize.X), float64(dest.Y+destSize.Y))
+	rect := image.Rectangle{Min: a.pt(), Max: b.pt()}.Canon()
+	if rect.Empty() || sub.Bounds().Empty() {
+		return nil
+	}
+	x0, y0 := clamp(fpoint{X: float64(rect.Min.X), Y: float64(rect.Min.Y)})
+	return p.c.DrawImage(x0, y0, uint32(rect.Dx()), uint32(rect.Dy()), sub)
+}
+
+// maxDIBSize bounds the width and height of the bitmaps a metafile
+// embeds, so that a corrupt header cannot claim gigabytes.
+const maxDIBSize = 1 << 15
+
+// decodeDIB decodes an uncompressed device independent bitmap, telling
+// whether its rows are stored bottom-up.
+func decodeDIB(bmi, bits []byte) (*image.RGBA, bool, error) {
+	if len(bmi) < 40 {
+		return nil, false, errors.New("bad bitmap header")
+	}
+	le := binary.LittleEndian
+	headerSize := int(le.Uint32(bmi[0:]))
+	width := int(int32(le.Uint32(bmi[4:])))
+	height := int(int32(le.Uint32(bmi[8:])))
+	bpp := int(le.Uint16(bmi[14:]))
+	compression := le.Uint32(bmi[16:])
+	colorsUsed := int(le.Uint32(bmi[32:]))
+	bottomUp := height > 0
+	if height < 0 {
+		height = -height
+	}
+	if width <= 0 || compression != BI_RGB && !(compression == BI_BITFIELDS && bpp == 32) {
+		return nil, false, fmt.Errorf("compressed bitmap (%d)", compression)
+	}
+	switch bpp {
+	case 1, 4, 8, 16, 24, 32:
+	default:
+		return nil, false, fmt.Errorf("%d bits per pixel bitmap", bpp)
+	}
+	if headerSize < 40 || headerSize > len(bmi) || width > maxDIBSize || height < 0 || height > maxDIBSize {
+		return nil, false, errors.New("bad bitmap header")
+	}
+
+	var palette []color.RGBA
+	if bpp <= 8 {
+		if colorsUsed == 0 {
+			colorsUsed = 1 << bpp
+		}
+		for i := 0; i < colorsUsed && headerSize+4*i+4 <= len(bmi); i++ {
+			q := bmi[headerSize+4*i:]
+			palette = append(palette, color.RGBA{R: q[2], G: q[1], B: q[0], A: 0xFF})
+		}
+	}
+	stride := pad4((width*bpp + 7) / 8)
+	if len(bits)/stride < height {
+		return nil, false, errors.New("truncated bitmap")
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		row := bits[y*stride:]
+		dy := y
+		if bottomUp {
+			dy = height - 1 - y
+		}
+		for x := 0; x < width; x++ {
+			var c color.RGBA
+			switch bpp {
+			case 1, 4, 8:
+				bit := x * bpp
+				i := int(row[bit/8]>>(8-bpp-bit%8)) & (1<<bpp - 1)
+				if i < len(palette) {
+					c = palette[i]
+				}
+			case 16:
+				v := le.Uint16(row[2*x:])
+				c = color.RGBA{R: uint8(v >> 10 & 31 * 255 / 31), G: uint8(v >> 5 & 31 * 255 / 31), B: uint8(v & 31 * 255 / 31), A: 0xFF}
+			case 24:
+				c = color.RGBA{R: row[3*x+2], G: row[3*x+1], B: row[3*x], A: 0xFF}
+			case 32:
+				c = color.RGBA{R: row[4*x+2], G: row[4*x+1], B: row[4*x], A: 0xFF}
+			}
+			img.SetRGBA(x, dy, c)
+		}
+	}
+	return img, bottomUp, nil
+}
+
+func readXform(r *reader) xform {
+	return xform{m11: r.f32(), m12: r.f32(), m21: r.f32(), m22: r.f32(), dx: r.f32(), dy: r.f32()}
+}
+
+// readColor reads a COLORREF.
+func readColor(r *reader) color.RGBA {
+	v := r.u32()
+	return color.RGBA{R: uint8(v), G: uint8(v >> 8), B: uint8(v >> 16), A: 0xFF}
+}
+
+func readLogFont(r *reader) logicalFont {
+	lf := logicalFont{height: int32(r.i32())}
+	r.i32() // width
+	lf.escapement = int32(r.i32())
+	r.i32() // orientation
+	lf.weight = r.u32()
+	attrs := r.next(8)
+	lf.italic = attrs[0] != 0
+	face := r.utf16At(r.off, 32)
+	lf.face, _, _ = strings.Cut(face, "\x00")
+	return lf
+}
+
+// is16 tells whether t stores its points as 16 bits POINTS.
+func is16(t RecordType) bool {
+	return t >= EMR_POLYBEZIER16 && t <= EMR_POLYDRAW16
+}
+
+func readPoint(r *reader, t RecordType) image.Point {
+	if is16(t) {
+		return r.points()
+	}
+	return r.pointl()
+}
+
+// readPoints reads the count and points of a poly record.
+func readPoints(r *reader, t RecordType) []image.Point {
+	n := int(r.u32())
+	if n > len(r.b) {
+		r.err = errShortRecord
+		return nil
+	}
+	pts := make([]image.Point, n)
+	for i := range pts {
+		pts[i] = readPoint(r, t)
+	}
+	return pts
+}
+
+// readPolyPoints reads the bounds, counts and points of a poly-poly record.
+func readPolyPoints(r *reader, t RecordType) [][]image.Point {
+	r.rectl()
+	n, total := int(r.u32()), int(r.u32())
+	if n > len(r.b) || total > len(r.b) {
+		r.err = errShortRecord
+		return nil
+	}
+	counts := make([]int, n)
+	for i := range counts {
+		counts[i] = int(r.u32())
+	}
+	polys := make([][]image.Point, 0, n)
+	for _, c := range counts {
+		if c > total {
+			r.err = errShortRecord
+			return nil
+		}
+		total -= c
+		pts := make([]image.Point, c)
+		for i := range pts {
+			pts[i] = readPoint(r, t)
+		}
+		polys = append(polys, pts)
+	}
+	return polys
+}
+
+// clamp converts a destination point to canvas coordinates, which cannot
+// be negative.
+func clamp(f fpoint) (uint32, uint32) {
+	pt := f.pt()
+	return uint32(max(pt.X, 0)), uint32(max(pt.Y, 0))
+}
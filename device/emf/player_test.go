@@ -0,0 +1,224 @@
+package emf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/sipkg/golang-win32-printer/device"
+	"github.com/sipkg/golang-win32-printer/device/raster"
+)
+
+var inch = device.PageSize{Width: 25.4, Height: 25.4}
+
+// draw draws the same page on any device.
+func draw(t testing.TB, d device.Device) {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, color.RGBA{R: 0xFF, A: 0xFF})
+	img.Set(1, 1, color.RGBA{B: 0xFF, A: 0xFF})
+	d.StartDoc("test")
+	d.StartPage()
+	d.SetFont("Courier New")
+	d.SetTextSize(20)
+	d.TextOut(5, 5, "Total")
+	d.SetBoldFont(true)
+	d.SetTextColor(color.RGBA{G: 0x80, A: 0xFF})
+	d.TextOut(5, 30, "42.00")
+	d.MoveTo(5, 60)
+	d.LineTo(95, 60)
+	if err := d.DrawImage(60, 70, 20, 20, img); err != nil {
+		t.Fatal(err)
+	}
+	d.EndPage()
+	d.EndDoc()
+}
+
+// rec encodes a record made of 32 bits fields.
+func rec(t RecordType, fields ...uint32) []byte {
+	b := binary.LittleEndian.AppendUint32(nil, uint32(t))
+	b = binary.LittleEndian.AppendUint32(b, uint32(8+4*len(fields)))
+	for _, f := range fields {
+		b = binary.LittleEndian.AppendUint32(b, f)
+	}
+	return b
+}
+
+// insert inserts records before the EMR_EOF record of the metafile b.
+func insert(b []byte, records ...[]byte) []byte {
+	const eofSize = 20
+	out := append([]byte{}, b[:len(b)-eofSize]...)
+	for _, r := range records {
+		out = append(out, r...)
+	}
+	return append(out, b[len(b)-eofSize:]...)
+}
+
+func emptyPage(t *testing.T) []byte {
+	t.Helper()
+	w := NewWriter(inch, 100)
+	w.StartDoc("test")
+	w.StartPage()
+	w.EndPage()
+	return w.Pages[0]
+}
+
+func TestParse(t *testing.T) {
+	w := NewWriter(inch, 100)
+	draw(t, w)
+	m, err := Parse(w.Pages[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := m.Header
+	if h.Device != image.Pt(100, 100) || h.Micrometers != image.Pt(25400, 25400) {
+		t.Errorf("reference device %v px, %v µm", h.Device, h.Micrometers)
+	}
+	if h.Frame != image.Rect(0, 0, 2539, 2539) {
+		t.Errorf("Frame = %v", h.Frame)
+	}
+	if h.Description != "golang-win32-printer\x00test" {
+		t.Errorf("Description = %q", h.Description)
+	}
+	if int(h.Records) != len(m.Records) || int(h.Bytes) != len(w.Pages[0]) {
+		t.Errorf("header counts %d records, %d bytes", h.Records, h.Bytes)
+	}
+
+	if _, err := Parse(w.Pages[0][:len(w.Pages[0])-20]); err == nil {
+		t.Error("metafile without EMR_EOF parsed")
+	}
+	if _, err := Parse(w.Pages[0][100:]); err == nil {
+		t.Error("metafile without EMR_HEADER parsed")
+	}
+}
+
+// TestPlay checks that playing a metafile onto a raster draws the same
+// pixels as drawing directly on it.
+func TestPlay(t *testing.T) {
+	want := raster.New(inch, 100)
+	draw(t, want)
+
+	w := NewWriter(inch, 100)
+	draw(t, w)
+	m, err := Parse(w.Pages[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := raster.New(inch, 100)
+	got.StartDoc("test")
+	got.StartPage()
+	if err := Play(got, m, image.Rectangle{}); err != nil {
+		t.Fatal(err)
+	}
+	got.EndPage()
+	if !bytes.Equal(got.Pages[0].Pix, want.Pages[0].Pix) {
+		t.Error("played metafile differs from direct rendering")
+	}
+}
+
+func TestPlayMapMode(t *testing.T) {
+	b := insert(emptyPage(t),
+		rec(EMR_SETMAPMODE, MM_LOMETRIC),
+		rec(EMR_SELECTOBJECT, BLACK_BRUSH),
+		rec(EMR_SELECTOBJECT, NULL_PEN),
+		// 5 to 15 millimeters down and right, y pointing up.
+		rec(EMR_RECTANGLE, 50, -50&0xFFFFFFFF, 150, -150&0xFFFFFFFF),
+	)
+	m, err := Parse(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := raster.New(device.PageSize{Width: 50.8, Height: 50.8}, 100)
+	r.StartDoc("test")
+	r.StartPage()
+	// Play at twice the size.
+	if err := Play(r, m, image.Rect(0, 0, 200, 200)); err != nil {
+		t.Fatal(err)
+	}
+	dark := func(x, y int) bool {
+		cr, _, _, _ := r.Page().At(x, y).RGBA()
+		return cr < 0x8000
+	}
+	if !dark(40, 40) || !dark(110, 110) {
+		t.Error("rectangle not filled")
+	}
+	if dark(35, 40) || dark(40, 35) || dark(125, 40) {
+		t.Error("rectangle filled outside of its bounds")
+	}
+}
+
+func TestPlayUnsupported(t *testing.T) {
+	b := insert(emptyPage(t),
+		rec(EMR_BEGINPATH),
+		rec(EMR_SELECTOBJECT, BLACK_PEN),
+		rec(EMR_MOVETOEX, 0, 50),
+		rec(EMR_LINETO, 99, 50),
+		rec(EMR_INTERSECTCLIPRECT, 0, 0, 10, 10),
+	)
+	m, err := Parse(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := raster.New(inch, 100)
+	r.StartDoc("test")
+	r.StartPage()
+	err = Play(r, m, image.Rectangle{})
+	var u *UnsupportedError
+	if !errors.As(err, &u) {
+		t.Fatalf("Play() = %v, want an UnsupportedError", err)
+	}
+	if len(u.Records) != 2 || u.Records[0].Type != EMR_BEGINPATH || u.Records[1].Type != EMR_INTERSECTCLIPRECT {
+		t.Errorf("unsupported records = %+v", u.Records)
+	}
+	if c, _, _, _ := r.Page().At(50, 50).RGBA(); c > 0x8000 {
+		t.Error("supported records not played")
+	}
+}
+
+func TestDecodeDIBMalformed(t *testing.T) {
+	header := func(width, height int32, bpp uint16) []byte {
+		b := make([]byte, 40)
+		le := binary.LittleEndian
+		le.PutUint32(b[0:], 40)
+		le.PutUint32(b[4:], uint32(width))
+		le.PutUint32(b[8:], uint32(height))
+		le.PutUint16(b[12:], 1)
+		le.PutUint16(b[14:], bpp)
+		return b
+	}
+	for name, bmi := range map[string][]byte{
+		"no bits per pixel": header(1<<30, 1<<30, 0),
+		"2 bits per pixel":  header(4, 4, 2),
+		"too wide":          header(1<<30, 1, 1),
+		"too high":          header(1, -(1 << 30), 32),
+		"huge":              header(1<<15, 1<<15, 32),
+		"short header":      header(4, 4, 24)[:32],
+	} {
+		if _, _, err := decodeDIB(bmi, make([]byte, 64)); err == nil {
+			t.Errorf("%s: decoded", name)
+		}
+	}
+	img, bottomUp, err := decodeDIB(header(2, 2, 24), make([]byte, 16))
+	if err != nil || img.Bounds().Dx() != 2 || !bottomUp {
+		t.Errorf("decodeDIB() = %v, %v, %v", img.Bounds(), bottomUp, err)
+	}
+}
+
+func FuzzPlay(f *testing.F) {
+	w := NewWriter(inch, 100)
+	draw(f, w)
+	f.Add(w.Pages[0])
+	f.Fuzz(func(t *testing.T, b []byte) {
+		m, err := Parse(b)
+		if err != nil {
+			return
+		}
+		r := raster.New(inch, 100)
+		r.StartDoc("fuzz")
+		r.StartPage()
+		Play(r, m, image.Rectangle{})
+	})
+}
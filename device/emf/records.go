@@ -0,0 +1,346 @@
+package emf
+
+import "fmt"
+
+// RecordType identifies an EMF record, see [MS-EMF] 2.1.1.
+type RecordType uint32
+
+const (
+	EMR_HEADER                  RecordType = 1
+	EMR_POLYBEZIER              RecordType = 2
+	EMR_POLYGON                 RecordType = 3
+	EMR_POLYLINE                RecordType = 4
+	EMR_POLYBEZIERTO            RecordType = 5
+	EMR_POLYLINETO              RecordType = 6
+	EMR_POLYPOLYLINE            RecordType = 7
+	EMR_POLYPOLYGON             RecordType = 8
+	EMR_SETWINDOWEXTEX          RecordType = 9
+	EMR_SETWINDOWORGEX          RecordType = 10
+	EMR_SETVIEWPORTEXTEX        RecordType = 11
+	EMR_SETVIEWPORTORGEX        RecordType = 12
+	EMR_SETBRUSHORGEX           RecordType = 13
+	EMR_EOF                     RecordType = 14
+	EMR_SETPIXELV               RecordType = 15
+	EMR_SETMAPPERFLAGS          RecordType = 16
+	EMR_SETMAPMODE              RecordType = 17
+	EMR_SETBKMODE               RecordType = 18
+	EMR_SETPOLYFILLMODE         RecordType = 19
+	EMR_SETROP2                 RecordType = 20
+	EMR_SETSTRETCHBLTMODE       RecordType = 21
+	EMR_SETTEXTALIGN            RecordType = 22
+	EMR_SETCOLORADJUSTMENT      RecordType = 23
+	EMR_SETTEXTCOLOR            RecordType = 24
+	EMR_SETBKCOLOR              RecordType = 25
+	EMR_OFFSETCLIPRGN           RecordType = 26
+	EMR_MOVETOEX                RecordType = 27
+	EMR_SETMETARGN              RecordType = 28
+	EMR_EXCLUDECLIPRECT         RecordType = 29
+	EMR_INTERSECTCLIPRECT       RecordType = 30
+	EMR_SCALEVIEWPORTEXTEX      RecordType = 31
+	EMR_SCALEWINDOWEXTEX        RecordType = 32
+	EMR_SAVEDC                  RecordType = 33
+	EMR_RESTOREDC               RecordType = 34
+	EMR_SETWORLDTRANSFORM       RecordType = 35
+	EMR_MODIFYWORLDTRANSFORM    RecordType = 36
+	EMR_SELECTOBJECT            RecordType = 37
+	EMR_CREATEPEN               RecordType = 38
+	EMR_CREATEBRUSHINDIRECT     RecordType = 39
+	EMR_DELETEOBJECT            RecordType = 40
+	EMR_ANGLEARC                RecordType = 41
+	EMR_ELLIPSE                 RecordType = 42
+	EMR_RECTANGLE               RecordType = 43
+	EMR_ROUNDRECT               RecordType = 44
+	EMR_ARC                     RecordType = 45
+	EMR_CHORD                   RecordType = 46
+	EMR_PIE                     RecordType = 47
+	EMR_SELECTPALETTE           RecordType = 48
+	EMR_CREATEPALETTE           RecordType = 49
+	EMR_SETPALETTEENTRIES       RecordType = 50
+	EMR_RESIZEPALETTE           RecordType = 51
+	EMR_REALIZEPALETTE          RecordType = 52
+	EMR_EXTFLOODFILL            RecordType = 53
+	EMR_LINETO                  RecordType = 54
+	EMR_ARCTO                   RecordType = 55
+	EMR_POLYDRAW                RecordType = 56
+	EMR_SETARCDIRECTION         RecordType = 57
+	EMR_SETMITERLIMIT           RecordType = 58
+	EMR_BEGINPATH               RecordType = 59
+	EMR_ENDPATH                 RecordType = 60
+	EMR_CLOSEFIGURE             RecordType = 61
+	EMR_FILLPATH                RecordType = 62
+	EMR_STROKEANDFILLPATH       RecordType = 63
+	EMR_STROKEPATH              RecordType = 64
+	EMR_FLATTENPATH             RecordType = 65
+	EMR_WIDENPATH               RecordType = 66
+	EMR_SELECTCLIPPATH          RecordType = 67
+	EMR_ABORTPATH               RecordType = 68
+	EMR_COMMENT                 RecordType = 70
+	EMR_FILLRGN                 RecordType = 71
+	EMR_FRAMERGN                RecordType = 72
+	EMR_INVERTRGN               RecordType = 73
+	EMR_PAINTRGN                RecordType = 74
+	EMR_EXTSELECTCLIPRGN        RecordType = 75
+	EMR_BITBLT                  RecordType = 76
+	EMR_STRETCHBLT              RecordType = 77
+	EMR_MASKBLT                 RecordType = 78
+	EMR_PLGBLT                  RecordType = 79
+	EMR_SETDIBITSTODEVICE       RecordType = 80
+	EMR_STRETCHDIBITS           RecordType = 81
+	EMR_EXTCREATEFONTINDIRECTW  RecordType = 82
+	EMR_EXTTEXTOUTA             RecordType = 83
+	EMR_EXTTEXTOUTW             RecordType = 84
+	EMR_POLYBEZIER16            RecordType = 85
+	EMR_POLYGON16               RecordType = 86
+	EMR_POLYLINE16              RecordType = 87
+	EMR_POLYBEZIERTO16          RecordType = 88
+	EMR_POLYLINETO16            RecordType = 89
+	EMR_POLYPOLYLINE16          RecordType = 90
+	EMR_POLYPOLYGON16           RecordType = 91
+	EMR_POLYDRAW16              RecordType = 92
+	EMR_CREATEMONOBRUSH         RecordType = 93
+	EMR_CREATEDIBPATTERNBRUSHPT RecordType = 94
+	EMR_EXTCREATEPEN            RecordType = 95
+	EMR_POLYTEXTOUTA            RecordType = 96
+	EMR_POLYTEXTOUTW            RecordType = 97
+	EMR_SETICMMODE              RecordType = 98
+	EMR_CREATECOLORSPACE        RecordType = 99
+	EMR_SETCOLORSPACE           RecordType = 100
+	EMR_DELETECOLORSPACE        RecordType = 101
+	EMR_GLSRECORD               RecordType = 102
+	EMR_GLSBOUNDEDRECORD        RecordType = 103
+	EMR_PIXELFORMAT             RecordType = 104
+	EMR_SMALLTEXTOUT            RecordType = 108
+	EMR_FORCEUFIMAPPING         RecordType = 109
+	EMR_NAMEDESCAPE             RecordType = 110
+	EMR_COLORCORRECTPALETTE     RecordType = 111
+	EMR_SETICMPROFILEA          RecordType = 112
+	EMR_SETICMPROFILEW          RecordType = 113
+	EMR_ALPHABLEND              RecordType = 114
+	EMR_SETLAYOUT               RecordType = 115
+	EMR_TRANSPARENTBLT          RecordType = 116
+	EMR_GRADIENTFILL            RecordType = 118
+	EMR_SETLINKEDUFIS           RecordType = 119
+	EMR_SETTEXTJUSTIFICATION    RecordType = 120
+	EMR_COLORMATCHTOTARGETW     RecordType = 121
+	EMR_CREATECOLORSPACEW       RecordType = 122
+)
+
+var recordNames = map[RecordType]string{
+	EMR_HEADER:                  "EMR_HEADER",
+	EMR_POLYBEZIER:              "EMR_POLYBEZIER",
+	EMR_POLYGON:                 "EMR_POLYGON",
+	EMR_POLYLINE:                "EMR_POLYLINE",
+	EMR_POLYBEZIERTO:            "EMR_POLYBEZIERTO",
+	EMR_POLYLINETO:              "EMR_POLYLINETO",
+	EMR_POLYPOLYLINE:            "EMR_POLYPOLYLINE",
+	EMR_POLYPOLYGON:             "EMR_POLYPOLYGON",
+	EMR_SETWINDOWEXTEX:          "EMR_SETWINDOWEXTEX",
+	EMR_SETWINDOWORGEX:          "EMR_SETWINDOWORGEX",
+	EMR_SETVIEWPORTEXTEX:        "EMR_SETVIEWPORTEXTEX",
+	EMR_SETVIEWPORTORGEX:        "EMR_SETVIEWPORTORGEX",
+	EMR_SETBRUSHORGEX:           "EMR_SETBRUSHORGEX",
+	EMR_EOF:                     "EMR_EOF",
+	EMR_SETPIXELV:               "EMR_SETPIXELV",
+	EMR_SETMAPPERFLAGS:          "EMR_SETMAPPERFLAGS",
+	EMR_SETMAPMODE:              "EMR_SETMAPMODE",
+	EMR_SETBKMODE:               "EMR_SETBKMODE",
+	EMR_SETPOLYFILLMODE:         "EMR_SETPOLYFILLMODE",
+	EMR_SETROP2:                 "EMR_SETROP2",
+	EMR_SETSTRETCHBLTMODE:       "EMR_SETSTRETCHBLTMODE",
+	EMR_SETTEXTALIGN:            "EMR_SETTEXTALIGN",
+	EMR_SETCOLORADJUSTMENT:      "EMR_SETCOLORADJUSTMENT",
+	EMR_SETTEXTCOLOR:            "EMR_SETTEXTCOLOR",
+	EMR_SETBKCOLOR:              "EMR_SETBKCOLOR",
+	EMR_OFFSETCLIPRGN:           "EMR_OFFSETCLIPRGN",
+	EMR_MOVETOEX:                "EMR_MOVETOEX",
+	EMR_SETMETARGN:              "EMR_SETMETARGN",
+	EMR_EXCLUDECLIPRECT:         "EMR_EXCLUDECLIPRECT",
+	EMR_INTERSECTCLIPRECT:       "EMR_INTERSECTCLIPRECT",
+	EMR_SCALEVIEWPORTEXTEX:      "EMR_SCALEVIEWPORTEXTEX",
+	EMR_SCALEWINDOWEXTEX:        "EMR_SCALEWINDOWEXTEX",
+	EMR_SAVEDC:                  "EMR_SAVEDC",
+	EMR_RESTOREDC:               "EMR_RESTOREDC",
+	EMR_SETWORLDTRANSFORM:       "EMR_SETWORLDTRANSFORM",
+	EMR_MODIFYWORLDTRANSFORM:    "EMR_MODIFYWORLDTRANSFORM",
+	EMR_SELECTOBJECT:            "EMR_SELECTOBJECT",
+	EMR_CREATEPEN:               "EMR_CREATEPEN",
+	EMR_CREATEBRUSHINDIRECT:     "EMR_CREATEBRUSHINDIRECT",
+	EMR_DELETEOBJECT:            "EMR_DELETEOBJECT",
+	EMR_ANGLEARC:                "EMR_ANGLEARC",
+	EMR_ELLIPSE:                 "EMR_ELLIPSE",
+	EMR_RECTANGLE:               "EMR_RECTANGLE",
+	EMR_ROUNDRECT:               "EMR_ROUNDRECT",
+	EMR_ARC:                     "EMR_ARC",
+	EMR_CHORD:                   "EMR_CHORD",
+	EMR_PIE:                     "EMR_PIE",
+	EMR_SELECTPALETTE:           "EMR_SELECTPALETTE",
+	EMR_CREATEPALETTE:           "EMR_CREATEPALETTE",
+	EMR_SETPALETTEENTRIES:       "EMR_SETPALETTEENTRIES",
+	EMR_RESIZEPALETTE:           "EMR_RESIZEPALETTE",
+	EMR_REALIZEPALETTE:          "EMR_REALIZEPALETTE",
+	EMR_EXTFLOODFILL:            "EMR_EXTFLOODFILL",
+	EMR_LINETO:                  "EMR_LINETO",
+	EMR_ARCTO:                   "EMR_ARCTO",
+	EMR_POLYDRAW:                "EMR_POLYDRAW",
+	EMR_SETARCDIRECTION:         "EMR_SETARCDIRECTION",
+	EMR_SETMITERLIMIT:           "EMR_SETMITERLIMIT",
+	EMR_BEGINPATH:               "EMR_BEGINPATH",
+	EMR_ENDPATH:                 "EMR_ENDPATH",
+	EMR_CLOSEFIGURE:             "EMR_CLOSEFIGURE",
+	EMR_FILLPATH:                "EMR_FILLPATH",
+	EMR_STROKEANDFILLPATH:       "EMR_STROKEANDFILLPATH",
+	EMR_STROKEPATH:              "EMR_STROKEPATH",
+	EMR_FLATTENPATH:             "EMR_FLATTENPATH",
+	EMR_WIDENPATH:               "EMR_WIDENPATH",
+	EMR_SELECTCLIPPATH:          "EMR_SELECTCLIPPATH",
+	EMR_ABORTPATH:               "EMR_ABORTPATH",
+	EMR_COMMENT:                 "EMR_COMMENT",
+	EMR_FILLRGN:                 "EMR_FILLRGN",
+	EMR_FRAMERGN:                "EMR_FRAMERGN",
+	EMR_INVERTRGN:               "EMR_INVERTRGN",
+	EMR_PAINTRGN:                "EMR_PAINTRGN",
+	EMR_EXTSELECTCLIPRGN:        "EMR_EXTSELECTCLIPRGN",
+	EMR_BITBLT:                  "EMR_BITBLT",
+	EMR_STRETCHBLT:              "EMR_STRETCHBLT",
+	EMR_MASKBLT:                 "EMR_MASKBLT",
+	EMR_PLGBLT:                  "EMR_PLGBLT",
+	EMR_SETDIBITSTODEVICE:       "EMR_SETDIBITSTODEVICE",
+	EMR_STRETCHDIBITS:           "EMR_STRETCHDIBITS",
+	EMR_EXTCREATEFONTINDIRECTW:  "EMR_EXTCREATEFONTINDIRECTW",
+	EMR_EXTTEXTOUTA:             "EMR_EXTTEXTOUTA",
+	EMR_EXTTEXTOUTW:             "EMR_EXTTEXTOUTW",
+	EMR_POLYBEZIER16:            "EMR_POLYBEZIER16",
+	EMR_POLYGON16:               "EMR_POLYGON16",
+	EMR_POLYLINE16:              "EMR_POLYLINE16",
+	EMR_POLYBEZIERTO16:          "EMR_POLYBEZIERTO16",
+	EMR_POLYLINETO16:            "EMR_POLYLINETO16",
+	EMR_POLYPOLYLINE16:          "EMR_POLYPOLYLINE16",
+	EMR_POLYPOLYGON16:           "EMR_POLYPOLYGON16",
+	EMR_POLYDRAW16:              "EMR_POLYDRAW16",
+	EMR_CREATEMONOBRUSH:         "EMR_CREATEMONOBRUSH",
+	EMR_CREATEDIBPATTERNBRUSHPT: "EMR_CREATEDIBPATTERNBRUSHPT",
+	EMR_EXTCREATEPEN:            "EMR_EXTCREATEPEN",
+	EMR_POLYTEXTOUTA:            "EMR_POLYTEXTOUTA",
+	EMR_POLYTEXTOUTW:            "EMR_POLYTEXTOUTW",
+	EMR_SETICMMODE:              "EMR_SETICMMODE",
+	EMR_CREATECOLORSPACE:        "EMR_CREATECOLORSPACE",
+	EMR_SETCOLORSPACE:           "EMR_SETCOLORSPACE",
+	EMR_DELETECOLORSPACE:        "EMR_DELETECOLORSPACE",
+	EMR_GLSRECORD:               "EMR_GLSRECORD",
+	EMR_GLSBOUNDEDRECORD:        "EMR_GLSBOUNDEDRECORD",
+	EMR_PIXELFORMAT:             "EMR_PIXELFORMAT",
+	EMR_SMALLTEXTOUT:            "EMR_SMALLTEXTOUT",
+	EMR_FORCEUFIMAPPING:         "EMR_FORCEUFIMAPPING",
+	EMR_NAMEDESCAPE:             "EMR_NAMEDESCAPE",
+	EMR_COLORCORRECTPALETTE:     "EMR_COLORCORRECTPALETTE",
+	EMR_SETICMPROFILEA:          "EMR_SETICMPROFILEA",
+	EMR_SETICMPROFILEW:          "EMR_SETICMPROFILEW",
+	EMR_ALPHABLEND:              "EMR_ALPHABLEND",
+	EMR_SETLAYOUT:               "EMR_SETLAYOUT",
+	EMR_TRANSPARENTBLT:          "EMR_TRANSPARENTBLT",
+	EMR_GRADIENTFILL:            "EMR_GRADIENTFILL",
+	EMR_SETLINKEDUFIS:           "EMR_SETLINKEDUFIS",
+	EMR_SETTEXTJUSTIFICATION:    "EMR_SETTEXTJUSTIFICATION",
+	EMR_COLORMATCHTOTARGETW:     "EMR_COLORMATCHTOTARGETW",
+	EMR_CREATECOLORSPACEW:       "EMR_CREATECOLORSPACEW",
+}
+
+func (t RecordType) String() string {
+	if name, ok := recordNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("EMR_%d", uint32(t))
+}
+
+// ENHMETA_SIGNATURE is the " EMF" signature of the header record.
+const ENHMETA_SIGNATURE = 0x464D4520
+
+// Background modes of EMR_SETBKMODE.
+const (
+	TRANSPARENT = 1
+	OPAQUE      = 2
+)
+
+// Graphics modes of EMR_EXTTEXTOUTW.
+const (
+	GM_COMPATIBLE = 1
+	GM_ADVANCED   = 2
+)
+
+// Font weights and character set of the LOGFONT written by the Writer.
+const (
+	FW_NORMAL       = 400
+	FW_BOLD         = 700
+	DEFAULT_CHARSET = 1
+)
+
+// Stock objects, selected with the high bit of the object index set.
+const (
+	STOCK_OBJECT = 0x80000000
+
+	WHITE_BRUSH  = 0x80000000
+	LTGRAY_BRUSH = 0x80000001
+	GRAY_BRUSH   = 0x80000002
+	DKGRAY_BRUSH = 0x80000003
+	BLACK_BRUSH  = 0x80000004
+	NULL_BRUSH   = 0x80000005
+	WHITE_PEN    = 0x80000006
+	BLACK_PEN    = 0x80000007
+	NULL_PEN     = 0x80000008
+
+	OEM_FIXED_FONT   = 0x8000000A
+	SYSTEM_FONT      = 0x8000000D
+	DEFAULT_GUI_FONT = 0x80000011
+)
+
+// Mapping modes of EMR_SETMAPMODE.
+const (
+	MM_TEXT        = 1
+	MM_LOMETRIC    = 2
+	MM_HIMETRIC    = 3
+	MM_LOENGLISH   = 4
+	MM_HIENGLISH   = 5
+	MM_TWIPS       = 6
+	MM_ISOTROPIC   = 7
+	MM_ANISOTROPIC = 8
+)
+
+// Text alignment flags of EMR_SETTEXTALIGN.
+const (
+	TA_NOUPDATECP = 0
+	TA_UPDATECP   = 1
+	TA_LEFT       = 0
+	TA_RIGHT      = 2
+	TA_CENTER     = 6
+	TA_TOP        = 0
+	TA_BOTTOM     = 8
+	TA_BASELINE   = 24
+)
+
+// Modes of EMR_MODIFYWORLDTRANSFORM.
+const (
+	MWT_IDENTITY      = 1
+	MWT_LEFTMULTIPLY  = 2
+	MWT_RIGHTMULTIPLY = 3
+	MWT_SET           = 4
+)
+
+// Pen and brush styles.
+const (
+	PS_NULL       = 5
+	PS_STYLE_MASK = 0x0000000F
+	PS_GEOMETRIC  = 0x00010000
+	BS_SOLID      = 0
+	BS_NULL       = 1
+	BS_HATCHED    = 2
+)
+
+// RGN_COPY is the EMR_EXTSELECTCLIPRGN mode replacing the clipping region.
+const RGN_COPY = 5
+
+// Color usage and compression of device independent bitmaps.
+const (
+	DIB_RGB_COLORS = 0
+	BI_RGB         = 0
+	BI_BITFIELDS   = 3
+)
@@ -0,0 +1,392 @@
+// Package emf writes and plays Windows enhanced metafiles in pure Go.
+//
+// Writer implements device.Device and produces one .emf file per page, made
+// of the records GDI itself would spool for the same calls: TextOut becomes
+// EMR_EXTTEXTOUTW, font changes EMR_EXTCREATEFONTINDIRECTW and
+// EMR_SELECTOBJECT, lines EMR_MOVETOEX and EMR_LINETO, and images
+// EMR_STRETCHDIBITS. Metafile coordinates are the device pixels of the page.
+//
+// Parse reads existing metafiles, such as spool files or reports exported by
+// other applications, and Play draws them onto any device.Canvas, for
+// instance the raster backend.
+package emf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"unicode/utf16"
+
+	"github.com/sipkg/golang-win32-printer/device"
+	"github.com/sipkg/golang-win32-printer/device/fonts"
+	"github.com/sipkg/golang-win32-printer/win32"
+)
+
+var errNoPage = errors.New("emf: must call StartPage before")
+
+// headerSize is the size of an EMR_HEADER record with both header
+// extensions, before the description string.
+const headerSize = 108
+
+// Writer records the pages drawn on it as enhanced metafiles.
+type Writer struct {
+	// Pages holds one enhanced metafile per page finished by EndPage since
+	// the last StartDoc.
+	Pages [][]byte
+
+	size    device.PageSize
+	dpi     int
+	width   int
+	height  int
+	docName string
+
+	page      *bytes.Buffer
+	records   uint32
+	handles   uint32
+	fontIndex uint32
+	fontDirty bool
+	font      device.Font
+	textColor color.Color
+}
+
+var _ device.Device = (*Writer)(nil)
+
+// NewWriter returns a Writer with pages of the given size addressed in pixels
+// at dpi dots per inch.
+func NewWriter(size device.PageSize, dpi int) *Writer {
+	width, height := size.Pixels(dpi)
+	return &Writer{
+		size:      size,
+		dpi:       dpi,
+		width:     width,
+		height:    height,
+		font:      device.DefaultFont(dpi),
+		textColor: color.Black,
+	}
+}
+
+func (w *Writer) StartDoc(docName string) error {
+	w.Pages = nil
+	w.docName = docName
+	return nil
+}
+
+// StartPage starts a new metafile. The font and text color selected so far
+// carry over, as they do on a printer DC.
+func (w *Writer) StartPage() error {
+	w.page = &bytes.Buffer{}
+	w.page.Write(make([]byte, headerSize+pad4(2*len(w.description()))))
+	w.records = 1
+	w.handles = 1
+	w.fontIndex = 0
+	w.fontDirty = true
+	w.record(EMR_SETBKMODE, TRANSPARENT)
+	w.record(EMR_SETTEXTCOLOR, colorRef(w.textColor))
+	return nil
+}
+
+// EndPage terminates the metafile and appends it to Pages.
+func (w *Writer) EndPage() error {
+	if w.page == nil {
+		return errNoPage
+	}
+	w.record(EMR_EOF, 0, 16, 20)
+	b := w.page.Bytes()
+	w.writeHeader(b)
+	w.Pages = append(w.Pages, b)
+	w.page = nil
+	return nil
+}
+
+func (w *Writer) EndDoc() error {
+	return nil
+}
+
+// description is the application and document name pair of the header,
+// each terminated by a NUL and the pair by a second NUL.
+func (w *Writer) description() []uint16 {
+	return utf16.Encode([]rune("golang-win32-printer\x00" + w.docName + "\x00\x00"))
+}
+
+func (w *Writer) writeHeader(b []byte) {
+	desc := w.description()
+	le := binary.LittleEndian
+	le.PutUint32(b[0:], uint32(EMR_HEADER))
+	le.PutUint32(b[4:], uint32(headerSize+pad4(2*len(desc))))
+	// Bounds, inclusive, in device units.
+	le.PutUint32(b[8:], 0)
+	le.PutUint32(b[12:], 0)
+	le.PutUint32(b[16:], uint32(w.width-1))
+	le.PutUint32(b[20:], uint32(w.height-1))
+	// Frame, inclusive, in .01 millimeter units.
+	le.PutUint32(b[24:], 0)
+	le.PutUint32(b[28:], 0)
+	le.PutUint32(b[32:], uint32(w.size.Width*100)-1)
+	le.PutUint32(b[36:], uint32(w.size.Height*100)-1)
+	le.PutUint32(b[40:], ENHMETA_SIGNATURE)
+	le.PutUint32(b[44:], 0x10000)
+	le.PutUint32(b[48:], uint32(len(b)))
+	le.PutUint32(b[52:], w.records)
+	le.PutUint16(b[56:], uint16(w.handles))
+	le.PutUint16(b[58:], 0)
+	le.PutUint32(b[60:], uint32(len(desc)))
+	le.PutUint32(b[64:], headerSize)
+	le.PutUint32(b[68:], 0)
+	// Reference device in pixels and millimeters.
+	le.PutUint32(b[72:], uint32(w.width))
+	le.PutUint32(b[76:], uint32(w.height))
+	le.PutUint32(b[80:], uint32(w.size.Width))
+	le.PutUint32(b[84:], uint32(w.size.Height))
+	// Extension 1: no pixel format, no OpenGL.
+	le.PutUint32(b[88:], 0)
+	le.PutUint32(b[92:], 0)
+	le.PutUint32(b[96:], 0)
+	// Extension 2: reference device size in micrometers.
+	le.PutUint32(b[100:], uint32(w.size.Width*1000))
+	le.PutUint32(b[104:], uint32(w.size.Height*1000))
+	for i, c := range desc {
+		le.PutUint16(b[headerSize+2*i:], c)
+	}
+}
+
+// record appends a record made of 32 bits fields.
+func (w *Writer) record(t RecordType, fields ...uint32) {
+	b := make([]byte, 4*len(fields))
+	for i, f := range fields {
+		binary.LittleEndian.PutUint32(b[4*i:], f)
+	}
+	w.recordBytes(t, b)
+}
+
+func (w *Writer) recordBytes(t RecordType, data []byte) {
+	var head [8]byte
+	binary.LittleEndian.PutUint32(head[0:], uint32(t))
+	binary.LittleEndian.PutUint32(head[4:], uint32(8+pad4(len(data))))
+	w.page.Write(head[:])
+	w.page.Write(data)
+	w.page.Write(make([]byte, pad4(len(data))-len(data)))
+	w.records++
+}
+
+// selectFont creates the current logical font and selects it, deleting the
+// previous one, when the font changed since the last TextOut.
+func (w *Writer) selectFont() {
+	if !w.fontDirty {
+		return
+	}
+	index := w.handles
+	w.handles++
+	w.recordBytes(EMR_EXTCREATEFONTINDIRECTW, append(u32(index), logFont(w.font)...))
+	w.record(EMR_SELECTOBJECT, index)
+	if w.fontIndex != 0 {
+		w.record(EMR_DELETEOBJECT, w.fontIndex)
+	}
+	w.fontIndex = index
+	w.fontDirty = false
+}
+
+// logFont encodes f as a LOGFONTW.
+func logFont(f device.Font) []byte {
+	lf := win32.LOGFONT{Height: -f.Height, Weight: FW_NORMAL, CharSet: DEFAULT_CHARSET}
+	if f.Bold {
+		lf.Weight = FW_BOLD
+	}
+	if f.Italic {
+		lf.Italic = 1
+	}
+	lf.SetFaceName(f.Name)
+	b, _ := lf.MarshalBinary()
+	return b
+}
+
+func (w *Writer) TextOut(x, y uint32, text string) error {
+	if w.page == nil {
+		return errNoPage
+	}
+	w.selectFont()
+	f := fonts.Lookup(w.font.Name, w.font.Bold, w.font.Italic)
+	chars := utf16.Encode([]rune(text))
+	dx := make([]uint32, 0, len(chars))
+	var width uint32
+	for _, r := range text {
+		adv, _, err := f.Metrics(string(r), float64(w.font.Height))
+		if err != nil {
+			return err
+		}
+		d := uint32(adv + 0.5)
+		dx = append(dx, d)
+		if r >= 0x10000 {
+			// The low surrogate advances by nothing.
+			dx = append(dx, 0)
+		}
+		width += d
+	}
+	_, height, err := f.Metrics(text, float64(w.font.Height))
+	if err != nil {
+		return err
+	}
+	bounds := [4]uint32{x, y, x + width, y + uint32(height+0.5)}
+
+	const fixed = 76 // record header, bounds, mode, scales and EmrText
+	offString := fixed
+	offDx := offString + pad4(2*len(chars))
+	data := make([]byte, offDx-8+4*len(dx))
+	le := binary.LittleEndian
+	for i, v := range bounds {
+		le.PutUint32(data[4*i:], v)
+	}
+	le.PutUint32(data[16:], GM_COMPATIBLE)
+	scale := float32(2540) / float32(w.dpi) // .01 mm per device pixel
+	le.PutUint32(data[20:], math.Float32bits(scale))
+	le.PutUint32(data[24:], math.Float32bits(scale))
+	le.PutUint32(data[28:], x)
+	le.PutUint32(data[32:], y)
+	le.PutUint32(data[36:], uint32(len(chars)))
+	le.PutUint32(data[40:], uint32(offString))
+	le.PutUint32(data[44:], 0) // options
+	// The clipping rectangle is left empty, as ETO_CLIPPED is not set.
+	le.PutUint32(data[64:], uint32(offDx))
+	for i, c := range chars {
+		le.PutUint16(data[offString-8+2*i:], c)
+	}
+	for i, d := range dx {
+		le.PutUint32(data[offDx-8+4*i:], d)
+	}
+	w.recordBytes(EMR_EXTTEXTOUTW, data)
+	return nil
+}
+
+func (w *Writer) GetTextExtentPoint32(text string) (uint32, uint32, error) {
+	f := fonts.Lookup(w.font.Name, w.font.Bold, w.font.Italic)
+	width, height, err := f.Metrics(text, float64(w.font.Height))
+	if err != nil {
+		return 0, 0, err
+	}
+	return uint32(width + 0.5), uint32(height + 0.5), nil
+}
+
+func (w *Writer) MoveTo(x, y uint32) error {
+	if w.page == nil {
+		return errNoPage
+	}
+	w.record(EMR_MOVETOEX, x, y)
+	return nil
+}
+
+func (w *Writer) LineTo(x, y uint32) error {
+	if w.page == nil {
+		return errNoPage
+	}
+	w.record(EMR_LINETO, x, y)
+	return nil
+}
+
+// DrawImage records img as a bottom-up 24 bits DIB stretched onto the page.
+func (w *Writer) DrawImage(x, y, width, height uint32, img image.Image) error {
+	if w.page == nil {
+		return errNoPage
+	}
+	b := img.Bounds()
+	bmi, _ := win32.NewBITMAPINFO(int32(b.Dx()), int32(b.Dy()), 24).BmiHeader.MarshalBinary()
+	bits := dibBits(img)
+
+	const fixed = 80 // record header and EMR_STRETCHDIBITS fields
+	data := make([]byte, fixed-8, fixed-8+len(bmi)+len(bits))
+	le := binary.LittleEndian
+	le.PutUint32(data[0:], x)
+	le.PutUint32(data[4:], y)
+	le.PutUint32(data[8:], x+width-1)
+	le.PutUint32(data[12:], y+height-1)
+	le.PutUint32(data[16:], x)
+	le.PutUint32(data[20:], y)
+	le.PutUint32(data[24:], 0)
+	le.PutUint32(data[28:], 0)
+	le.PutUint32(data[32:], uint32(b.Dx()))
+	le.PutUint32(data[36:], uint32(b.Dy()))
+	le.PutUint32(data[40:], fixed)
+	le.PutUint32(data[44:], uint32(len(bmi)))
+	le.PutUint32(data[48:], uint32(fixed+len(bmi)))
+	le.PutUint32(data[52:], uint32(len(bits)))
+	le.PutUint32(data[56:], 0)          // DIB_RGB_COLORS
+	le.PutUint32(data[60:], 0x00CC0020) // SRCCOPY
+	le.PutUint32(data[64:], width)
+	le.PutUint32(data[68:], height)
+	data = append(append(data, bmi...), bits...)
+	w.recordBytes(EMR_STRETCHDIBITS, data)
+	return nil
+}
+
+func dibStride(width int) int {
+	return pad4(3 * width)
+}
+
+// dibBits returns the pixels of img as bottom-up BGR rows padded to 32 bits.
+func dibBits(img image.Image) []byte {
+	b := img.Bounds()
+	stride := dibStride(b.Dx())
+	bits := make([]byte, stride*b.Dy())
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := bits[(b.Max.Y-1-y)*stride:]
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			i := 3 * (x - b.Min.X)
+			row[i], row[i+1], row[i+2] = byte(bl>>8), byte(g>>8), byte(r>>8)
+		}
+	}
+	return bits
+}
+
+func (w *Writer) SetFont(fontName string) error {
+	w.font.Name = fontName
+	w.fontDirty = true
+	return nil
+}
+
+func (w *Writer) SetTextSize(size int32) (int32, error) {
+	old := w.font.Height
+	w.font.Height = size
+	w.fontDirty = true
+	return old, nil
+}
+
+func (w *Writer) SetBoldFont(bold bool) error {
+	w.font.Bold = bold
+	w.fontDirty = true
+	return nil
+}
+
+func (w *Writer) SetItalicFont(italic bool) error {
+	w.font.Italic = italic
+	w.fontDirty = true
+	return nil
+}
+
+func (w *Writer) SetTextColor(c color.Color) (color.Color, error) {
+	old := w.textColor
+	w.textColor = c
+	if w.page != nil {
+		w.record(EMR_SETTEXTCOLOR, colorRef(c))
+	}
+	return old, nil
+}
+
+func (w *Writer) GetDeviceCaps(index device.Cap) (uint32, error) {
+	return w.size.Caps(w.dpi, index)
+}
+
+// colorRef encodes c as a COLORREF, see win32.RGB.
+func colorRef(c color.Color) uint32 {
+	r, g, b, _ := c.RGBA()
+	return b>>8<<16 | g>>8<<8 | r>>8
+}
+
+func u32(v uint32) []byte {
+	return binary.LittleEndian.AppendUint32(nil, v)
+}
+
+func pad4(n int) int {
+	return (n + 3) &^ 3
+}
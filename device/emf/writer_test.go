@@ -0,0 +1,119 @@
+package emf
+
+import (
+	"encoding/binary"
+	"image"
+	"reflect"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/sipkg/golang-win32-printer/device"
+)
+
+// split returns the records of the metafile b, checking their framing.
+func split(t *testing.T, b []byte) (types []RecordType, records [][]byte) {
+	t.Helper()
+	le := binary.LittleEndian
+	for len(b) > 0 {
+		size := le.Uint32(b[4:])
+		if size < 8 || size%4 != 0 || int(size) > len(b) {
+			t.Fatalf("bad record size %d", size)
+		}
+		types = append(types, RecordType(le.Uint32(b)))
+		records = append(records, b[:size])
+		b = b[size:]
+	}
+	return
+}
+
+func TestWriter(t *testing.T) {
+	w := NewWriter(device.A4, 300)
+	w.StartDoc("ticket")
+	w.StartPage()
+	w.SetFont("Courier New")
+	w.SetTextSize(64)
+	if err := w.TextOut(350, 100, "Hé"); err != nil {
+		t.Fatal(err)
+	}
+	w.SetBoldFont(true)
+	w.TextOut(350, 200, "Total")
+	w.MoveTo(0, 300)
+	w.LineTo(2480, 300)
+	if err := w.DrawImage(10, 20, 30, 40, image.NewRGBA(image.Rect(0, 0, 3, 2))); err != nil {
+		t.Fatal(err)
+	}
+	w.EndPage()
+	w.EndDoc()
+
+	if len(w.Pages) != 1 {
+		t.Fatalf("len(Pages) = %d, want 1", len(w.Pages))
+	}
+	b := w.Pages[0]
+	types, records := split(t, b)
+	want := []RecordType{
+		EMR_HEADER, EMR_SETBKMODE, EMR_SETTEXTCOLOR,
+		EMR_EXTCREATEFONTINDIRECTW, EMR_SELECTOBJECT, EMR_EXTTEXTOUTW,
+		EMR_EXTCREATEFONTINDIRECTW, EMR_SELECTOBJECT, EMR_DELETEOBJECT, EMR_EXTTEXTOUTW,
+		EMR_MOVETOEX, EMR_LINETO, EMR_STRETCHDIBITS, EMR_EOF,
+	}
+	if !reflect.DeepEqual(types, want) {
+		t.Fatalf("records = %v, want %v", types, want)
+	}
+
+	le := binary.LittleEndian
+	header := records[0]
+	if le.Uint32(header[40:]) != ENHMETA_SIGNATURE {
+		t.Error("bad signature")
+	}
+	if got := le.Uint32(header[48:]); got != uint32(len(b)) {
+		t.Errorf("header Bytes = %d, want %d", got, len(b))
+	}
+	if got := le.Uint32(header[52:]); got != uint32(len(records)) {
+		t.Errorf("header Records = %d, want %d", got, len(records))
+	}
+	if got := le.Uint16(header[56:]); got != 3 {
+		t.Errorf("header Handles = %d, want 3", got)
+	}
+
+	font := records[6]
+	if got := int32(le.Uint32(font[12:])); got != -64 {
+		t.Errorf("font height = %d, want -64", got)
+	}
+	if got := le.Uint32(font[28:]); got != FW_BOLD {
+		t.Errorf("font weight = %d, want bold", got)
+	}
+	face := make([]uint16, 11)
+	for i := range face {
+		face[i] = le.Uint16(font[40+2*i:])
+	}
+	if got := string(utf16.Decode(face)); got != "Courier New" {
+		t.Errorf("font face = %q", got)
+	}
+
+	text := records[5]
+	if got := le.Uint32(text[36:]); got != 350 {
+		t.Errorf("text x = %d, want 350", got)
+	}
+	n := le.Uint32(text[44:])
+	off := le.Uint32(text[48:])
+	chars := make([]uint16, n)
+	for i := range chars {
+		chars[i] = le.Uint16(text[int(off)+2*i:])
+	}
+	if got := string(utf16.Decode(chars)); got != "Hé" {
+		t.Errorf("text = %q", got)
+	}
+	offDx := le.Uint32(text[72:])
+	if dx := le.Uint32(text[offDx:]); dx != 38 {
+		t.Errorf("advance of H = %d, want 38 for a 64 pixels monospace font", dx)
+	}
+
+	dib := records[12]
+	offBits, cbBits := le.Uint32(dib[56:]), le.Uint32(dib[60:])
+	if cbBits != 2*12 || int(offBits+cbBits) != len(dib) {
+		t.Errorf("bits at %d, size %d, in a %d bytes record", offBits, cbBits, len(dib))
+	}
+	if got := le.Uint32(dib[72:]); got != 30 {
+		t.Errorf("cxDest = %d, want 30", got)
+	}
+}
@@ -0,0 +1,171 @@
+// Package fonts maps the font face names used with Canvas.SetFont to
+// TrueType data, so that portable backends can measure, rasterize and embed
+// text without the Windows font mapper.
+//
+// Out of the box the Go fonts stand in for the usual Windows faces: Go Mono
+// for "Courier New" and the proportional Go font for everything else.
+// Register installs the real faces when they are available.
+package fonts
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/sipkg/golang-win32-printer/device"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gobolditalic"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/gomonobold"
+	"golang.org/x/image/font/gofont/gomonobolditalic"
+	"golang.org/x/image/font/gofont/gomonoitalic"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// Default is the face used when SetFont was never called, or was called with
+// a name that is not registered.
+const Default = device.DefaultFontName
+
+// Font is a parsed TrueType font for one face name and style.
+type Font struct {
+	Name   string
+	Bold   bool
+	Italic bool
+	// Data is the raw TrueType file, as embedded by the PDF backend.
+	Data []byte
+	// Monospace tells whether every glyph has the same advance.
+	Monospace bool
+
+	sfnt *sfnt.Font
+}
+
+type key struct {
+	name         string
+	bold, italic bool
+}
+
+var (
+	mu       sync.RWMutex
+	registry = map[key]*Font{}
+)
+
+func init() {
+	for _, name := range []string{"Arial", "Verdana", "Times New Roman"} {
+		mustRegister(name, false, false, goregular.TTF)
+		mustRegister(name, true, false, gobold.TTF)
+		mustRegister(name, false, true, goitalic.TTF)
+		mustRegister(name, true, true, gobolditalic.TTF)
+	}
+	mustRegister("Courier New", false, false, gomono.TTF)
+	mustRegister("Courier New", true, false, gomonobold.TTF)
+	mustRegister("Courier New", false, true, gomonoitalic.TTF)
+	mustRegister("Courier New", true, true, gomonobolditalic.TTF)
+}
+
+func mustRegister(name string, bold, italic bool, ttf []byte) {
+	if err := Register(name, bold, italic, ttf); err != nil {
+		panic(err)
+	}
+}
+
+// Register makes the TrueType font ttf available under name for the given
+// style, replacing any previous registration. Names are case insensitive.
+func Register(name string, bold, italic bool, ttf []byte) error {
+	f, err := opentype.Parse(ttf)
+	if err != nil {
+		return fmt.Errorf("fonts: parse %q: %w", name, err)
+	}
+	var buf sfnt.Buffer
+	iw, err := f.GlyphAdvance(&buf, mustGlyph(f, &buf, 'i'), fixed.I(1000), font.HintingNone)
+	if err != nil {
+		return fmt.Errorf("fonts: parse %q: %w", name, err)
+	}
+	mw, err := f.GlyphAdvance(&buf, mustGlyph(f, &buf, 'M'), fixed.I(1000), font.HintingNone)
+	if err != nil {
+		return fmt.Errorf("fonts: parse %q: %w", name, err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	registry[key{strings.ToLower(name), bold, italic}] = &Font{
+		Name:      name,
+		Bold:      bold,
+		Italic:    italic,
+		Data:      ttf,
+		Monospace: iw == mw,
+		sfnt:      f,
+	}
+	return nil
+}
+
+func mustGlyph(f *sfnt.Font, buf *sfnt.Buffer, r rune) sfnt.GlyphIndex {
+	i, _ := f.GlyphIndex(buf, r)
+	return i
+}
+
+// Lookup returns the font registered for name and style, falling back to the
+// Default face like the Windows font mapper does for unknown names.
+func Lookup(name string, bold, italic bool) *Font {
+	mu.RLock()
+	defer mu.RUnlock()
+	if f, ok := registry[key{strings.ToLower(name), bold, italic}]; ok {
+		return f
+	}
+	return registry[key{strings.ToLower(Default), bold, italic}]
+}
+
+// Face returns a face rendering the font with a character height of size
+// pixels, which is what a negative LOGFONT height asks GDI for.
+func (f *Font) Face(size float64) (font.Face, error) {
+	return opentype.NewFace(f.sfnt, &opentype.FaceOptions{
+		Size:    size,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+}
+
+// SFNT returns the parsed font.
+func (f *Font) SFNT() *sfnt.Font {
+	return f.sfnt
+}
+
+// Metrics returns the extent of text at a character height of size pixels,
+// computed like GetTextExtentPoint32: the sum of the glyph advances, without
+// kerning, and the ascent plus descent of the font.
+func (f *Font) Metrics(text string, size float64) (width, height float64, err error) {
+	var buf sfnt.Buffer
+	ppem := fixed.Int26_6(size * 64)
+	m, err := f.sfnt.Metrics(&buf, ppem, font.HintingNone)
+	if err != nil {
+		return 0, 0, err
+	}
+	var adv fixed.Int26_6
+	for _, r := range text {
+		g, err := f.sfnt.GlyphIndex(&buf, r)
+		if err != nil {
+			return 0, 0, err
+		}
+		a, err := f.sfnt.GlyphAdvance(&buf, g, ppem, font.HintingNone)
+		if err != nil {
+			return 0, 0, err
+		}
+		adv += a
+	}
+	return float64(adv) / 64, float64(m.Ascent+m.Descent) / 64, nil
+}
+
+// Ascent returns the distance from the top of the character cell to the
+// baseline at a character height of size pixels, where TextOut places text.
+func (f *Font) Ascent(size float64) (float64, error) {
+	var buf sfnt.Buffer
+	m, err := f.sfnt.Metrics(&buf, fixed.Int26_6(size*64), font.HintingNone)
+	if err != nil {
+		return 0, err
+	}
+	return float64(m.Ascent) / 64, nil
+}
@@ -0,0 +1,103 @@
+package pcl
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/sipkg/golang-win32-printer/image/mono"
+)
+
+// Raster compression methods of ESC * b M.
+const (
+	modeTIFF     = 2
+	modeDeltaRow = 3
+)
+
+// writeRaster writes the rows of m as raster data, each compressed with the
+// method giving the fewer bytes. The seed row of the delta row method is the
+// previous row, whatever its method, starting with a blank row.
+func writeRaster(buf *bytes.Buffer, m *mono.Image) {
+	b := m.Bounds()
+	stride := (b.Dx() + 7) / 8
+	seed := make([]byte, stride)
+	mode := 0
+	var tiff, delta []byte
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := m.Row(y)[:stride]
+		// Trailing zeros are left out, the printer filling the row with
+		// zeros.
+		tiff = packBits(tiff[:0], bytes.TrimRight(row, "\x00"))
+		delta = deltaRow(delta[:0], row, seed)
+		data, rowMode := tiff, modeTIFF
+		if len(delta) < len(tiff) || (len(delta) == len(tiff) && mode == modeDeltaRow) {
+			data, rowMode = delta, modeDeltaRow
+		}
+		if rowMode != mode {
+			fmt.Fprintf(buf, esc+"*b%dM", rowMode)
+			mode = rowMode
+		}
+		fmt.Fprintf(buf, esc+"*b%dW", len(data))
+		buf.Write(data)
+		copy(seed, row)
+	}
+}
+
+// packBits appends row compressed with the TIFF PackBits method: a control
+// byte n from 0 to 127 is followed by n+1 literal bytes, one from -1 to
+// -127 by a byte repeated 1-n times.
+func packBits(dst, row []byte) []byte {
+	for i := 0; i < len(row); {
+		run := 1
+		for i+run < len(row) && run < 128 && row[i+run] == row[i] {
+			run++
+		}
+		if run > 1 {
+			dst = append(dst, byte(1-run), row[i])
+			i += run
+			continue
+		}
+		lit := 1
+		for i+lit < len(row) && lit < 128 && (i+lit+1 >= len(row) || row[i+lit] != row[i+lit+1]) {
+			lit++
+		}
+		dst = append(dst, byte(lit-1))
+		dst = append(dst, row[i:i+lit]...)
+		i += lit
+	}
+	return dst
+}
+
+// deltaRow appends the bytes of row differing from seed with the delta row
+// method: each run of up to 8 replaced bytes is preceded by a command byte
+// holding its length minus one in the top 3 bits and, in the low 5 bits,
+// its offset from the end of the previous run, continued in extra bytes
+// when it reaches 31.
+func deltaRow(dst, row, seed []byte) []byte {
+	last := 0
+	for i := 0; i < len(row); {
+		if row[i] == seed[i] {
+			i++
+			continue
+		}
+		n := 1
+		for i+n < len(row) && n < 8 && row[i+n] != seed[i+n] {
+			n++
+		}
+		offset := i - last
+		if offset < 31 {
+			dst = append(dst, byte((n-1)<<5|offset))
+		} else {
+			dst = append(dst, byte((n-1)<<5|31))
+			offset -= 31
+			for offset >= 255 {
+				dst = append(dst, 255)
+				offset -= 255
+			}
+			dst = append(dst, byte(offset))
+		}
+		dst = append(dst, row[i:i+n]...)
+		i += n
+		last = i
+	}
+	return dst
+}
@@ -0,0 +1,391 @@
+// Package pcl implements device.Device by writing PCL 5, the language of HP
+// and most office laser printers.
+//
+// Text is printed with the resident fonts of the printer, lines with rules
+// and images as 1 bit raster graphics, compressed with the TIFF (mode 2) or
+// delta row (mode 3) method, whichever is smaller for each row. Pages are
+// written as soon as they end, so the document is usually sent as a raw job:
+//
+//	job, _ := printer.NewRawJob(name, "report", printer.DatatypeRAW)
+//	p := pcl.New(job, device.A4, 300)
+//	ticket.RenderA4(p, margin, textHeight, pdv, t)
+//	job.Close()
+//
+// As on the GDI printer, coordinates are relative to the printable area,
+// which PCL calls the logical page, and GetDeviceCaps reports its offset
+// from the edge of the paper.
+//
+// NewXL returns the PCL XL (PCL 6) counterpart, for printers that no longer
+// understand PCL 5. It prints text with the same resident fonts, lines as
+// paths and images as PackBits compressed 1 bit images, and addresses the
+// whole sheet of paper.
+package pcl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	stddraw "image/draw"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/sipkg/golang-win32-printer/device"
+	"github.com/sipkg/golang-win32-printer/device/fonts"
+	"github.com/sipkg/golang-win32-printer/image/mono"
+	"golang.org/x/image/draw"
+	"golang.org/x/text/encoding/charmap"
+)
+
+const esc = "\x1b"
+
+var errNoPage = errors.New("pcl: must call StartPage before")
+
+// paperSizes are the PCL page size codes of ESC & l A.
+var paperSizes = map[device.PageSize]int{
+	device.A4:     26,
+	device.A5:     25,
+	device.Letter: 2,
+}
+
+// typefaces are the PCL typeface numbers of the resident fonts standing in
+// for the Windows faces, Arial being used for the other names.
+var typefaces = map[string]int{
+	"arial":           16602,
+	"courier":         4099,
+	"courier new":     4099,
+	"letter gothic":   4102,
+	"times new roman": 16901,
+	"times":           4101,
+	"univers":         4148,
+}
+
+const arial = 16602
+
+// PCL writes the pages drawn on it as PCL 5 to an io.Writer.
+type PCL struct {
+	// ImageOptions tells how images are converted to black and white dots,
+	// a threshold at mid gray when nil.
+	ImageOptions *mono.Options
+
+	w       io.Writer
+	size    device.PageSize
+	dpi     int
+	width   int
+	height  int
+	offsetX int
+	started bool
+
+	page      *bytes.Buffer
+	pos       image.Point
+	font      device.Font
+	textColor color.Color
+	selected  string
+	pattern   int
+}
+
+var _ device.Device = (*PCL)(nil)
+
+// New returns a PCL writing to w, with pages of the given size addressed in
+// pixels at dpi dots per inch, which should be a resolution supported by PCL
+// raster graphics: 75, 100, 150, 200, 300 or 600.
+func New(w io.Writer, size device.PageSize, dpi int) *PCL {
+	width, height := size.Pixels(dpi)
+	// Portrait logical pages leave a quarter of an inch on both sides,
+	// slightly less on A4.
+	offsetX := dpi / 4
+	if size == device.A4 {
+		offsetX = 71 * dpi / 300
+	}
+	return &PCL{
+		w:         w,
+		size:      size,
+		dpi:       dpi,
+		width:     width - 2*offsetX,
+		height:    height,
+		offsetX:   offsetX,
+		font:      device.DefaultFont(dpi),
+		textColor: color.Black,
+	}
+}
+
+// StartDoc resets the printer and sets up the page size and resolution.
+func (p *PCL) StartDoc(docName string) error {
+	paper, ok := paperSizes[p.size]
+	if !ok {
+		// Custom paper size, in decipoints.
+		paper = 101
+	}
+	var b strings.Builder
+	b.WriteString(esc + "%-12345X" + esc + "E")
+	if paper == 101 {
+		fmt.Fprintf(&b, esc+"&f%dI"+esc+"&f%dJ", int(p.size.Width*720/25.4), int(p.size.Height*720/25.4))
+	}
+	fmt.Fprintf(&b, esc+"&l%dA"+esc+"&l0O"+esc+"&l0E"+esc+"&l0L", paper)
+	fmt.Fprintf(&b, esc+"&u%dD"+esc+"*t%dR"+esc+"(19U", p.dpi, p.dpi)
+	p.started = true
+	p.selected = ""
+	p.pattern = 0
+	_, err := io.WriteString(p.w, b.String())
+	return err
+}
+
+func (p *PCL) StartPage() error {
+	if !p.started {
+		if err := p.StartDoc(""); err != nil {
+			return err
+		}
+	}
+	p.page = &bytes.Buffer{}
+	p.pos = image.Point{}
+	return nil
+}
+
+// EndPage writes the page, followed by a form feed.
+func (p *PCL) EndPage() error {
+	if p.page == nil {
+		return errNoPage
+	}
+	p.page.WriteByte('\f')
+	_, err := p.w.Write(p.page.Bytes())
+	p.page = nil
+	return err
+}
+
+// EndDoc resets the printer, ejecting a page left open.
+func (p *PCL) EndDoc() error {
+	if p.page != nil {
+		if err := p.EndPage(); err != nil {
+			return err
+		}
+	}
+	if !p.started {
+		if err := p.StartDoc(""); err != nil {
+			return err
+		}
+	}
+	p.started = false
+	_, err := io.WriteString(p.w, esc+"E"+esc+"%-12345X")
+	return err
+}
+
+// moveTo positions the cursor at x, y.
+func (p *PCL) moveTo(x, y int) {
+	fmt.Fprintf(p.page, esc+"*p%dx%dY", x, y)
+}
+
+// setPattern selects the solid black (0) or white (1) pattern used to print
+// text and rules.
+func (p *PCL) setPattern(white bool) {
+	pattern := 0
+	if white {
+		pattern = 1
+	}
+	if pattern != p.pattern {
+		fmt.Fprintf(p.page, esc+"*v%dT", pattern)
+		p.pattern = pattern
+	}
+}
+
+// selectFont selects the resident font closest to the current one, when it
+// changed since the last text.
+func (p *PCL) selectFont() {
+	typeface, ok := typefaces[strings.ToLower(p.font.Name)]
+	if !ok {
+		typeface = arial
+	}
+	points := float64(p.font.Height) * 72 / float64(p.dpi)
+	spacing := "1p"
+	if typeface == 4099 || typeface == 4102 {
+		// Fixed pitch fonts are selected by their pitch: Courier is 10
+		// characters per inch at 12 points.
+		spacing = "0p" + num(120/points) + "h"
+	}
+	style, weight := 0, 0
+	if p.font.Italic {
+		style = 1
+	}
+	if p.font.Bold {
+		weight = 3
+	}
+	sel := fmt.Sprintf(esc+"(s%s%sv%ds%db%dT", spacing, num(points), style, weight, typeface)
+	if sel != p.selected {
+		p.page.WriteString(sel)
+		p.selected = sel
+	}
+}
+
+// TextOut prints text in a resident font. The baseline, where PCL places
+// text, is computed with the metrics of the portable font of the same name.
+func (p *PCL) TextOut(x, y uint32, text string) error {
+	if p.page == nil {
+		return errNoPage
+	}
+	ascent, err := fonts.Lookup(p.font.Name, p.font.Bold, p.font.Italic).Ascent(float64(p.font.Height))
+	if err != nil {
+		return err
+	}
+	p.selectFont()
+	p.setPattern(mono.Gray(p.textColor) >= 0x8000)
+	p.moveTo(int(x), int(y)+int(ascent+0.5))
+	p.page.Write(encodeText(text))
+	return nil
+}
+
+// encodeText encodes text in Windows-1252, the 19U symbol set, control
+// characters being replaced with spaces and missing ones with '?'.
+func encodeText(text string) []byte {
+	b := make([]byte, 0, len(text))
+	for _, r := range text {
+		c, ok := charmap.Windows1252.EncodeRune(r)
+		switch {
+		case r < 0x20:
+			c = ' '
+		case !ok:
+			c = '?'
+		}
+		b = append(b, c)
+	}
+	return b
+}
+
+func (p *PCL) GetTextExtentPoint32(text string) (uint32, uint32, error) {
+	f := fonts.Lookup(p.font.Name, p.font.Bold, p.font.Italic)
+	w, h, err := f.Metrics(text, float64(p.font.Height))
+	if err != nil {
+		return 0, 0, err
+	}
+	return uint32(w + 0.5), uint32(h + 0.5), nil
+}
+
+func (p *PCL) MoveTo(x, y uint32) error {
+	p.pos = image.Point{X: int(x), Y: int(y)}
+	return nil
+}
+
+// rule fills the rectangle r in black.
+func (p *PCL) rule(r image.Rectangle) {
+	p.moveTo(r.Min.X, r.Min.Y)
+	fmt.Fprintf(p.page, esc+"*c%da%db0P", r.Dx(), r.Dy())
+}
+
+// LineTo draws the line with rules: one for horizontal and vertical lines,
+// one per run of pixels for the others. As with GDI, the last point is not
+// drawn.
+func (p *PCL) LineTo(x, y uint32) error {
+	if p.page == nil {
+		return errNoPage
+	}
+	p.setPattern(false)
+	from, to := p.pos, image.Point{X: int(x), Y: int(y)}
+	p.pos = to
+	d := to.Sub(from)
+	steps := max(abs(d.X), abs(d.Y))
+	var run image.Rectangle
+	for i := 0; i < steps; i++ {
+		pt := from.Add(image.Pt(
+			int(math.Round(float64(d.X*i)/float64(steps))),
+			int(math.Round(float64(d.Y*i)/float64(steps)))))
+		px := image.Rectangle{Min: pt, Max: pt.Add(image.Pt(1, 1))}
+		// Pixels extending the run in its row or column join it.
+		if u := run.Union(px); !run.Empty() && u.Dx()*u.Dy() == run.Dx()*run.Dy()+1 {
+			run = u
+			continue
+		}
+		if !run.Empty() {
+			p.rule(run)
+		}
+		run = px
+	}
+	if !run.Empty() {
+		p.rule(run)
+	}
+	return nil
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// DrawImage prints img as raster graphics, scaled to width x height pixels
+// and converted to black and white with ImageOptions. White pixels are
+// transparent.
+func (p *PCL) DrawImage(x, y, width, height uint32, img image.Image) error {
+	if p.page == nil {
+		return errNoPage
+	}
+	b := img.Bounds()
+	if width == 0 || height == 0 || b.Empty() {
+		return nil
+	}
+	if b.Dx() != int(width) || b.Dy() != int(height) {
+		scaled := image.NewRGBA(image.Rect(0, 0, int(width), int(height)))
+		stddraw.Draw(scaled, scaled.Bounds(), image.White, image.Point{}, stddraw.Src)
+		draw.ApproxBiLinear.Scale(scaled, scaled.Bounds(), img, b, draw.Over, nil)
+		img = scaled
+	}
+	m, ok := img.(*mono.Image)
+	if !ok {
+		m = mono.Convert(img, p.ImageOptions)
+	}
+	p.moveTo(int(x), int(y))
+	fmt.Fprintf(p.page, esc+"*r%dS"+esc+"*r%dT"+esc+"*r1A", width, height)
+	writeRaster(p.page, m)
+	p.page.WriteString(esc + "*rC")
+	return nil
+}
+
+func (p *PCL) SetFont(fontName string) error {
+	p.font.Name = fontName
+	return nil
+}
+
+func (p *PCL) SetTextSize(size int32) (int32, error) {
+	old := p.font.Height
+	p.font.Height = size
+	return old, nil
+}
+
+func (p *PCL) SetBoldFont(bold bool) error {
+	p.font.Bold = bold
+	return nil
+}
+
+func (p *PCL) SetItalicFont(italic bool) error {
+	p.font.Italic = italic
+	return nil
+}
+
+// SetTextColor changes the text color. Text is printed black, or white
+// when c is lighter than mid gray.
+func (p *PCL) SetTextColor(c color.Color) (color.Color, error) {
+	old := p.textColor
+	p.textColor = c
+	return old, nil
+}
+
+// GetDeviceCaps reports the size of the logical page, and its offset from
+// the left edge of the paper.
+func (p *PCL) GetDeviceCaps(index device.Cap) (uint32, error) {
+	switch index {
+	case device.HorzRes:
+		return uint32(p.width), nil
+	case device.PhysicalOffsetX:
+		return uint32(p.offsetX), nil
+	case device.BitsPixel:
+		return 1, nil
+	}
+	return p.size.Caps(p.dpi, index)
+}
+
+// num formats v with at most two decimals, as PCL values allow.
+func num(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	return strings.TrimRight(strings.TrimRight(s, "0"), ".")
+}
@@ -0,0 +1,187 @@
+package pcl
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/sipkg/golang-win32-printer/device"
+	"github.com/sipkg/golang-win32-printer/image/mono"
+)
+
+func TestDocument(t *testing.T) {
+	var out bytes.Buffer
+	p := New(&out, device.A4, 300)
+	if w, _ := p.GetDeviceCaps(device.HorzRes); w != 2480-2*71 {
+		t.Errorf("HorzRes = %d", w)
+	}
+	p.StartDoc("report")
+	p.StartPage()
+	p.SetFont("Courier New")
+	p.SetTextSize(50)
+	p.SetBoldFont(true)
+	if err := p.TextOut(100, 100, "Été"); err != nil {
+		t.Fatal(err)
+	}
+	p.TextOut(100, 200, "€")
+	p.MoveTo(10, 300)
+	p.LineTo(110, 300)
+	p.LineTo(110, 250)
+	p.EndPage()
+	p.StartPage()
+	p.SetFont("Arial")
+	p.SetBoldFont(false)
+	p.SetTextColor(color.White)
+	p.TextOut(0, 0, "x")
+	p.EndDoc()
+
+	got := out.String()
+	for _, want := range []string{
+		"\x1b%-12345X\x1bE\x1b&l26A\x1b&l0O\x1b&l0E\x1b&l0L\x1b&u300D\x1b*t300R\x1b(19U",
+		"\x1b(s0p10h12v0s3b4099T\x1b*p100x",
+		"\xc9t\xe9\x1b*p100x",
+		"Y\x80\x1b*p10x300Y\x1b*c100a1b0P\x1b*p110x251Y\x1b*c1a50b0P\f",
+		"\x1b(s1p12v0s0b16602T\x1b*v1T\x1b*p0x",
+		"x\f\x1bE\x1b%-12345X",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("document lacks %q:\n%q", want, got)
+		}
+	}
+	if n := strings.Count(got, "\x1b(s"); n != 2 {
+		t.Errorf("%d font selections, want 2", n)
+	}
+}
+
+func TestDiagonalLine(t *testing.T) {
+	var out bytes.Buffer
+	p := New(&out, device.Letter, 300)
+	p.StartPage()
+	p.MoveTo(0, 0)
+	p.LineTo(6, 2)
+	p.EndPage()
+	rules := regexp.MustCompile(`\x1b\*p(\d+)x(\d+)Y\x1b\*c(\d+)a(\d+)b0P`).FindAllStringSubmatch(out.String(), -1)
+	var got []string
+	for _, r := range rules {
+		got = append(got, strings.Join(r[1:], ","))
+	}
+	// Pixels (0,0) (1,0) (2,1) (3,1) (4,1) (5,2).
+	want := "0,0,2,1 2,1,3,1 5,2,1,1"
+	if strings.Join(got, " ") != want {
+		t.Errorf("rules %v, want %s", got, want)
+	}
+}
+
+// decodeRaster decodes the rows of raster data in PCL, width bytes wide.
+func decodeRaster(t *testing.T, pcl []byte, width int) [][]byte {
+	t.Helper()
+	cmd := regexp.MustCompile(`^\x1b\*b(\d+)([MW])`)
+	seed := make([]byte, width)
+	mode := 0
+	var rows [][]byte
+	for len(pcl) > 0 {
+		m := cmd.FindSubmatch(pcl)
+		if m == nil {
+			break
+		}
+		n, _ := strconv.Atoi(string(m[1]))
+		pcl = pcl[len(m[0]):]
+		if m[2][0] == 'M' {
+			mode = n
+			continue
+		}
+		data := pcl[:n]
+		pcl = pcl[n:]
+		row := make([]byte, width)
+		switch mode {
+		case modeTIFF:
+			for i, j := 0, 0; i < len(data); {
+				c := int8(data[i])
+				if c >= 0 {
+					j += copy(row[j:], data[i+1:i+2+int(c)])
+					i += 2 + int(c)
+				} else {
+					for k := 0; k < 1-int(c); k++ {
+						row[j] = data[i+1]
+						j++
+					}
+					i += 2
+				}
+			}
+		case modeDeltaRow:
+			copy(row, seed)
+			for i, j := 0, 0; i < len(data); {
+				count, offset := int(data[i]>>5)+1, int(data[i]&31)
+				i++
+				if offset == 31 {
+					for {
+						offset += int(data[i])
+						i++
+						if data[i-1] != 255 {
+							break
+						}
+					}
+				}
+				j += offset
+				j += copy(row[j:], data[i:i+count])
+				i += count
+			}
+		default:
+			t.Fatalf("compression mode %d", mode)
+		}
+		rows = append(rows, row)
+		copy(seed, row)
+	}
+	return rows
+}
+
+func TestRaster(t *testing.T) {
+	img := mono.New(image.Rect(0, 0, 700, 40))
+	rnd := rand.New(rand.NewSource(1))
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 700; x++ {
+			switch {
+			case y < 10:
+				img.SetBlack(x, y, x < 300)
+			case y < 20:
+				img.SetBlack(x, y, x < 300 || (x == 650 && y%2 == 0))
+			case y < 30:
+				img.SetBlack(x, y, rnd.Intn(2) == 0)
+			}
+		}
+	}
+	var out bytes.Buffer
+	p := New(&out, device.A4, 300)
+	p.StartPage()
+	if err := p.DrawImage(20, 30, 700, 40, img); err != nil {
+		t.Fatal(err)
+	}
+	p.EndPage()
+	data := out.Bytes()
+	start := bytes.Index(data, []byte("\x1b*p20x30Y\x1b*r700S\x1b*r40T\x1b*r1A"))
+	end := bytes.Index(data, []byte("\x1b*rC"))
+	if start < 0 || end < 0 {
+		t.Fatalf("no raster graphics in %q", data)
+	}
+	raster := data[start+len("\x1b*p20x30Y\x1b*r700S\x1b*r40T\x1b*r1A") : end]
+	if !bytes.Contains(raster, []byte("\x1b*b2M")) || !bytes.Contains(raster, []byte("\x1b*b3M")) {
+		t.Errorf("raster does not use both compression modes")
+	}
+	if len(raster) > 88*15 {
+		t.Errorf("raster of %d bytes, not compressed", len(raster))
+	}
+	rows := decodeRaster(t, raster, 88)
+	if len(rows) != 40 {
+		t.Fatalf("%d rows, want 40", len(rows))
+	}
+	for y, row := range rows {
+		if want := img.Row(y); !bytes.Equal(row, want) {
+			t.Fatalf("row %d is\n%x\nwant\n%x", y, row, want)
+		}
+	}
+}
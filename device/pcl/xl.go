@@ -0,0 +1,500 @@
+package pcl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	stddraw "image/draw"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/sipkg/golang-win32-printer/device"
+	"github.com/sipkg/golang-win32-printer/device/fonts"
+	"github.com/sipkg/golang-win32-printer/image/mono"
+	"golang.org/x/image/draw"
+)
+
+// Data type tags of PCL XL.
+const (
+	xlUbyte      = 0xC0
+	xlUint16     = 0xC1
+	xlReal32     = 0xC5
+	xlUbyteArray = 0xC8
+	xlUint16XY   = 0xD1
+	xlSint16XY   = 0xD3
+	xlReal32XY   = 0xD5
+	xlAttr       = 0xF8
+	xlData       = 0xFA
+)
+
+// Operators of PCL XL.
+const (
+	xlBeginSession    = 0x41
+	xlEndSession      = 0x42
+	xlBeginPage       = 0x43
+	xlEndPage         = 0x44
+	xlOpenDataSource  = 0x48
+	xlCloseDataSource = 0x49
+	xlPopGS           = 0x60
+	xlPushGS          = 0x61
+	xlSetBrushSource  = 0x63
+	xlSetColorSpace   = 0x6A
+	xlSetCursor       = 0x6B
+	xlSetFont         = 0x6F
+	xlSetPenSource    = 0x79
+	xlSetPenWidth     = 0x7A
+	xlSetSourceTxMode = 0x7C
+	xlNewPath         = 0x85
+	xlPaintPath       = 0x86
+	xlLinePath        = 0x9B
+	xlText            = 0xA8
+	xlBeginImage      = 0xB0
+	xlReadImage       = 0xB1
+	xlEndImage        = 0xB2
+)
+
+// Attributes of PCL XL.
+const (
+	xlPaletteDepth         = 2
+	xlColorSpace           = 3
+	xlNullBrush            = 4
+	xlPaletteData          = 6
+	xlRGBColor             = 11
+	xlMediaSize            = 37
+	xlOrientation          = 40
+	xlTxMode               = 45
+	xlCustomMediaSize      = 47
+	xlCustomMediaSizeUnits = 48
+	xlEndPoint             = 69
+	xlPenWidth             = 75
+	xlPoint                = 76
+	xlColorDepth           = 98
+	xlBlockHeight          = 99
+	xlColorMapping         = 100
+	xlCompressMode         = 101
+	xlDestinationSize      = 103
+	xlSourceHeight         = 107
+	xlSourceWidth          = 108
+	xlStartLine            = 109
+	xlDataOrg              = 130
+	xlMeasure              = 134
+	xlSourceType           = 136
+	xlUnitsPerMeasure      = 137
+	xlErrorReport          = 143
+	xlCharSize             = 166
+	xlFontName             = 168
+	xlSymbolSet            = 170
+	xlTextData             = 171
+)
+
+// Enumerations of PCL XL attributes.
+const (
+	xlInch              = 0
+	xlBackChAndErrPage  = 3
+	xlBinaryLowFirst    = 1
+	xlDefaultDataSource = 0
+	xlPortrait          = 0
+	xlRGB               = 2
+	xlIndexedPixel      = 1
+	xl1Bit              = 0
+	xl8Bit              = 2
+	xlRLECompression    = 1
+	xlTransparent       = 1
+)
+
+// xlMediaSizes are the PCL XL media size codes.
+var xlMediaSizes = map[device.PageSize]byte{
+	device.Letter: 0,
+	device.A4:     2,
+	device.A5:     16,
+}
+
+// xlFonts are the resident PCL XL fonts standing in for the Windows faces,
+// regular, bold, italic and bold italic, Arial being used for the other
+// names.
+var xlFonts = map[string][4]string{
+	"arial":           {"Arial", "ArialBd", "ArialIt", "ArialBdIt"},
+	"courier":         {"Courier", "CourierBd", "CourierIt", "CourierBdIt"},
+	"courier new":     {"Courier", "CourierBd", "CourierIt", "CourierBdIt"},
+	"times":           {"TimesNewRmn", "TimesNewRmnBd", "TimesNewRmnIt", "TimesNewRmnBdIt"},
+	"times new roman": {"TimesNewRmn", "TimesNewRmnBd", "TimesNewRmnIt", "TimesNewRmnBdIt"},
+}
+
+// xl19U is the 19U symbol set, Windows-1252, as a PCL XL value.
+const xl19U = 19*32 + 'U' - 64
+
+var errImageTooLarge = errors.New("pcl: image too large for PCL XL")
+
+// xlStream encodes PCL XL operators, with the little endian binding.
+type xlStream struct {
+	bytes.Buffer
+}
+
+func (s *xlStream) attr(id byte) {
+	s.WriteByte(xlAttr)
+	s.WriteByte(id)
+}
+
+func (s *xlStream) ubyte(v byte, id byte) {
+	s.WriteByte(xlUbyte)
+	s.WriteByte(v)
+	s.attr(id)
+}
+
+func (s *xlStream) uint16(v int, id byte) {
+	s.WriteByte(xlUint16)
+	s.Write(binary.LittleEndian.AppendUint16(nil, uint16(v)))
+	s.attr(id)
+}
+
+func (s *xlStream) real32(v float64, id byte) {
+	s.WriteByte(xlReal32)
+	s.Write(binary.LittleEndian.AppendUint32(nil, math.Float32bits(float32(v))))
+	s.attr(id)
+}
+
+func (s *xlStream) uint16XY(x, y int, id byte) {
+	s.WriteByte(xlUint16XY)
+	s.Write(binary.LittleEndian.AppendUint16(nil, uint16(x)))
+	s.Write(binary.LittleEndian.AppendUint16(nil, uint16(y)))
+	s.attr(id)
+}
+
+// point encodes a point, clamped to the sint16 range of PCL XL.
+func (s *xlStream) point(p image.Point, id byte) {
+	clamp := func(v int) uint16 {
+		return uint16(int16(min(max(v, math.MinInt16), math.MaxInt16)))
+	}
+	s.WriteByte(xlSint16XY)
+	s.Write(binary.LittleEndian.AppendUint16(nil, clamp(p.X)))
+	s.Write(binary.LittleEndian.AppendUint16(nil, clamp(p.Y)))
+	s.attr(id)
+}
+
+func (s *xlStream) real32XY(x, y float64, id byte) {
+	s.WriteByte(xlReal32XY)
+	s.Write(binary.LittleEndian.AppendUint32(nil, math.Float32bits(float32(x))))
+	s.Write(binary.LittleEndian.AppendUint32(nil, math.Float32bits(float32(y))))
+	s.attr(id)
+}
+
+func (s *xlStream) ubyteArray(b []byte, id byte) {
+	s.WriteByte(xlUbyteArray)
+	s.WriteByte(xlUint16)
+	s.Write(binary.LittleEndian.AppendUint16(nil, uint16(len(b))))
+	s.Write(b)
+	s.attr(id)
+}
+
+func (s *xlStream) op(tag byte) {
+	s.WriteByte(tag)
+}
+
+// data writes the embedded data following an operator.
+func (s *xlStream) data(b []byte) {
+	s.WriteByte(xlData)
+	s.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(b))))
+	s.Write(b)
+}
+
+// XL writes the pages drawn on it as PCL XL, also called PCL 6, the binary
+// language of recent HP printers.
+type XL struct {
+	// ImageOptions tells how images are converted to black and white dots,
+	// a threshold at mid gray when nil.
+	ImageOptions *mono.Options
+
+	w       io.Writer
+	size    device.PageSize
+	dpi     int
+	started bool
+
+	page      *xlStream
+	pos       image.Point
+	font      device.Font
+	textColor color.Color
+	selected  string
+	brush     color.RGBA
+}
+
+var _ device.Device = (*XL)(nil)
+
+// NewXL returns an XL writing to w, with pages of the given size addressed
+// in pixels at dpi dots per inch. Coordinates are relative to the edge of
+// the paper.
+func NewXL(w io.Writer, size device.PageSize, dpi int) *XL {
+	return &XL{
+		w:         w,
+		size:      size,
+		dpi:       dpi,
+		font:      device.DefaultFont(dpi),
+		textColor: color.Black,
+	}
+}
+
+// StartDoc switches the printer to PCL XL and opens the session, in which
+// user units are device pixels.
+func (p *XL) StartDoc(docName string) error {
+	s := &xlStream{}
+	s.WriteString(esc + "%-12345X@PJL ENTER LANGUAGE = PCLXL\r\n")
+	s.WriteString(") HP-PCL XL;2;0;golang-win32-printer\r\n")
+	s.uint16XY(p.dpi, p.dpi, xlUnitsPerMeasure)
+	s.ubyte(xlInch, xlMeasure)
+	s.ubyte(xlBackChAndErrPage, xlErrorReport)
+	s.op(xlBeginSession)
+	s.ubyte(xlDefaultDataSource, xlSourceType)
+	s.ubyte(xlBinaryLowFirst, xlDataOrg)
+	s.op(xlOpenDataSource)
+	p.started = true
+	_, err := p.w.Write(s.Bytes())
+	return err
+}
+
+// StartPage begins a page in the RGB color space, with a two color palette
+// for images whose white pixels are transparent.
+func (p *XL) StartPage() error {
+	if !p.started {
+		if err := p.StartDoc(""); err != nil {
+			return err
+		}
+	}
+	s := &xlStream{}
+	s.ubyte(xlPortrait, xlOrientation)
+	if media, ok := xlMediaSizes[p.size]; ok {
+		s.ubyte(media, xlMediaSize)
+	} else {
+		s.real32XY(p.size.Width/25.4, p.size.Height/25.4, xlCustomMediaSize)
+		s.ubyte(xlInch, xlCustomMediaSizeUnits)
+	}
+	s.op(xlBeginPage)
+	s.ubyte(xlRGB, xlColorSpace)
+	s.ubyte(xl8Bit, xlPaletteDepth)
+	s.ubyteArray([]byte{0xFF, 0xFF, 0xFF, 0, 0, 0}, xlPaletteData)
+	s.op(xlSetColorSpace)
+	s.ubyte(xlTransparent, xlTxMode)
+	s.op(xlSetSourceTxMode)
+	p.page = s
+	p.pos = image.Point{}
+	p.selected = ""
+	p.brush = color.RGBA{A: 0xFF}
+	return nil
+}
+
+func (p *XL) EndPage() error {
+	if p.page == nil {
+		return errNoPage
+	}
+	p.page.op(xlEndPage)
+	_, err := p.w.Write(p.page.Bytes())
+	p.page = nil
+	return err
+}
+
+// EndDoc closes the session, ending a page left open, and returns the
+// printer to its default language.
+func (p *XL) EndDoc() error {
+	if p.page != nil {
+		if err := p.EndPage(); err != nil {
+			return err
+		}
+	}
+	if !p.started {
+		if err := p.StartDoc(""); err != nil {
+			return err
+		}
+	}
+	p.started = false
+	s := &xlStream{}
+	s.op(xlCloseDataSource)
+	s.op(xlEndSession)
+	s.WriteString(esc + "%-12345X")
+	_, err := p.w.Write(s.Bytes())
+	return err
+}
+
+// selectFont selects the resident font closest to the current one, when it
+// changed since the last text.
+func (p *XL) selectFont() {
+	family, ok := xlFonts[strings.ToLower(p.font.Name)]
+	if !ok {
+		family = xlFonts["arial"]
+	}
+	style := 0
+	if p.font.Bold {
+		style |= 1
+	}
+	if p.font.Italic {
+		style |= 2
+	}
+	name := fmt.Sprintf("%-16s", family[style])
+	sel := fmt.Sprintf("%s %d", name, p.font.Height)
+	if sel == p.selected {
+		return
+	}
+	p.page.ubyteArray([]byte(name), xlFontName)
+	p.page.real32(float64(p.font.Height), xlCharSize)
+	p.page.uint16(xl19U, xlSymbolSet)
+	p.page.op(xlSetFont)
+	p.selected = sel
+}
+
+// setBrush sets the color text is printed with.
+func (p *XL) setBrush(c color.Color) {
+	rgba := color.RGBAModel.Convert(c).(color.RGBA)
+	rgba.A = 0xFF
+	if rgba == p.brush {
+		return
+	}
+	p.page.ubyteArray([]byte{rgba.R, rgba.G, rgba.B}, xlRGBColor)
+	p.page.op(xlSetBrushSource)
+	p.brush = rgba
+}
+
+// TextOut prints text in a resident font. The baseline, where PCL XL places
+// text, is computed with the metrics of the portable font of the same name.
+func (p *XL) TextOut(x, y uint32, text string) error {
+	if p.page == nil {
+		return errNoPage
+	}
+	ascent, err := fonts.Lookup(p.font.Name, p.font.Bold, p.font.Italic).Ascent(float64(p.font.Height))
+	if err != nil {
+		return err
+	}
+	p.selectFont()
+	p.setBrush(p.textColor)
+	p.page.point(image.Pt(int(x), int(y)+int(ascent+0.5)), xlPoint)
+	p.page.op(xlSetCursor)
+	p.page.ubyteArray(encodeText(text), xlTextData)
+	p.page.op(xlText)
+	return nil
+}
+
+func (p *XL) GetTextExtentPoint32(text string) (uint32, uint32, error) {
+	f := fonts.Lookup(p.font.Name, p.font.Bold, p.font.Italic)
+	w, h, err := f.Metrics(text, float64(p.font.Height))
+	if err != nil {
+		return 0, 0, err
+	}
+	return uint32(w + 0.5), uint32(h + 0.5), nil
+}
+
+func (p *XL) MoveTo(x, y uint32) error {
+	p.pos = image.Point{X: int(x), Y: int(y)}
+	return nil
+}
+
+// LineTo strokes a path, leaving the brush of text unchanged.
+func (p *XL) LineTo(x, y uint32) error {
+	if p.page == nil {
+		return errNoPage
+	}
+	to := image.Point{X: int(x), Y: int(y)}
+	s := p.page
+	s.op(xlPushGS)
+	s.ubyte(0, xlNullBrush)
+	s.op(xlSetBrushSource)
+	s.ubyteArray([]byte{0, 0, 0}, xlRGBColor)
+	s.op(xlSetPenSource)
+	s.uint16(1, xlPenWidth)
+	s.op(xlSetPenWidth)
+	s.op(xlNewPath)
+	s.point(p.pos, xlPoint)
+	s.op(xlSetCursor)
+	s.point(to, xlEndPoint)
+	s.op(xlLinePath)
+	s.op(xlPaintPath)
+	s.op(xlPopGS)
+	p.pos = to
+	return nil
+}
+
+// DrawImage prints img as a 1 bit image, scaled to width x height pixels
+// and converted to black and white with ImageOptions. White pixels are
+// transparent. Rows are compressed with PackBits, in blocks of about 64 KB.
+func (p *XL) DrawImage(x, y, width, height uint32, img image.Image) error {
+	if p.page == nil {
+		return errNoPage
+	}
+	b := img.Bounds()
+	if width == 0 || height == 0 || b.Empty() {
+		return nil
+	}
+	if width > math.MaxUint16 || height > math.MaxUint16 {
+		return errImageTooLarge
+	}
+	if b.Dx() != int(width) || b.Dy() != int(height) {
+		scaled := image.NewRGBA(image.Rect(0, 0, int(width), int(height)))
+		stddraw.Draw(scaled, scaled.Bounds(), image.White, image.Point{}, stddraw.Src)
+		draw.ApproxBiLinear.Scale(scaled, scaled.Bounds(), img, b, draw.Over, nil)
+		img = scaled
+	}
+	m, ok := img.(*mono.Image)
+	if !ok {
+		m = mono.Convert(img, p.ImageOptions)
+	}
+	b = m.Bounds()
+	s := p.page
+	s.point(image.Pt(int(x), int(y)), xlPoint)
+	s.op(xlSetCursor)
+	s.ubyte(xlIndexedPixel, xlColorMapping)
+	s.ubyte(xl1Bit, xlColorDepth)
+	s.uint16(b.Dx(), xlSourceWidth)
+	s.uint16(b.Dy(), xlSourceHeight)
+	s.uint16XY(b.Dx(), b.Dy(), xlDestinationSize)
+	s.op(xlBeginImage)
+	// Rows are padded to 32 bits.
+	stride := (b.Dx() + 31) / 32 * 4
+	block := max(1, 65536/stride)
+	row := make([]byte, stride)
+	var data []byte
+	for start := 0; start < b.Dy(); start += block {
+		n := min(block, b.Dy()-start)
+		data = data[:0]
+		for y := start; y < start+n; y++ {
+			copy(row, m.Row(b.Min.Y + y)[:(b.Dx()+7)/8])
+			data = packBits(data, row)
+		}
+		s.uint16(start, xlStartLine)
+		s.uint16(n, xlBlockHeight)
+		s.ubyte(xlRLECompression, xlCompressMode)
+		s.op(xlReadImage)
+		s.data(data)
+	}
+	s.op(xlEndImage)
+	return nil
+}
+
+func (p *XL) SetFont(fontName string) error {
+	p.font.Name = fontName
+	return nil
+}
+
+func (p *XL) SetTextSize(size int32) (int32, error) {
+	old := p.font.Height
+	p.font.Height = size
+	return old, nil
+}
+
+func (p *XL) SetBoldFont(bold bool) error {
+	p.font.Bold = bold
+	return nil
+}
+
+func (p *XL) SetItalicFont(italic bool) error {
+	p.font.Italic = italic
+	return nil
+}
+
+func (p *XL) SetTextColor(c color.Color) (color.Color, error) {
+	old := p.textColor
+	p.textColor = c
+	return old, nil
+}
+
+func (p *XL) GetDeviceCaps(index device.Cap) (uint32, error) {
+	return p.size.Caps(p.dpi, index)
+}
@@ -0,0 +1,182 @@
+package pcl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/sipkg/golang-win32-printer/device"
+	"github.com/sipkg/golang-win32-printer/image/mono"
+)
+
+// xlOp is a decoded PCL XL operator, with its attributes and embedded data.
+type xlOp struct {
+	tag   byte
+	attrs map[byte][]byte
+	data  []byte
+}
+
+// decodeXL decodes the operators of the PCL XL stream following the header.
+func decodeXL(t *testing.T, out []byte) []xlOp {
+	t.Helper()
+	const header = ") HP-PCL XL;2;0;"
+	i := bytes.Index(out, []byte(header))
+	if i < 0 {
+		t.Fatalf("no PCL XL header in %q", out)
+	}
+	s := out[i+bytes.IndexByte(out[i:], '\n')+1:]
+	sizes := map[byte]int{xlUbyte: 1, xlUint16: 2, xlReal32: 4, xlUint16XY: 4, xlSint16XY: 4, xlReal32XY: 8}
+	var ops []xlOp
+	var value []byte
+	attrs := map[byte][]byte{}
+	for len(s) > 0 && s[0] != 0x1B {
+		tag := s[0]
+		s = s[1:]
+		switch {
+		case sizes[tag] > 0:
+			value, s = s[:sizes[tag]], s[sizes[tag]:]
+		case tag == xlUbyteArray:
+			if s[0] != xlUint16 {
+				t.Fatalf("array length of type %#x", s[0])
+			}
+			n := int(binary.LittleEndian.Uint16(s[1:]))
+			value, s = s[3:3+n], s[3+n:]
+		case tag == xlAttr:
+			attrs[s[0]] = value
+			s = s[1:]
+		case tag == xlData:
+			n := int(binary.LittleEndian.Uint32(s))
+			ops[len(ops)-1].data, s = s[4:4+n], s[4+n:]
+		case tag >= 0x41 && tag < 0xC0:
+			ops = append(ops, xlOp{tag: tag, attrs: attrs})
+			attrs = map[byte][]byte{}
+		default:
+			t.Fatalf("unknown tag %#x", tag)
+		}
+	}
+	return ops
+}
+
+func TestXLDocument(t *testing.T) {
+	var out bytes.Buffer
+	p := NewXL(&out, device.A4, 300)
+	p.StartDoc("report")
+	p.StartPage()
+	p.SetFont("Courier New")
+	p.SetTextSize(50)
+	p.SetBoldFont(true)
+	if err := p.TextOut(100, 100, "Été"); err != nil {
+		t.Fatal(err)
+	}
+	p.TextOut(100, 200, "€")
+	p.MoveTo(10, 300)
+	p.LineTo(110, 300)
+	p.EndPage()
+	p.StartPage()
+	p.SetFont("Arial")
+	p.SetBoldFont(false)
+	p.TextOut(0, 0, "x")
+	p.EndDoc()
+
+	got := out.Bytes()
+	if !bytes.HasPrefix(got, []byte("\x1b%-12345X@PJL ENTER LANGUAGE = PCLXL\r\n) HP-PCL XL;2;0;")) {
+		t.Errorf("stream starts with %q", got[:min(len(got), 60)])
+	}
+	if !bytes.HasSuffix(got, []byte{xlCloseDataSource, xlEndSession, 0x1B, '%', '-', '1', '2', '3', '4', '5', 'X'}) {
+		t.Errorf("stream ends with %q", got[max(len(got)-20, 0):])
+	}
+	ops := decodeXL(t, got)
+	var tags, fonts, texts []string
+	for _, op := range ops {
+		tags = append(tags, string(rune(op.tag)))
+		switch op.tag {
+		case xlBeginSession:
+			if v := op.attrs[xlUnitsPerMeasure]; !bytes.Equal(v, []byte{44, 1, 44, 1}) {
+				t.Errorf("units per measure %v", v)
+			}
+		case xlBeginPage:
+			if v := op.attrs[xlMediaSize]; !bytes.Equal(v, []byte{2}) {
+				t.Errorf("media size %v", v)
+			}
+		case xlSetFont:
+			fonts = append(fonts, string(op.attrs[xlFontName]))
+		case xlText:
+			texts = append(texts, string(op.attrs[xlTextData]))
+		case xlLinePath:
+			if v := op.attrs[xlEndPoint]; !bytes.Equal(v, []byte{110, 0, 44, 1}) {
+				t.Errorf("line to %v", v)
+			}
+		}
+	}
+	if want := []string{"CourierBd       ", "Arial           "}; strings.Join(fonts, ",") != strings.Join(want, ",") {
+		t.Errorf("fonts %q, want %q", fonts, want)
+	}
+	if want := []string{"\xc9t\xe9", "\x80", "x"}; strings.Join(texts, ",") != strings.Join(want, ",") {
+		t.Errorf("texts %q, want %q", texts, want)
+	}
+	want := string([]rune{xlBeginSession, xlOpenDataSource,
+		xlBeginPage, xlSetColorSpace, xlSetSourceTxMode, xlSetFont, xlSetCursor, xlText, xlSetCursor, xlText,
+		xlPushGS, xlSetBrushSource, xlSetPenSource, xlSetPenWidth, xlNewPath, xlSetCursor, xlLinePath, xlPaintPath, xlPopGS, xlEndPage,
+		xlBeginPage, xlSetColorSpace, xlSetSourceTxMode, xlSetFont, xlSetCursor, xlText, xlEndPage,
+		xlCloseDataSource, xlEndSession,
+	})
+	if strings.Join(tags, "") != want {
+		t.Errorf("operators %q, want %q", strings.Join(tags, ""), want)
+	}
+}
+
+func TestXLImage(t *testing.T) {
+	img := mono.New(image.Rect(0, 0, 700, 800))
+	rnd := rand.New(rand.NewSource(1))
+	for y := 0; y < 800; y++ {
+		for x := 0; x < 700; x++ {
+			img.SetBlack(x, y, x < 300 || rnd.Intn(2) == 0 && y > 700)
+		}
+	}
+	var out bytes.Buffer
+	p := NewXL(&out, device.Letter, 300)
+	p.StartPage()
+	if err := p.DrawImage(20, 30, 700, 800, img); err != nil {
+		t.Fatal(err)
+	}
+	p.EndDoc()
+	var rows [][]byte
+	for _, op := range decodeXL(t, out.Bytes()) {
+		switch op.tag {
+		case xlBeginImage:
+			if v := op.attrs[xlSourceWidth]; !bytes.Equal(v, []byte{0xBC, 2}) {
+				t.Errorf("source width %v", v)
+			}
+		case xlReadImage:
+			if len(rows) != int(binary.LittleEndian.Uint16(op.attrs[xlStartLine])) {
+				t.Errorf("block starts at line %v after %d rows", op.attrs[xlStartLine], len(rows))
+			}
+			// Rows of 700 pixels are padded to 88 bytes.
+			for d := op.data; len(d) > 0; {
+				var row []byte
+				for len(row) < 88 {
+					c := int8(d[0])
+					if c >= 0 {
+						row = append(row, d[1:2+int(c)]...)
+						d = d[2+int(c):]
+					} else {
+						row = append(row, bytes.Repeat(d[1:2], 1-int(c))...)
+						d = d[2:]
+					}
+				}
+				rows = append(rows, row)
+			}
+		}
+	}
+	if len(rows) != 800 {
+		t.Fatalf("%d rows, want 800", len(rows))
+	}
+	for y, row := range rows {
+		if want := img.Row(y)[:88]; !bytes.Equal(row, want) {
+			t.Fatalf("row %d is\n%x\nwant\n%x", y, row, want)
+		}
+	}
+}
@@ -0,0 +1,460 @@
+// Package pdf implements device.Device by writing a PDF file in pure Go.
+//
+// Coordinates are device pixels at the resolution given to New, exactly as on
+// the GDI printer, and are converted to PDF points when the page is written.
+// Text is drawn with the TrueType fonts of package fonts, embedded in full,
+// and images are stored as compressed RGB samples.
+//
+//	f, _ := os.Create("ticket.pdf")
+//	p := pdf.New(f, device.A4, 300)
+//	ticket.RenderA4(p, margin, textHeight, pdv, t)
+//	f.Close()
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sipkg/golang-win32-printer/device"
+	"github.com/sipkg/golang-win32-printer/device/fonts"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+var errNoPage = errors.New("pdf: must call StartPage before")
+
+// Object numbers reserved for the document structure.
+const (
+	catalogID = iota + 1
+	pagesID
+	resourcesID
+	infoID
+)
+
+type fontRes struct {
+	name   string
+	font   *fonts.Font
+	id     int
+	glyphs map[sfnt.GlyphIndex]rune
+}
+
+// PDF writes the pages drawn on it as a PDF document to an io.Writer when
+// EndDoc is called.
+type PDF struct {
+	w        io.Writer
+	size     device.PageSize
+	dpi      int
+	width    int
+	height   int
+	title    string
+	objs     [][]byte
+	pageIDs  []int
+	fonts    []*fontRes
+	imageIDs []int
+
+	page      *bytes.Buffer
+	pos       image.Point
+	font      device.Font
+	textColor color.Color
+	buf       sfnt.Buffer
+}
+
+var _ device.Device = (*PDF)(nil)
+
+// New returns a PDF writing to w, with pages of the given size addressed in
+// pixels at dpi dots per inch.
+func New(w io.Writer, size device.PageSize, dpi int) *PDF {
+	width, height := size.Pixels(dpi)
+	return &PDF{
+		w:         w,
+		size:      size,
+		dpi:       dpi,
+		width:     width,
+		height:    height,
+		font:      device.DefaultFont(dpi),
+		textColor: color.Black,
+	}
+}
+
+// StartDoc starts a new document, discarding anything drawn before.
+func (p *PDF) StartDoc(docName string) error {
+	p.title = docName
+	p.objs = make([][]byte, infoID)
+	p.pageIDs = nil
+	p.fonts = nil
+	p.imageIDs = nil
+	return nil
+}
+
+func (p *PDF) StartPage() error {
+	if p.objs == nil {
+		p.StartDoc("")
+	}
+	p.page = &bytes.Buffer{}
+	p.pos = image.Point{}
+	return nil
+}
+
+func (p *PDF) EndPage() error {
+	if p.page == nil {
+		return errNoPage
+	}
+	content := p.addObj(stream("", p.page.Bytes()))
+	p.pageIDs = append(p.pageIDs, p.addObj([]byte(fmt.Sprintf(
+		"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources %d 0 R /Contents %d 0 R >>",
+		pagesID, num(p.pt(float64(p.width))), num(p.pt(float64(p.height))), resourcesID, content))))
+	p.page = nil
+	return nil
+}
+
+// EndDoc writes the whole document to the underlying writer.
+func (p *PDF) EndDoc() error {
+	if p.page != nil {
+		if err := p.EndPage(); err != nil {
+			return err
+		}
+	}
+	if p.objs == nil {
+		p.StartDoc("")
+	}
+	for _, f := range p.fonts {
+		if err := p.writeFont(f); err != nil {
+			return err
+		}
+	}
+
+	var kids, fontRefs, imageRefs strings.Builder
+	for _, id := range p.pageIDs {
+		fmt.Fprintf(&kids, "%d 0 R ", id)
+	}
+	for _, f := range p.fonts {
+		fmt.Fprintf(&fontRefs, "/%s %d 0 R ", f.name, f.id)
+	}
+	for i, id := range p.imageIDs {
+		fmt.Fprintf(&imageRefs, "/Im%d %d 0 R ", i+1, id)
+	}
+	p.objs[catalogID-1] = []byte(fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+	p.objs[pagesID-1] = []byte(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>",
+		strings.TrimSpace(kids.String()), len(p.pageIDs)))
+	p.objs[resourcesID-1] = []byte(fmt.Sprintf("<< /ProcSet [/PDF /Text /ImageC] /Font << %s>> /XObject << %s>> >>",
+		fontRefs.String(), imageRefs.String()))
+	p.objs[infoID-1] = []byte(fmt.Sprintf("<< /Title %s /Producer (golang-win32-printer) >>", textString(p.title)))
+
+	out := &bytes.Buffer{}
+	out.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+	offsets := make([]int, len(p.objs))
+	for i, obj := range p.objs {
+		offsets[i] = out.Len()
+		fmt.Fprintf(out, "%d 0 obj\n", i+1)
+		out.Write(obj)
+		out.WriteString("\nendobj\n")
+	}
+	xref := out.Len()
+	fmt.Fprintf(out, "xref\n0 %d\n0000000000 65535 f \n", len(p.objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(out, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(p.objs)+1, catalogID, infoID, xref)
+	p.objs = nil
+	_, err := p.w.Write(out.Bytes())
+	return err
+}
+
+func (p *PDF) addObj(body []byte) int {
+	p.objs = append(p.objs, body)
+	return len(p.objs)
+}
+
+// pt converts a length in device pixels to PDF points.
+func (p *PDF) pt(px float64) float64 {
+	return px * 72 / float64(p.dpi)
+}
+
+// y converts a device y coordinate to PDF user space, whose origin is at the
+// bottom left corner of the page.
+func (p *PDF) y(px float64) float64 {
+	return p.pt(float64(p.height) - px)
+}
+
+func (p *PDF) currentFont() *fontRes {
+	f := fonts.Lookup(p.font.Name, p.font.Bold, p.font.Italic)
+	for _, r := range p.fonts {
+		if r.font == f {
+			return r
+		}
+	}
+	r := &fontRes{
+		name:   fmt.Sprintf("F%d", len(p.fonts)+1),
+		font:   f,
+		id:     p.addObj(nil),
+		glyphs: map[sfnt.GlyphIndex]rune{},
+	}
+	p.fonts = append(p.fonts, r)
+	return r
+}
+
+func (p *PDF) TextOut(x, y uint32, text string) error {
+	if p.page == nil {
+		return errNoPage
+	}
+	f := p.currentFont()
+	sf := f.font.SFNT()
+	size := float64(p.font.Height)
+	ascent, err := f.font.Ascent(size)
+	if err != nil {
+		return err
+	}
+	var hex strings.Builder
+	for _, r := range text {
+		g, err := sf.GlyphIndex(&p.buf, r)
+		if err != nil {
+			return err
+		}
+		f.glyphs[g] = r
+		fmt.Fprintf(&hex, "%04X", uint16(g))
+	}
+	fmt.Fprintf(p.page, "BT /%s %s Tf %s rg %s %s Td <%s> Tj ET\n",
+		f.name, num(p.pt(size)), rgb(p.textColor),
+		num(p.pt(float64(x))), num(p.y(float64(y)+ascent)), hex.String())
+	return nil
+}
+
+func (p *PDF) GetTextExtentPoint32(text string) (uint32, uint32, error) {
+	f := fonts.Lookup(p.font.Name, p.font.Bold, p.font.Italic)
+	w, h, err := f.Metrics(text, float64(p.font.Height))
+	if err != nil {
+		return 0, 0, err
+	}
+	return uint32(w + 0.5), uint32(h + 0.5), nil
+}
+
+func (p *PDF) MoveTo(x, y uint32) error {
+	p.pos = image.Point{X: int(x), Y: int(y)}
+	return nil
+}
+
+func (p *PDF) LineTo(x, y uint32) error {
+	if p.page == nil {
+		return errNoPage
+	}
+	fmt.Fprintf(p.page, "%s w 0 0 0 RG %s %s m %s %s l S\n", num(p.pt(1)),
+		num(p.pt(float64(p.pos.X))), num(p.y(float64(p.pos.Y))),
+		num(p.pt(float64(x))), num(p.y(float64(y))))
+	p.pos = image.Point{X: int(x), Y: int(y)}
+	return nil
+}
+
+func (p *PDF) DrawImage(x, y, width, height uint32, img image.Image) error {
+	if p.page == nil {
+		return errNoPage
+	}
+	b := img.Bounds()
+	samples := make([]byte, 0, 3*b.Dx()*b.Dy())
+	for py := b.Min.Y; py < b.Max.Y; py++ {
+		for px := b.Min.X; px < b.Max.X; px++ {
+			// Lay the pixel over white paper, the image having no soft
+			// mask.
+			r, g, bl, a := img.At(px, py).RGBA()
+			samples = append(samples, byte((r+0xFFFF-a)>>8), byte((g+0xFFFF-a)>>8), byte((bl+0xFFFF-a)>>8))
+		}
+	}
+	id := p.addObj(stream(fmt.Sprintf(
+		"/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8",
+		b.Dx(), b.Dy()), samples))
+	p.imageIDs = append(p.imageIDs, id)
+	fmt.Fprintf(p.page, "q %s 0 0 %s %s %s cm /Im%d Do Q\n",
+		num(p.pt(float64(width))), num(p.pt(float64(height))),
+		num(p.pt(float64(x))), num(p.y(float64(y+height))), len(p.imageIDs))
+	return nil
+}
+
+func (p *PDF) SetFont(fontName string) error {
+	p.font.Name = fontName
+	return nil
+}
+
+func (p *PDF) SetTextSize(size int32) (int32, error) {
+	old := p.font.Height
+	p.font.Height = size
+	return old, nil
+}
+
+func (p *PDF) SetBoldFont(bold bool) error {
+	p.font.Bold = bold
+	return nil
+}
+
+func (p *PDF) SetItalicFont(italic bool) error {
+	p.font.Italic = italic
+	return nil
+}
+
+func (p *PDF) SetTextColor(c color.Color) (color.Color, error) {
+	old := p.textColor
+	p.textColor = c
+	return old, nil
+}
+
+func (p *PDF) GetDeviceCaps(index device.Cap) (uint32, error) {
+	return p.size.Caps(p.dpi, index)
+}
+
+// writeFont embeds f as a Type0 font with Identity-H encoding, so that the
+// content streams can address glyphs by their index in the TrueType file.
+func (p *PDF) writeFont(f *fontRes) error {
+	sf := f.font.SFNT()
+	ppem := fixed.I(1000)
+	baseFont, err := sf.Name(&p.buf, sfnt.NameIDPostScript)
+	if err != nil || baseFont == "" {
+		baseFont = strings.ReplaceAll(f.font.Name, " ", "")
+	}
+	m, err := sf.Metrics(&p.buf, ppem, font.HintingNone)
+	if err != nil {
+		return err
+	}
+	bounds, err := sf.Bounds(&p.buf, ppem, font.HintingNone)
+	if err != nil {
+		return err
+	}
+
+	gids := make([]sfnt.GlyphIndex, 0, len(f.glyphs))
+	for g := range f.glyphs {
+		gids = append(gids, g)
+	}
+	sort.Slice(gids, func(i, j int) bool { return gids[i] < gids[j] })
+	var widths strings.Builder
+	for _, g := range gids {
+		adv, err := sf.GlyphAdvance(&p.buf, g, ppem, font.HintingNone)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&widths, "%d [%d] ", g, adv.Round())
+	}
+
+	flags := 32 // Nonsymbolic
+	if f.font.Monospace {
+		flags |= 1
+	}
+	italicAngle := 0.0
+	if post := sf.PostTable(); post != nil {
+		italicAngle = post.ItalicAngle
+	}
+	if f.font.Italic {
+		flags |= 64
+	}
+
+	file := p.addObj(stream(fmt.Sprintf("/Length1 %d", len(f.font.Data)), f.font.Data))
+	descriptor := p.addObj([]byte(fmt.Sprintf(
+		"<< /Type /FontDescriptor /FontName /%s /Flags %d /FontBBox [%d %d %d %d] /ItalicAngle %s /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		baseFont, flags, bounds.Min.X.Round(), -bounds.Max.Y.Round(), bounds.Max.X.Round(), -bounds.Min.Y.Round(),
+		num(italicAngle), m.Ascent.Round(), -m.Descent.Round(), m.CapHeight.Round(), file)))
+	cid := p.addObj([]byte(fmt.Sprintf(
+		"<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /W [%s] /CIDToGIDMap /Identity >>",
+		baseFont, descriptor, strings.TrimSpace(widths.String()))))
+	toUnicode := p.addObj(stream("", toUnicodeCMap(gids, f.glyphs)))
+	p.objs[f.id-1] = []byte(fmt.Sprintf(
+		"<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		baseFont, cid, toUnicode))
+	return nil
+}
+
+// toUnicodeCMap maps the glyphs used back to text, so that the document can
+// be searched and copied from.
+func toUnicodeCMap(gids []sfnt.GlyphIndex, glyphs map[sfnt.GlyphIndex]rune) []byte {
+	b := &bytes.Buffer{}
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	b.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	b.WriteString("/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	b.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for len(gids) > 0 {
+		n := min(len(gids), 100)
+		fmt.Fprintf(b, "%d beginbfchar\n", n)
+		for _, g := range gids[:n] {
+			fmt.Fprintf(b, "<%04X> <", uint16(g))
+			for _, u := range utf16Encode(glyphs[g]) {
+				fmt.Fprintf(b, "%04X", u)
+			}
+			b.WriteString(">\n")
+		}
+		b.WriteString("endbfchar\n")
+		gids = gids[n:]
+	}
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return b.Bytes()
+}
+
+func utf16Encode(r rune) []uint16 {
+	if r < 0x10000 {
+		return []uint16{uint16(r)}
+	}
+	r -= 0x10000
+	return []uint16{uint16(0xD800 + r>>10), uint16(0xDC00 + r&0x3FF)}
+}
+
+// stream returns a Flate compressed stream object with the extra dictionary
+// entries dict.
+func stream(dict string, data []byte) []byte {
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write(data)
+	zw.Close()
+	if dict != "" {
+		dict += " "
+	}
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "<< %s/Filter /FlateDecode /Length %d >>\nstream\n", dict, z.Len())
+	b.Write(z.Bytes())
+	b.WriteString("\nendstream")
+	return b.Bytes()
+}
+
+// textString encodes s as a PDF text string, in UTF-16BE when it is not
+// plain ASCII.
+func textString(s string) string {
+	ascii := true
+	for _, r := range s {
+		if r >= 0x80 {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		r := strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`)
+		return "(" + r.Replace(s) + ")"
+	}
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, r := range s {
+		for _, u := range utf16Encode(r) {
+			fmt.Fprintf(&b, "%04X", u)
+		}
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+func rgb(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("%s %s %s", num(float64(r)/0xFFFF), num(float64(g)/0xFFFF), num(float64(b)/0xFFFF))
+}
+
+// num formats v with at most three decimals, as PDF readers expect no
+// exponent.
+func num(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 3, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
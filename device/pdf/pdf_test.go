@@ -0,0 +1,140 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/sipkg/golang-win32-printer/device"
+)
+
+type doc struct {
+	t   *testing.T
+	raw []byte
+}
+
+// object returns the body of object id, checking that the xref table points
+// at it.
+func (d doc) object(id int) string {
+	d.t.Helper()
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(d.raw)
+	if m == nil {
+		d.t.Fatal("missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	entries := strings.Split(string(d.raw[xref:]), "\n")
+	off, _ := strconv.Atoi(entries[2+id][:10])
+	head := fmt.Sprintf("%d 0 obj\n", id)
+	if !bytes.HasPrefix(d.raw[off:], []byte(head)) {
+		d.t.Fatalf("xref entry of object %d points at %q", id, d.raw[off:off+20])
+	}
+	body := d.raw[off+len(head):]
+	return string(body[:bytes.Index(body, []byte("\nendobj\n"))])
+}
+
+// stream returns the decompressed data of stream object id.
+func (d doc) stream(id int) string {
+	d.t.Helper()
+	obj := d.object(id)
+	i := strings.Index(obj, "stream\n")
+	r, err := zlib.NewReader(strings.NewReader(obj[i+len("stream\n"):]))
+	if err != nil {
+		d.t.Fatal(err)
+	}
+	data, _ := io.ReadAll(r)
+	return string(data)
+}
+
+func ref(t *testing.T, obj, key string) int {
+	t.Helper()
+	m := regexp.MustCompile(key + `\s*(\d+) 0 R`).FindStringSubmatch(obj)
+	if m == nil {
+		t.Fatalf("no %s in %q", key, obj)
+	}
+	id, _ := strconv.Atoi(m[1])
+	return id
+}
+
+func TestDocument(t *testing.T) {
+	var out bytes.Buffer
+	p := New(&out, device.A4, 300)
+	p.StartDoc("Ticket (1)")
+	p.StartPage()
+	p.SetTextSize(50)
+	if err := p.TextOut(100, 100, "Bé"); err != nil {
+		t.Fatal(err)
+	}
+	p.MoveTo(0, 300)
+	p.LineTo(2480, 300)
+	// The second pixel is transparent and prints white.
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.RGBA{R: 0xFF, A: 0xFF})
+	if err := p.DrawImage(10, 10, 20, 10, img); err != nil {
+		t.Fatal(err)
+	}
+	p.EndPage()
+	p.StartPage()
+	p.EndPage()
+	if err := p.EndDoc(); err != nil {
+		t.Fatal(err)
+	}
+
+	d := doc{t, out.Bytes()}
+	if !bytes.HasPrefix(d.raw, []byte("%PDF-1.4\n")) {
+		t.Fatal("missing header")
+	}
+	if got := d.object(infoID); !strings.Contains(got, `/Title (Ticket \(1\))`) {
+		t.Errorf("info = %q", got)
+	}
+	pages := d.object(pagesID)
+	if !strings.Contains(pages, "/Count 2") {
+		t.Errorf("pages = %q", pages)
+	}
+	page := d.object(ref(t, pages, `/Kids \[`))
+	if !strings.Contains(page, "/MediaBox [0 0 595.2 841.92]") {
+		t.Errorf("page = %q", page)
+	}
+
+	content := d.stream(ref(t, page, "/Contents"))
+	for _, want := range []string{
+		"BT /F1 12 Tf 0 0 0 rg 24 ",
+		"0.24 w 0 0 0 RG 0 769.92 m 595.2 769.92 l S",
+		"q 4.8 0 0 2.4 2.4 837.12 cm /Im1 Do Q",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("content %q does not contain %q", content, want)
+		}
+	}
+
+	if got := d.stream(ref(t, d.object(resourcesID), "/Im1")); got != "\xff\x00\x00\xff\xff\xff" {
+		t.Errorf("image samples %x", got)
+	}
+
+	font := d.object(ref(t, d.object(resourcesID), "/F1"))
+	if !strings.Contains(font, "/Subtype /Type0") {
+		t.Errorf("font = %q", font)
+	}
+	cmap := d.stream(ref(t, font, "/ToUnicode"))
+	if !strings.Contains(cmap, "> <0042>") || !strings.Contains(cmap, "> <00E9>") {
+		t.Errorf("ToUnicode CMap = %q", cmap)
+	}
+	descriptor := d.object(ref(t, d.object(ref(t, font, `/DescendantFonts \[`)), "/FontDescriptor"))
+	if got := d.object(ref(t, descriptor, "/FontFile2")); !strings.Contains(got, "/Length1 ") {
+		t.Errorf("font file = %q", got[:50])
+	}
+}
+
+func TestTextOutOutsidePage(t *testing.T) {
+	p := New(io.Discard, device.A4, 300)
+	p.StartDoc("")
+	if err := p.TextOut(0, 0, "x"); err == nil {
+		t.Error("TextOut() outside of a page should fail")
+	}
+}
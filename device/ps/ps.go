@@ -0,0 +1,423 @@
+// Package ps implements device.Device by writing a Level 2 PostScript
+// document, structured with the DSC comments print spoolers such as CUPS
+// rely on.
+//
+// Coordinates are device pixels at the resolution given to New, converted
+// to PostScript points. Text is drawn with the standard fonts every
+// PostScript printer has, Courier, Helvetica and Times, re-encoded in
+// Windows-1252 so that prices keep their euro sign, and images with the
+// image and colorimage operators. Pages are written as soon as they end:
+//
+//	f, _ := os.Create("ticket.ps")
+//	p := ps.New(f, device.A4, 300)
+//	ticket.RenderA4(p, margin, textHeight, pdv, t)
+//	f.Close()
+package ps
+
+import (
+	"bytes"
+	"encoding/ascii85"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/sipkg/golang-win32-printer/device"
+	"github.com/sipkg/golang-win32-printer/device/fonts"
+	"github.com/sipkg/golang-win32-printer/image/mono"
+	"golang.org/x/text/encoding/charmap"
+)
+
+var errNoPage = errors.New("ps: must call StartPage before")
+
+// prolog defines WinAnsiEncoding, the Windows-1252 encoding vector: ISO
+// Latin 1 with the hyphen and quotes of ASCII and the euro sign, dashes and
+// curly quotes in 128 to 159. SF selects a standard font re-encoded with
+// it: size /BaseFont /NewName SF.
+const prolog = `/WinAnsiEncoding ISOLatin1Encoding dup length array copy
+  dup 39 /quotesingle put dup 45 /hyphen put dup 96 /grave put
+  dup 128 [ /Euro /.notdef /quotesinglbase /florin /quotedblbase /ellipsis
+  /dagger /daggerdbl /circumflex /perthousand /Scaron /guilsinglleft /OE
+  /.notdef /Zcaron /.notdef /.notdef /quoteleft /quoteright /quotedblleft
+  /quotedblright /bullet /endash /emdash /tilde /trademark /scaron
+  /guilsinglright /oe /.notdef /zcaron /Ydieresis ] putinterval def
+/RE { findfont dup length dict begin
+  { 1 index /FID ne { def } { pop pop } ifelse } forall
+  /Encoding WinAnsiEncoding def currentdict end definefont pop } bind def
+/SF { dup FontDirectory exch known { exch pop } { exch 1 index exch RE } ifelse
+  findfont exch scalefont setfont } bind def
+`
+
+// families are the standard fonts standing in for the Windows faces,
+// regular, bold, italic and bold italic, Helvetica being used for the other
+// names.
+var families = map[string][4]string{
+	"courier":         {"Courier", "Courier-Bold", "Courier-Oblique", "Courier-BoldOblique"},
+	"courier new":     {"Courier", "Courier-Bold", "Courier-Oblique", "Courier-BoldOblique"},
+	"times":           {"Times-Roman", "Times-Bold", "Times-Italic", "Times-BoldItalic"},
+	"times new roman": {"Times-Roman", "Times-Bold", "Times-Italic", "Times-BoldItalic"},
+}
+
+var helvetica = [4]string{"Helvetica", "Helvetica-Bold", "Helvetica-Oblique", "Helvetica-BoldOblique"}
+
+// PS writes the pages drawn on it as PostScript to an io.Writer.
+type PS struct {
+	w       io.Writer
+	size    device.PageSize
+	dpi     int
+	width   int
+	height  int
+	started bool
+	pages   int
+	fonts   []string
+
+	page      *bytes.Buffer
+	pos       image.Point
+	font      device.Font
+	textColor color.Color
+	selected  string
+}
+
+var _ device.Device = (*PS)(nil)
+
+// New returns a PS writing to w, with pages of the given size addressed in
+// pixels at dpi dots per inch.
+func New(w io.Writer, size device.PageSize, dpi int) *PS {
+	width, height := size.Pixels(dpi)
+	return &PS{
+		w:         w,
+		size:      size,
+		dpi:       dpi,
+		width:     width,
+		height:    height,
+		font:      device.DefaultFont(dpi),
+		textColor: color.Black,
+	}
+}
+
+// StartDoc writes the header, prolog and setup of the document.
+func (p *PS) StartDoc(docName string) error {
+	w, h := p.pt(float64(p.width)), p.pt(float64(p.height))
+	var b strings.Builder
+	b.WriteString("%!PS-Adobe-3.0\n")
+	fmt.Fprintf(&b, "%%%%Title: %s\n", text(docName))
+	b.WriteString("%%Creator: golang-win32-printer\n")
+	fmt.Fprintf(&b, "%%%%BoundingBox: 0 0 %d %d\n", int(w+0.5), int(h+0.5))
+	b.WriteString("%%LanguageLevel: 2\n")
+	b.WriteString("%%DocumentNeededResources: (atend)\n")
+	b.WriteString("%%Pages: (atend)\n")
+	b.WriteString("%%EndComments\n")
+	b.WriteString("%%BeginProlog\n" + prolog + "%%EndProlog\n")
+	fmt.Fprintf(&b, "%%%%BeginSetup\n<< /PageSize [%s %s] >> setpagedevice\n%%%%EndSetup\n", num(w), num(h))
+	p.started = true
+	p.pages = 0
+	p.fonts = nil
+	_, err := io.WriteString(p.w, b.String())
+	return err
+}
+
+func (p *PS) StartPage() error {
+	if !p.started {
+		if err := p.StartDoc(""); err != nil {
+			return err
+		}
+	}
+	p.pages++
+	p.page = &bytes.Buffer{}
+	p.pos = image.Point{}
+	p.selected = ""
+	fmt.Fprintf(p.page, "%%%%Page: %d %d\nsave\n", p.pages, p.pages)
+	return nil
+}
+
+func (p *PS) EndPage() error {
+	if p.page == nil {
+		return errNoPage
+	}
+	p.page.WriteString("restore showpage\n")
+	_, err := p.w.Write(p.page.Bytes())
+	p.page = nil
+	return err
+}
+
+// EndDoc writes the trailer, with the number of pages and the fonts used.
+func (p *PS) EndDoc() error {
+	if p.page != nil {
+		if err := p.EndPage(); err != nil {
+			return err
+		}
+	}
+	if !p.started {
+		if err := p.StartDoc(""); err != nil {
+			return err
+		}
+	}
+	var b strings.Builder
+	b.WriteString("%%Trailer\n")
+	for i, f := range p.fonts {
+		if i == 0 {
+			b.WriteString("%%DocumentNeededResources: font " + f + "\n")
+		} else {
+			b.WriteString("%%+ font " + f + "\n")
+		}
+	}
+	fmt.Fprintf(&b, "%%%%Pages: %d\n%%%%EOF\n", p.pages)
+	p.started = false
+	_, err := io.WriteString(p.w, b.String())
+	return err
+}
+
+// pt converts a length in device pixels to points.
+func (p *PS) pt(px float64) float64 {
+	return px * 72 / float64(p.dpi)
+}
+
+// y converts a device y coordinate to the default user space, whose origin
+// is at the bottom left corner of the page.
+func (p *PS) y(px float64) float64 {
+	return p.pt(float64(p.height) - px)
+}
+
+// selectFont selects the standard font standing in for the current one,
+// when it changed since the last text of the page.
+func (p *PS) selectFont() {
+	family, ok := families[strings.ToLower(p.font.Name)]
+	if !ok {
+		family = helvetica
+	}
+	style := 0
+	if p.font.Bold {
+		style |= 1
+	}
+	if p.font.Italic {
+		style |= 2
+	}
+	name := family[style]
+	sel := fmt.Sprintf("%s /%s /%s-WinAnsi SF\n", num(p.pt(float64(p.font.Height))), name, name)
+	if sel == p.selected {
+		return
+	}
+	p.page.WriteString(sel)
+	p.selected = sel
+	for _, f := range p.fonts {
+		if f == name {
+			return
+		}
+	}
+	p.fonts = append(p.fonts, name)
+}
+
+// TextOut shows text in a standard font. The baseline is computed with the
+// metrics of the portable font of the same name.
+func (p *PS) TextOut(x, y uint32, s string) error {
+	if p.page == nil {
+		return errNoPage
+	}
+	ascent, err := fonts.Lookup(p.font.Name, p.font.Bold, p.font.Italic).Ascent(float64(p.font.Height))
+	if err != nil {
+		return err
+	}
+	p.selectFont()
+	fmt.Fprintf(p.page, "%s setrgbcolor %s %s moveto %s show\n", rgb(p.textColor),
+		num(p.pt(float64(x))), num(p.y(float64(y)+ascent)), text(s))
+	return nil
+}
+
+func (p *PS) GetTextExtentPoint32(text string) (uint32, uint32, error) {
+	f := fonts.Lookup(p.font.Name, p.font.Bold, p.font.Italic)
+	w, h, err := f.Metrics(text, float64(p.font.Height))
+	if err != nil {
+		return 0, 0, err
+	}
+	return uint32(w + 0.5), uint32(h + 0.5), nil
+}
+
+func (p *PS) MoveTo(x, y uint32) error {
+	p.pos = image.Point{X: int(x), Y: int(y)}
+	return nil
+}
+
+func (p *PS) LineTo(x, y uint32) error {
+	if p.page == nil {
+		return errNoPage
+	}
+	fmt.Fprintf(p.page, "%s setlinewidth 0 setgray %s %s moveto %s %s lineto stroke\n", num(p.pt(1)),
+		num(p.pt(float64(p.pos.X))), num(p.y(float64(p.pos.Y))),
+		num(p.pt(float64(x))), num(p.y(float64(y))))
+	p.pos = image.Point{X: int(x), Y: int(y)}
+	return nil
+}
+
+// DrawImage draws img with colorimage, or with image for gray and black and
+// white images, its samples run length encoded in ASCII85.
+func (p *PS) DrawImage(x, y, width, height uint32, img image.Image) error {
+	if p.page == nil {
+		return errNoPage
+	}
+	b := img.Bounds()
+	if b.Empty() {
+		return nil
+	}
+	var samples []byte
+	bits, op := 8, "false 3 colorimage"
+	switch src := img.(type) {
+	case *mono.Image:
+		// A clear bit is black with the default decode array.
+		bits, op = 1, "image"
+		stride := (b.Dx() + 7) / 8
+		for py := b.Min.Y; py < b.Max.Y; py++ {
+			for _, v := range src.Row(py)[:stride] {
+				samples = append(samples, ^v)
+			}
+		}
+	case *image.Gray:
+		op = "image"
+		for py := b.Min.Y; py < b.Max.Y; py++ {
+			i := src.PixOffset(b.Min.X, py)
+			samples = append(samples, src.Pix[i:i+b.Dx()]...)
+		}
+	default:
+		samples = make([]byte, 0, 3*b.Dx()*b.Dy())
+		for py := b.Min.Y; py < b.Max.Y; py++ {
+			for px := b.Min.X; px < b.Max.X; px++ {
+				// Lay the pixel over white paper, as PostScript has no
+				// transparency.
+				r, g, bl, a := img.At(px, py).RGBA()
+				samples = append(samples, byte((r+0xFFFF-a)>>8), byte((g+0xFFFF-a)>>8), byte((bl+0xFFFF-a)>>8))
+			}
+		}
+	}
+	fmt.Fprintf(p.page, "gsave %s %s translate %s %s scale\n",
+		num(p.pt(float64(x))), num(p.y(float64(y+height))),
+		num(p.pt(float64(width))), num(p.pt(float64(height))))
+	fmt.Fprintf(p.page, "%d %d %d [%d 0 0 -%d 0 %d] currentfile /ASCII85Decode filter /RunLengthDecode filter %s\n",
+		b.Dx(), b.Dy(), bits, b.Dx(), b.Dy(), b.Dy(), op)
+	enc := ascii85.NewEncoder(&lineWriter{w: p.page})
+	enc.Write(runLength(samples))
+	enc.Close()
+	p.page.WriteString("~>\ngrestore\n")
+	return nil
+}
+
+func (p *PS) SetFont(fontName string) error {
+	p.font.Name = fontName
+	return nil
+}
+
+func (p *PS) SetTextSize(size int32) (int32, error) {
+	old := p.font.Height
+	p.font.Height = size
+	return old, nil
+}
+
+func (p *PS) SetBoldFont(bold bool) error {
+	p.font.Bold = bold
+	return nil
+}
+
+func (p *PS) SetItalicFont(italic bool) error {
+	p.font.Italic = italic
+	return nil
+}
+
+func (p *PS) SetTextColor(c color.Color) (color.Color, error) {
+	old := p.textColor
+	p.textColor = c
+	return old, nil
+}
+
+func (p *PS) GetDeviceCaps(index device.Cap) (uint32, error) {
+	return p.size.Caps(p.dpi, index)
+}
+
+// runLength compresses data for the RunLengthDecode filter: a length byte
+// n below 128 is followed by n+1 literal bytes, one above 128 by a byte
+// repeated 257-n times, and 128 ends the data.
+func runLength(data []byte) []byte {
+	var out []byte
+	for i := 0; i < len(data); {
+		run := 1
+		for i+run < len(data) && run < 128 && data[i+run] == data[i] {
+			run++
+		}
+		if run > 1 {
+			out = append(out, byte(257-run), data[i])
+			i += run
+			continue
+		}
+		lit := 1
+		for i+lit < len(data) && lit < 128 && (i+lit+1 >= len(data) || data[i+lit] != data[i+lit+1]) {
+			lit++
+		}
+		out = append(out, byte(lit-1))
+		out = append(out, data[i:i+lit]...)
+		i += lit
+	}
+	return append(out, 128)
+}
+
+// lineWriter breaks the ASCII85 data in lines, which DSC limits to 255
+// characters.
+type lineWriter struct {
+	w   io.Writer
+	col int
+}
+
+func (l *lineWriter) Write(b []byte) (int, error) {
+	n := len(b)
+	for len(b) > 0 {
+		k := min(len(b), 76-l.col)
+		if _, err := l.w.Write(b[:k]); err != nil {
+			return 0, err
+		}
+		b = b[k:]
+		if l.col += k; l.col == 76 {
+			if _, err := l.w.Write([]byte{'\n'}); err != nil {
+				return 0, err
+			}
+			l.col = 0
+		}
+	}
+	return n, nil
+}
+
+// text returns s as a PostScript string in Windows-1252, characters outside
+// it being replaced with '?'.
+func text(s string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, r := range s {
+		c, ok := charmap.Windows1252.EncodeRune(r)
+		switch {
+		case !ok:
+			b.WriteByte('?')
+		case c == '(' || c == ')' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c >= 0x7F:
+			fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+func rgb(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("%s %s %s", num(float64(r)/0xFFFF), num(float64(g)/0xFFFF), num(float64(b)/0xFFFF))
+}
+
+// num formats v with at most three decimals.
+func num(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 3, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
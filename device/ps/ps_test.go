@@ -0,0 +1,139 @@
+package ps
+
+import (
+	"bytes"
+	"encoding/ascii85"
+	"image"
+	"image/color"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/sipkg/golang-win32-printer/device"
+	"github.com/sipkg/golang-win32-printer/image/mono"
+)
+
+func TestDocument(t *testing.T) {
+	var out bytes.Buffer
+	p := New(&out, device.A4, 300)
+	p.StartDoc("Ticket (1)")
+	p.StartPage()
+	p.SetTextSize(50)
+	if err := p.TextOut(100, 100, "Bé (€)"); err != nil {
+		t.Fatal(err)
+	}
+	p.MoveTo(0, 300)
+	p.LineTo(2480, 300)
+	p.EndPage()
+	p.StartPage()
+	p.SetFont("Courier New")
+	p.SetBoldFont(true)
+	p.SetTextColor(color.RGBA{R: 0xFF, A: 0xFF})
+	p.TextOut(0, 0, "a")
+	p.TextOut(0, 60, "b")
+	if err := p.EndDoc(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := out.String()
+	for _, want := range []string{
+		"%!PS-Adobe-3.0\n%%Title: (Ticket \\(1\\))\n",
+		"%%BoundingBox: 0 0 595 842\n",
+		"%%Pages: (atend)\n%%EndComments\n%%BeginProlog\n",
+		"%%EndProlog\n%%BeginSetup\n<< /PageSize [595.2 841.92] >> setpagedevice\n%%EndSetup\n%%Page: 1 1\nsave\n",
+		"12 /Helvetica /Helvetica-WinAnsi SF\n0 0 0 setrgbcolor 24 ",
+		" moveto (B\\351 \\(\\200\\)) show\n",
+		"0.24 setlinewidth 0 setgray 0 769.92 moveto 595.2 769.92 lineto stroke\nrestore showpage\n%%Page: 2 2\n",
+		"12 /Courier-Bold /Courier-Bold-WinAnsi SF\n1 0 0 setrgbcolor",
+		"restore showpage\n%%Trailer\n%%DocumentNeededResources: font Helvetica\n%%+ font Courier-Bold\n%%Pages: 2\n%%EOF\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("document lacks %q:\n%s", want, got)
+		}
+	}
+	if n := strings.Count(got, " SF\n"); n != 2 {
+		t.Errorf("%d font selections, want 2", n)
+	}
+}
+
+// samples decodes the data of the image drawn by out.
+func samples(t *testing.T, out string) []byte {
+	t.Helper()
+	m := regexp.MustCompile(`filter (?:false 3 color)?image\n([^~]*)~>`).FindStringSubmatch(out)
+	if m == nil {
+		t.Fatalf("no image in %s", out)
+	}
+	rl := make([]byte, len(m[1]))
+	n, _, err := ascii85.Decode(rl, []byte(m[1]), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data []byte
+	for rl = rl[:n]; rl[0] != 128; {
+		if rl[0] < 128 {
+			data = append(data, rl[1:2+int(rl[0])]...)
+			rl = rl[2+int(rl[0]):]
+		} else {
+			data = append(data, bytes.Repeat(rl[1:2], 257-int(rl[0]))...)
+			rl = rl[2:]
+		}
+	}
+	return data
+}
+
+func TestDrawImage(t *testing.T) {
+	rgba := image.NewRGBA(image.Rect(0, 0, 300, 2))
+	for x := 0; x < 300; x++ {
+		rgba.Set(x, 0, color.RGBA{R: 0xFF, A: 0xFF})
+		rgba.Set(x, 1, color.RGBA{R: byte(x), G: byte(x / 2), B: 3, A: 0xFF})
+	}
+	bw := mono.New(image.Rect(0, 0, 10, 1))
+	bw.SetBlack(0, 0, true)
+	gray := image.NewGray(image.Rect(0, 0, 2, 1))
+	gray.Pix[1] = 0x80
+	// A logo on a transparent background prints on white.
+	logo := image.NewNRGBA(image.Rect(0, 0, 3, 1))
+	logo.Set(1, 0, color.NRGBA{B: 0xFF, A: 0xFF})
+	logo.Set(2, 0, color.NRGBA{A: 0x80})
+
+	for _, tt := range []struct {
+		img  image.Image
+		head string
+		want []byte
+	}{
+		{rgba, "300 2 8 [300 0 0 -2 0 2] currentfile /ASCII85Decode filter /RunLengthDecode filter false 3 colorimage\n", nil},
+		{bw, "10 1 1 [10 0 0 -1 0 1] currentfile /ASCII85Decode filter /RunLengthDecode filter image\n", []byte{0x7F, 0xFF}},
+		{gray, "2 1 8 [2 0 0 -1 0 1] currentfile /ASCII85Decode filter /RunLengthDecode filter image\n", []byte{0, 0x80}},
+		{logo, "3 1 8 [3 0 0 -1 0 1] currentfile /ASCII85Decode filter /RunLengthDecode filter false 3 colorimage\n",
+			[]byte{0xFF, 0xFF, 0xFF, 0, 0, 0xFF, 0x7F, 0x7F, 0x7F}},
+	} {
+		var out bytes.Buffer
+		p := New(&out, device.A4, 300)
+		p.StartPage()
+		if err := p.DrawImage(10, 20, 100, 50, tt.img); err != nil {
+			t.Fatal(err)
+		}
+		p.EndDoc()
+		got := out.String()
+		if !strings.Contains(got, "gsave 2.4 825.12 translate 24 12 scale\n"+tt.head) {
+			t.Errorf("image drawn as\n%s", got)
+		}
+		want := tt.want
+		if want == nil {
+			for y := 0; y < 2; y++ {
+				for x := 0; x < 300; x++ {
+					r, g, b, _ := rgba.At(x, y).RGBA()
+					want = append(want, byte(r>>8), byte(g>>8), byte(b>>8))
+				}
+			}
+		}
+		if data := samples(t, got); !bytes.Equal(data, want) {
+			t.Errorf("samples %x, want %x", data, want)
+		}
+		for _, line := range strings.Split(got, "\n") {
+			if len(line) > 255 {
+				t.Fatalf("line of %d characters", len(line))
+			}
+		}
+	}
+}
@@ -0,0 +1,399 @@
+// Package raster implements device.Device in pure Go by rendering every page
+// into a bgr.BGRImage, which is what the GDI printer would receive through
+// StretchDIBits. It is used for previews, golden tests and printers that only
+// accept raster data.
+//
+//	r := raster.New(device.A4, 300)
+//	ticket.RenderA4(r, margin, textHeight, pdv, t)
+//	png.Encode(w, r.Pages[0])
+package raster
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	stddraw "image/draw"
+	"math"
+
+	"github.com/sipkg/golang-win32-printer/device"
+	"github.com/sipkg/golang-win32-printer/device/fonts"
+	"github.com/sipkg/golang-win32-printer/image/bgr"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+var errNoPage = errors.New("raster: must call StartPage before")
+
+// Raster renders pages into bgr.BGRImage buffers.
+type Raster struct {
+	// Pages holds every page finished by EndPage since the last StartDoc.
+	Pages []*bgr.BGRImage
+
+	size   device.PageSize
+	dpi    int
+	width  int
+	height int
+
+	page      *bgr.BGRImage
+	pos       image.Point
+	font      device.Font
+	textColor color.Color
+	penColor  color.Color
+	penWidth  int
+	face      font.Face
+}
+
+var (
+	_ device.Device  = (*Raster)(nil)
+	_ device.Painter = (*Raster)(nil)
+)
+
+// New returns a Raster producing pages of the given size at dpi dots per inch.
+func New(size device.PageSize, dpi int) *Raster {
+	w, h := size.Pixels(dpi)
+	return &Raster{
+		size:      size,
+		dpi:       dpi,
+		width:     w,
+		height:    h,
+		font:      device.DefaultFont(dpi),
+		textColor: color.Black,
+		penColor:  color.Black,
+		penWidth:  1,
+	}
+}
+
+func (r *Raster) StartDoc(docName string) error {
+	r.Pages = nil
+	return nil
+}
+
+// StartPage starts a new white page.
+func (r *Raster) StartPage() error {
+	r.page = bgr.NewBGRImage(image.Rect(0, 0, r.width, r.height))
+	for i := range r.page.Pix {
+		r.page.Pix[i] = 0xFF
+	}
+	r.pos = image.Point{}
+	return nil
+}
+
+func (r *Raster) EndPage() error {
+	if r.page == nil {
+		return errNoPage
+	}
+	r.Pages = append(r.Pages, r.page)
+	r.page = nil
+	return nil
+}
+
+func (r *Raster) EndDoc() error {
+	return nil
+}
+
+// Page returns the page being drawn, or nil outside of StartPage/EndPage.
+func (r *Raster) Page() *bgr.BGRImage {
+	return r.page
+}
+
+func (r *Raster) fontFace() (font.Face, error) {
+	if r.face != nil {
+		return r.face, nil
+	}
+	f := fonts.Lookup(r.font.Name, r.font.Bold, r.font.Italic)
+	face, err := f.Face(float64(r.font.Height))
+	if err != nil {
+		return nil, err
+	}
+	r.face = face
+	return face, nil
+}
+
+func (r *Raster) setFont(f device.Font) {
+	if f != r.font {
+		r.font = f
+		r.face = nil
+	}
+}
+
+func (r *Raster) TextOut(x, y uint32, text string) error {
+	if r.page == nil {
+		return errNoPage
+	}
+	face, err := r.fontFace()
+	if err != nil {
+		return err
+	}
+	d := font.Drawer{
+		Dst:  r.page,
+		Src:  image.NewUniform(r.textColor),
+		Face: face,
+		Dot:  fixed.P(int(x), int(y)+face.Metrics().Ascent.Ceil()),
+	}
+	d.DrawString(text)
+	return nil
+}
+
+func (r *Raster) GetTextExtentPoint32(text string) (uint32, uint32, error) {
+	face, err := r.fontFace()
+	if err != nil {
+		return 0, 0, err
+	}
+	m := face.Metrics()
+	width := font.MeasureString(face, text)
+	return uint32(width.Ceil()), uint32((m.Ascent + m.Descent).Ceil()), nil
+}
+
+func (r *Raster) MoveTo(x, y uint32) error {
+	r.pos = image.Point{X: int(x), Y: int(y)}
+	return nil
+}
+
+// LineTo draws the line with the pen set by SetPen.
+func (r *Raster) LineTo(x, y uint32) error {
+	if r.page == nil {
+		return errNoPage
+	}
+	to := image.Point{X: int(x), Y: int(y)}
+	from, end, ok := clipLine(r.pos, to, r.page.Bounds().Inset(-r.penWidth))
+	if !ok {
+		r.pos = to
+		return nil
+	}
+	if r.penWidth > 1 {
+		thickLine(r.page, from, end, r.penWidth, r.penColor)
+	} else {
+		DrawLine(r.page, from, end, r.penColor)
+	}
+	r.pos = to
+	return nil
+}
+
+func (r *Raster) SetPen(c color.Color, width uint32) error {
+	r.penColor = c
+	// A pen twice as wide as the page already covers it.
+	r.penWidth = max(min(int(width), 2*max(r.width, r.height)), 1)
+	return nil
+}
+
+// FillPolygon fills the polygon with anti-aliased edges.
+func (r *Raster) FillPolygon(points []image.Point, c color.Color) error {
+	if r.page == nil {
+		return errNoPage
+	}
+	if len(points) < 3 {
+		return nil
+	}
+	poly := clipPolygon(points, r.page.Rect.Inset(-1))
+	if len(poly) < 3 {
+		return nil
+	}
+	z := vector.NewRasterizer(r.width, r.height)
+	z.MoveTo(float32(poly[0][0]), float32(poly[0][1]))
+	for _, p := range poly[1:] {
+		z.LineTo(float32(p[0]), float32(p[1]))
+	}
+	z.ClosePath()
+	z.Draw(r.page, r.page.Rect, image.NewUniform(c), image.Point{})
+	return nil
+}
+
+// thickLine draws the line from p0 to p1 with a square pen of width w, as
+// if stamping the pen on every pixel of the one pixel wide line, but one
+// span per row.
+func thickLine(dst stddraw.Image, p0, p1 image.Point, w int, c color.Color) {
+	// The extent of the line on each of its rows.
+	top := min(p0.Y, p1.Y)
+	lo, hi := make([]int, abs(p1.Y-p0.Y)+1), make([]int, abs(p1.Y-p0.Y)+1)
+	for i := range lo {
+		lo[i], hi[i] = math.MaxInt, math.MinInt
+	}
+	DrawLine(stampImage(func(p image.Point) {
+		lo[p.Y-top] = min(lo[p.Y-top], p.X)
+		hi[p.Y-top] = max(hi[p.Y-top], p.X)
+	}), p0, p1, c)
+
+	// Row y is covered by the stamps of the rows y+off-w+1 to y+off; the
+	// line being monotonic, their extent is that of the first and last.
+	off := w / 2
+	b := dst.Bounds()
+	src := image.NewUniform(c)
+	for y := max(top-off, b.Min.Y); y < min(top+len(lo)-off+w-1, b.Max.Y); y++ {
+		first, last := max(y+off-w+1-top, 0), min(y+off-top, len(lo)-1)
+		x0, x1 := min(lo[first], lo[last]), max(hi[first], hi[last])
+		stddraw.Draw(dst, image.Rect(x0-off, y, x1-off+w, y+1), src, image.Point{}, stddraw.Src)
+	}
+}
+
+// stampImage is a draw.Image calling its function for every pixel set.
+type stampImage func(p image.Point)
+
+func (s stampImage) ColorModel() color.Model     { return color.RGBAModel }
+func (s stampImage) Bounds() image.Rectangle     { return image.Rect(-1<<30, -1<<30, 1<<30, 1<<30) }
+func (s stampImage) At(x, y int) color.Color     { return color.Transparent }
+func (s stampImage) Set(x, y int, c color.Color) { s(image.Point{X: x, Y: y}) }
+
+func (r *Raster) DrawImage(x, y, width, height uint32, img image.Image) error {
+	if r.page == nil {
+		return errNoPage
+	}
+	dst := image.Rect(int(x), int(y), int(x+width), int(y+height))
+	if dst.Dx() == img.Bounds().Dx() && dst.Dy() == img.Bounds().Dy() {
+		stddraw.Draw(r.page, dst, img, img.Bounds().Min, stddraw.Src)
+		return nil
+	}
+	draw.ApproxBiLinear.Scale(r.page, dst, img, img.Bounds(), draw.Src, nil)
+	return nil
+}
+
+func (r *Raster) SetFont(fontName string) error {
+	f := r.font
+	f.Name = fontName
+	r.setFont(f)
+	return nil
+}
+
+func (r *Raster) SetTextSize(size int32) (int32, error) {
+	f := r.font
+	old := f.Height
+	f.Height = size
+	r.setFont(f)
+	return old, nil
+}
+
+func (r *Raster) SetBoldFont(bold bool) error {
+	f := r.font
+	f.Bold = bold
+	r.setFont(f)
+	return nil
+}
+
+func (r *Raster) SetItalicFont(italic bool) error {
+	f := r.font
+	f.Italic = italic
+	r.setFont(f)
+	return nil
+}
+
+func (r *Raster) SetTextColor(c color.Color) (color.Color, error) {
+	old := r.textColor
+	r.textColor = c
+	return old, nil
+}
+
+func (r *Raster) GetDeviceCaps(index device.Cap) (uint32, error) {
+	return r.size.Caps(r.dpi, index)
+}
+
+// DrawLine draws a one pixel wide line from p0 to p1 included, using
+// Bresenham's algorithm.
+func DrawLine(dst stddraw.Image, p0, p1 image.Point, c color.Color) {
+	dx, dy := abs(p1.X-p0.X), -abs(p1.Y-p0.Y)
+	sx, sy := 1, 1
+	if p0.X > p1.X {
+		sx = -1
+	}
+	if p0.Y > p1.Y {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		dst.Set(p0.X, p0.Y, c)
+		if p0 == p1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			p0.X += sx
+		}
+		if e2 <= dx {
+			e += dx
+			p0.Y += sy
+		}
+	}
+}
+
+// clipLine clips the line from p0 to p1 to the rectangle b, so that lines
+// reaching far off the page are not walked pixel by pixel. It returns
+// false when the line does not cross b.
+func clipLine(p0, p1 image.Point, b image.Rectangle) (image.Point, image.Point, bool) {
+	if p0.In(b) && p1.In(b) {
+		return p0, p1, true
+	}
+	// Liang-Barsky, on the pixel centers of b.
+	x0, y0 := float64(p0.X), float64(p0.Y)
+	dx, dy := float64(p1.X-p0.X), float64(p1.Y-p0.Y)
+	t0, t1 := 0.0, 1.0
+	for _, e := range [4][2]float64{
+		{-dx, x0 - float64(b.Min.X)},
+		{dx, float64(b.Max.X-1) - x0},
+		{-dy, y0 - float64(b.Min.Y)},
+		{dy, float64(b.Max.Y-1) - y0},
+	} {
+		p, q := e[0], e[1]
+		switch {
+		case p == 0:
+			if q < 0 {
+				return p0, p1, false
+			}
+		case p < 0:
+			t0 = math.Max(t0, q/p)
+		default:
+			t1 = math.Min(t1, q/p)
+		}
+	}
+	if t0 > t1 {
+		return p0, p1, false
+	}
+	at := func(t float64) image.Point {
+		return image.Pt(int(math.Round(x0+t*dx)), int(math.Round(y0+t*dy)))
+	}
+	return at(t0), at(t1), true
+}
+
+// clipPolygon clips the polygon to the rectangle b with the
+// Sutherland-Hodgman algorithm, as vertices far off the page overflow the
+// fixed point arithmetic of the rasterizer.
+func clipPolygon(points []image.Point, b image.Rectangle) [][2]float64 {
+	poly := make([][2]float64, len(points))
+	for i, p := range points {
+		poly[i] = [2]float64{float64(p.X), float64(p.Y)}
+	}
+	// Each edge of b keeps the side where (p[axis]-v)*sign >= 0.
+	for _, e := range [4]struct {
+		axis    int
+		v, sign float64
+	}{
+		{0, float64(b.Min.X), 1},
+		{0, float64(b.Max.X), -1},
+		{1, float64(b.Min.Y), 1},
+		{1, float64(b.Max.Y), -1},
+	} {
+		in := func(p [2]float64) bool { return (p[e.axis]-e.v)*e.sign >= 0 }
+		var out [][2]float64
+		for i, cur := range poly {
+			prev := poly[(i+len(poly)-1)%len(poly)]
+			if in(cur) != in(prev) {
+				t := (e.v - prev[e.axis]) / (cur[e.axis] - prev[e.axis])
+				out = append(out, [2]float64{prev[0] + t*(cur[0]-prev[0]), prev[1] + t*(cur[1]-prev[1])})
+			}
+			if in(cur) {
+				out = append(out, cur)
+			}
+		}
+		if poly = out; len(poly) == 0 {
+			break
+		}
+	}
+	return poly
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
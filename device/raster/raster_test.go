@@ -0,0 +1,173 @@
+package raster
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/sipkg/golang-win32-printer/device"
+	color2 "github.com/sipkg/golang-win32-printer/image/color"
+)
+
+func newPage(t *testing.T) *Raster {
+	t.Helper()
+	r := New(device.PageSize{Width: 25.4, Height: 25.4}, 100)
+	if err := r.StartDoc("test"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.StartPage(); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func dark(c color.Color) bool {
+	b := c.(color2.BGR)
+	return b.R < 0x80 && b.G < 0x80 && b.B < 0x80
+}
+
+func TestDeviceCaps(t *testing.T) {
+	r := New(device.A4, 300)
+	tests := []struct {
+		index device.Cap
+		want  uint32
+	}{
+		{device.HorzRes, 2480},
+		{device.VertRes, 3508},
+		{device.HorzSize, 210},
+		{device.VertSize, 297},
+		{device.LogPixelsY, 300},
+	}
+	for _, tt := range tests {
+		got, err := r.GetDeviceCaps(tt.index)
+		if err != nil || got != tt.want {
+			t.Errorf("GetDeviceCaps(%d) = %d, %v, want %d", tt.index, got, err, tt.want)
+		}
+	}
+}
+
+func TestLine(t *testing.T) {
+	r := newPage(t)
+	r.MoveTo(10, 50)
+	r.LineTo(90, 50)
+	for x := 10; x <= 90; x++ {
+		if !dark(r.Page().At(x, 50)) {
+			t.Fatalf("pixel %d,50 not drawn", x)
+		}
+	}
+	if dark(r.Page().At(50, 51)) || dark(r.Page().At(91, 50)) {
+		t.Error("line drawn outside of its path")
+	}
+}
+
+func TestLineOffPage(t *testing.T) {
+	r := newPage(t)
+	// A line reaching billions of pixels away is clipped to the page.
+	r.MoveTo(0, 50)
+	r.LineTo(1<<32-1, 50)
+	for x := 0; x < 100; x++ {
+		if !dark(r.Page().At(x, 50)) {
+			t.Fatalf("pixel %d,50 not drawn", x)
+		}
+	}
+	r.MoveTo(1<<30, 0)
+	r.LineTo(1<<30, 1<<30)
+	if r.pos != image.Pt(1<<30, 1<<30) {
+		t.Errorf("position after an off page line = %v", r.pos)
+	}
+	// A pen wider than the page covers it without stamping it on every
+	// pixel.
+	r.SetPen(color.Black, 1<<31)
+	r.MoveTo(50, 0)
+	r.LineTo(50, 1<<30)
+	if !dark(r.Page().At(0, 0)) || !dark(r.Page().At(99, 99)) {
+		t.Error("page not covered by a line of a very wide pen")
+	}
+}
+
+func TestFillPolygonOffPage(t *testing.T) {
+	r := newPage(t)
+	// Vertices far off a small page used to overflow the rasterizer.
+	if err := r.FillPolygon([]image.Point{{1 << 30, 10}, {1<<30 + 1, 20}, {10, 30}}, color.Black); err != nil {
+		t.Fatal(err)
+	}
+	r.FillPolygon([]image.Point{{0, 10}, {1 << 30, 10}, {0, 30}}, color.Black)
+	if !dark(r.Page().At(90, 20)) || dark(r.Page().At(90, 40)) {
+		t.Error("triangle reaching off the page not filled")
+	}
+	r.FillPolygon([]image.Point{{-1 << 30, -1 << 30}, {1 << 30, -1 << 30}, {1 << 30, 1 << 30}, {-1 << 30, 1 << 30}}, color.Black)
+	if !dark(r.Page().At(0, 0)) || !dark(r.Page().At(99, 99)) {
+		t.Error("page not covered by a polygon around it")
+	}
+	r.FillPolygon([]image.Point{{1 << 30, 0}, {1<<30 + 10, 0}, {1 << 30, 10}}, color.Black)
+}
+
+func TestTextOut(t *testing.T) {
+	r := newPage(t)
+	r.SetTextSize(20)
+	w, h, err := r.GetTextExtentPoint32("Hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w == 0 || h < 20 {
+		t.Fatalf("GetTextExtentPoint32() = %d, %d", w, h)
+	}
+	if err := r.TextOut(5, 5, "Hello"); err != nil {
+		t.Fatal(err)
+	}
+	inked := 0
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			if dark(r.Page().At(x, y)) {
+				if x < 5 || y < 5 || x > 5+int(w) || y > 5+int(h) {
+					t.Fatalf("ink at %d,%d outside of text extent", x, y)
+				}
+				inked++
+			}
+		}
+	}
+	if inked == 0 {
+		t.Error("TextOut() drew nothing")
+	}
+
+	r.SetBoldFont(true)
+	bw, _, _ := r.GetTextExtentPoint32("Hello")
+	if bw <= w {
+		t.Errorf("bold width %d not larger than regular width %d", bw, w)
+	}
+}
+
+func TestDrawImage(t *testing.T) {
+	r := newPage(t)
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	for i := range img.Pix {
+		img.Pix[i] = 0x80
+	}
+	if err := r.DrawImage(10, 10, 20, 20, img); err != nil {
+		t.Fatal(err)
+	}
+	want := color2.BGR{B: 0x80, G: 0x80, R: 0x80}
+	if got := r.Page().At(20, 20); got != want {
+		t.Errorf("At(20, 20) = %v, want %v", got, want)
+	}
+	if got := r.Page().At(31, 31); got != (color2.BGR{B: 0xFF, G: 0xFF, R: 0xFF}) {
+		t.Errorf("At(31, 31) = %v, want white", got)
+	}
+	if _, _, _, a := r.Page().At(31, 31).RGBA(); a != 0xFFFF {
+		t.Errorf("alpha = %#x, want opaque pages", a)
+	}
+}
+
+func TestPages(t *testing.T) {
+	r := newPage(t)
+	r.EndPage()
+	r.StartPage()
+	r.EndPage()
+	r.EndDoc()
+	if len(r.Pages) != 2 {
+		t.Errorf("len(Pages) = %d, want 2", len(r.Pages))
+	}
+	if err := r.TextOut(0, 0, "x"); err == nil {
+		t.Error("TextOut() outside of a page should fail")
+	}
+}
@@ -0,0 +1,318 @@
+// Package record captures the drawing operations of a print job into a
+// serializable display list, and replays a display list onto any
+// device.Device, including the GDI printer.
+//
+// A job can thus be rendered once on a Linux server, shipped as JSON to a
+// Windows till and replayed there, and a display list is a readable snapshot
+// of exactly what a document draws.
+package record
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+
+	"github.com/sipkg/golang-win32-printer/device"
+	"github.com/sipkg/golang-win32-printer/device/fonts"
+)
+
+// Kinds of operations, named after the device.Device methods they record.
+const (
+	StartDoc      = "StartDoc"
+	StartPage     = "StartPage"
+	EndPage       = "EndPage"
+	EndDoc        = "EndDoc"
+	TextOut       = "TextOut"
+	MoveTo        = "MoveTo"
+	LineTo        = "LineTo"
+	DrawImage     = "DrawImage"
+	SetFont       = "SetFont"
+	SetTextSize   = "SetTextSize"
+	SetBoldFont   = "SetBoldFont"
+	SetItalicFont = "SetItalicFont"
+	SetTextColor  = "SetTextColor"
+)
+
+// Op is one recorded operation. Only the fields used by its Kind are set.
+type Op struct {
+	Kind   string `json:"op"`
+	X      uint32 `json:"x,omitempty"`
+	Y      uint32 `json:"y,omitempty"`
+	Width  uint32 `json:"w,omitempty"`
+	Height uint32 `json:"h,omitempty"`
+	// Text is the text of TextOut, the document name of StartDoc and the
+	// face name of SetFont.
+	Text  string `json:"text,omitempty"`
+	Size  int32  `json:"size,omitempty"`
+	Flag  bool   `json:"flag,omitempty"`
+	Color Color  `json:"color,omitempty"`
+	// Font is the font TextOut drew with, so that every text operation
+	// can be read and replayed on its own.
+	Font *device.Font `json:"font,omitempty"`
+	// Image is the PNG encoded image of DrawImage.
+	Image []byte `json:"image,omitempty"`
+}
+
+// List is a display list: the page geometry the job was laid out for and
+// its operations in order.
+type List struct {
+	Size device.PageSize `json:"size"`
+	DPI  int             `json:"dpi"`
+	Ops  []Op            `json:"ops"`
+}
+
+// Encode writes l as JSON to w.
+func (l *List) Encode(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(l)
+}
+
+// Decode reads a display list written by List.Encode.
+func Decode(r io.Reader) (*List, error) {
+	l := &List{}
+	if err := json.NewDecoder(r).Decode(l); err != nil {
+		return nil, fmt.Errorf("record: decode display list: %w", err)
+	}
+	return l, nil
+}
+
+// Color is a color written as #rrggbb.
+type Color string
+
+// ColorOf returns the Color of c.
+func ColorOf(c color.Color) Color {
+	r, g, b, _ := c.RGBA()
+	return Color(fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8))
+}
+
+// RGBA returns the opaque color c stands for.
+func (c Color) RGBA() (color.RGBA, error) {
+	var rgba color.RGBA
+	if _, err := fmt.Sscanf(string(c), "#%02x%02x%02x", &rgba.R, &rgba.G, &rgba.B); err != nil {
+		return rgba, fmt.Errorf("record: bad color %q", c)
+	}
+	rgba.A = 0xFF
+	return rgba, nil
+}
+
+// Recorder is a device.Device appending every operation to a List. Text is
+// measured with package fonts, like the other portable backends.
+type Recorder struct {
+	list      List
+	font      device.Font
+	textColor color.Color
+}
+
+var _ device.Device = (*Recorder)(nil)
+
+// New returns a Recorder laying pages of the given size out at dpi dots per
+// inch.
+func New(size device.PageSize, dpi int) *Recorder {
+	return &Recorder{
+		list:      List{Size: size, DPI: dpi},
+		font:      device.DefaultFont(dpi),
+		textColor: color.Black,
+	}
+}
+
+// List returns the operations recorded since the last StartDoc.
+func (r *Recorder) List() *List {
+	return &r.list
+}
+
+func (r *Recorder) add(op Op) error {
+	r.list.Ops = append(r.list.Ops, op)
+	return nil
+}
+
+// StartDoc discards the operations recorded so far and records a new
+// document.
+func (r *Recorder) StartDoc(docName string) error {
+	r.list.Ops = nil
+	return r.add(Op{Kind: StartDoc, Text: docName})
+}
+
+func (r *Recorder) StartPage() error {
+	return r.add(Op{Kind: StartPage})
+}
+
+func (r *Recorder) EndPage() error {
+	return r.add(Op{Kind: EndPage})
+}
+
+func (r *Recorder) EndDoc() error {
+	return r.add(Op{Kind: EndDoc})
+}
+
+func (r *Recorder) TextOut(x, y uint32, text string) error {
+	f := r.font
+	return r.add(Op{Kind: TextOut, X: x, Y: y, Text: text, Font: &f, Color: ColorOf(r.textColor)})
+}
+
+func (r *Recorder) GetTextExtentPoint32(text string) (uint32, uint32, error) {
+	f := fonts.Lookup(r.font.Name, r.font.Bold, r.font.Italic)
+	w, h, err := f.Metrics(text, float64(r.font.Height))
+	if err != nil {
+		return 0, 0, err
+	}
+	return uint32(w + 0.5), uint32(h + 0.5), nil
+}
+
+func (r *Recorder) MoveTo(x, y uint32) error {
+	return r.add(Op{Kind: MoveTo, X: x, Y: y})
+}
+
+func (r *Recorder) LineTo(x, y uint32) error {
+	return r.add(Op{Kind: LineTo, X: x, Y: y})
+}
+
+func (r *Recorder) DrawImage(x, y, width, height uint32, img image.Image) error {
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		return err
+	}
+	return r.add(Op{Kind: DrawImage, X: x, Y: y, Width: width, Height: height, Image: b.Bytes()})
+}
+
+func (r *Recorder) SetFont(fontName string) error {
+	r.font.Name = fontName
+	return r.add(Op{Kind: SetFont, Text: fontName})
+}
+
+func (r *Recorder) SetTextSize(size int32) (int32, error) {
+	old := r.font.Height
+	r.font.Height = size
+	return old, r.add(Op{Kind: SetTextSize, Size: size})
+}
+
+func (r *Recorder) SetBoldFont(bold bool) error {
+	r.font.Bold = bold
+	return r.add(Op{Kind: SetBoldFont, Flag: bold})
+}
+
+func (r *Recorder) SetItalicFont(italic bool) error {
+	r.font.Italic = italic
+	return r.add(Op{Kind: SetItalicFont, Flag: italic})
+}
+
+func (r *Recorder) SetTextColor(c color.Color) (color.Color, error) {
+	old := r.textColor
+	r.textColor = c
+	return old, r.add(Op{Kind: SetTextColor, Color: ColorOf(c)})
+}
+
+func (r *Recorder) GetDeviceCaps(index device.Cap) (uint32, error) {
+	return r.list.Size.Caps(r.list.DPI, index)
+}
+
+// Replay plays the operations of l onto d. Before each TextOut the font and
+// color it was recorded with are selected on d if needed, so a list stays
+// replayable when operations are filtered out of it.
+func Replay(l *List, d device.Device) error {
+	var font *device.Font
+	var textColor Color
+	for i, op := range l.Ops {
+		var err error
+		switch op.Kind {
+		case StartDoc:
+			err = d.StartDoc(op.Text)
+		case StartPage:
+			err = d.StartPage()
+		case EndPage:
+			err = d.EndPage()
+		case EndDoc:
+			err = d.EndDoc()
+		case TextOut:
+			if op.Font != nil && (font == nil || *font != *op.Font) {
+				err = selectFont(d, font, *op.Font)
+				f := *op.Font
+				font = &f
+			}
+			if err == nil && op.Color != "" && op.Color != textColor {
+				err = setTextColor(d, op.Color)
+				textColor = op.Color
+			}
+			if err == nil {
+				err = d.TextOut(op.X, op.Y, op.Text)
+			}
+		case MoveTo:
+			err = d.MoveTo(op.X, op.Y)
+		case LineTo:
+			err = d.LineTo(op.X, op.Y)
+		case DrawImage:
+			var img image.Image
+			img, err = png.Decode(bytes.NewReader(op.Image))
+			if err == nil {
+				err = d.DrawImage(op.X, op.Y, op.Width, op.Height, img)
+			}
+		case SetFont:
+			err = d.SetFont(op.Text)
+			if font != nil {
+				font.Name = op.Text
+			}
+		case SetTextSize:
+			_, err = d.SetTextSize(op.Size)
+			if font != nil {
+				font.Height = op.Size
+			}
+		case SetBoldFont:
+			err = d.SetBoldFont(op.Flag)
+			if font != nil {
+				font.Bold = op.Flag
+			}
+		case SetItalicFont:
+			err = d.SetItalicFont(op.Flag)
+			if font != nil {
+				font.Italic = op.Flag
+			}
+		case SetTextColor:
+			err = setTextColor(d, op.Color)
+			textColor = op.Color
+		default:
+			err = fmt.Errorf("unknown operation %q", op.Kind)
+		}
+		if err != nil {
+			return fmt.Errorf("record: replay op %d (%s): %w", i, op.Kind, err)
+		}
+	}
+	return nil
+}
+
+// selectFont changes the font of d from cur, nil when unknown, to f.
+func selectFont(d device.Canvas, cur *device.Font, f device.Font) error {
+	if cur == nil || cur.Name != f.Name {
+		if err := d.SetFont(f.Name); err != nil {
+			return err
+		}
+	}
+	if cur == nil || cur.Height != f.Height {
+		if _, err := d.SetTextSize(f.Height); err != nil {
+			return err
+		}
+	}
+	if cur == nil || cur.Bold != f.Bold {
+		if err := d.SetBoldFont(f.Bold); err != nil {
+			return err
+		}
+	}
+	if cur == nil || cur.Italic != f.Italic {
+		if err := d.SetItalicFont(f.Italic); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setTextColor(d device.Canvas, c Color) error {
+	rgba, err := c.RGBA()
+	if err != nil {
+		return err
+	}
+	_, err = d.SetTextColor(rgba)
+	return err
+}
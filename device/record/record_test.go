@@ -0,0 +1,100 @@
+package record
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+
+	"github.com/sipkg/golang-win32-printer/device"
+	"github.com/sipkg/golang-win32-printer/device/raster"
+)
+
+var size = device.PageSize{Width: 50.8, Height: 25.4}
+
+type page struct{}
+
+func (page) Print(c device.Canvas) {
+	c.SetFont("Courier New")
+	c.SetTextSize(20)
+	c.TextOut(5, 5, "Hello")
+	c.SetBoldFont(true)
+	c.SetTextColor(color.RGBA{R: 200, A: 255})
+	w, _, _ := c.GetTextExtentPoint32("World")
+	c.TextOut(195-w, 5, "World")
+	c.MoveTo(0, 50)
+	c.LineTo(199, 50)
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(1, 1, color.RGBA{B: 255, A: 255})
+	c.DrawImage(10, 60, 20, 20, img)
+}
+
+func TestRoundTrip(t *testing.T) {
+	r := New(size, 100)
+	if err := device.Print(r, "doc", page{}); err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := r.List().Encode(&b); err != nil {
+		t.Fatal(err)
+	}
+	l, err := Decode(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(l, r.List()) {
+		t.Errorf("Decode(Encode(l)) = %+v, want %+v", l, r.List())
+	}
+	want := []string{StartDoc, StartPage, SetFont, SetTextSize, TextOut, SetBoldFont, SetTextColor,
+		TextOut, MoveTo, LineTo, DrawImage, EndPage, EndDoc}
+	var got []string
+	for _, op := range l.Ops {
+		got = append(got, op.Kind)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ops = %v, want %v", got, want)
+	}
+	if f := l.Ops[7].Font; f == nil || *f != (device.Font{Name: "Courier New", Height: 20, Bold: true}) {
+		t.Errorf("font of second TextOut = %+v", f)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	direct := raster.New(size, 100)
+	device.Print(direct, "doc", page{})
+
+	r := New(size, 100)
+	device.Print(r, "doc", page{})
+	replayed := raster.New(size, 100)
+	if err := Replay(r.List(), replayed); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(replayed.Pages[0].Pix, direct.Pages[0].Pix) {
+		t.Error("replayed page differs from the page drawn directly")
+	}
+
+	// Text operations carry their font and color.
+	filtered := &List{Size: size, DPI: 100}
+	for _, op := range r.List().Ops {
+		switch op.Kind {
+		case SetFont, SetTextSize, SetBoldFont, SetItalicFont, SetTextColor:
+		default:
+			filtered.Ops = append(filtered.Ops, op)
+		}
+	}
+	replayed = raster.New(size, 100)
+	if err := Replay(filtered, replayed); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(replayed.Pages[0].Pix, direct.Pages[0].Pix) {
+		t.Error("page replayed without font operations differs from the page drawn directly")
+	}
+}
+
+func TestReplayUnknownOp(t *testing.T) {
+	l := &List{Ops: []Op{{Kind: "Explode"}}}
+	if err := Replay(l, New(size, 100)); err == nil {
+		t.Error("Replay() of an unknown operation should fail")
+	}
+}
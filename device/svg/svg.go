@@ -0,0 +1,202 @@
+// Package svg implements device.Device by emitting one SVG document per page.
+//
+// The SVG view box is the page in device pixels, so the drawing calls of
+// ticket and the examples land exactly where they would on paper. Text is
+// kept as real <text> elements, stretched to the width measured with package
+// fonts so that columns line up whatever font the browser substitutes, and
+// images are embedded as PNG data URIs.
+package svg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strconv"
+
+	"github.com/sipkg/golang-win32-printer/device"
+	"github.com/sipkg/golang-win32-printer/device/fonts"
+)
+
+var errNoPage = errors.New("svg: must call StartPage before")
+
+// SVG renders pages as SVG documents.
+type SVG struct {
+	// Pages holds one SVG document per page finished by EndPage since the
+	// last StartDoc.
+	Pages [][]byte
+
+	size   device.PageSize
+	dpi    int
+	width  int
+	height int
+
+	page      *bytes.Buffer
+	pos       image.Point
+	font      device.Font
+	textColor color.Color
+}
+
+var _ device.Device = (*SVG)(nil)
+
+// New returns an SVG device with pages of the given size addressed in pixels
+// at dpi dots per inch.
+func New(size device.PageSize, dpi int) *SVG {
+	width, height := size.Pixels(dpi)
+	return &SVG{
+		size:      size,
+		dpi:       dpi,
+		width:     width,
+		height:    height,
+		font:      device.DefaultFont(dpi),
+		textColor: color.Black,
+	}
+}
+
+func (s *SVG) StartDoc(docName string) error {
+	s.Pages = nil
+	return nil
+}
+
+func (s *SVG) StartPage() error {
+	s.page = &bytes.Buffer{}
+	s.pos = image.Point{}
+	fmt.Fprintf(s.page, `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" width="%smm" height="%smm" viewBox="0 0 %d %d" xml:space="preserve">`+"\n",
+		num(s.size.Width), num(s.size.Height), s.width, s.height)
+	fmt.Fprintf(s.page, `<rect width="%d" height="%d" fill="white"/>`+"\n", s.width, s.height)
+	return nil
+}
+
+func (s *SVG) EndPage() error {
+	if s.page == nil {
+		return errNoPage
+	}
+	s.page.WriteString("</svg>\n")
+	s.Pages = append(s.Pages, s.page.Bytes())
+	s.page = nil
+	return nil
+}
+
+func (s *SVG) EndDoc() error {
+	return nil
+}
+
+func (s *SVG) TextOut(x, y uint32, text string) error {
+	if s.page == nil {
+		return errNoPage
+	}
+	f := fonts.Lookup(s.font.Name, s.font.Bold, s.font.Italic)
+	width, _, err := f.Metrics(text, float64(s.font.Height))
+	if err != nil {
+		return err
+	}
+	ascent, err := f.Ascent(float64(s.font.Height))
+	if err != nil {
+		return err
+	}
+	family := "sans-serif"
+	if f.Monospace {
+		family = "monospace"
+	}
+	fmt.Fprintf(s.page, `<text x="%d" y="%s" font-family="'%s', %s" font-size="%d"`,
+		x, num(float64(y)+ascent), escape(s.font.Name), family, s.font.Height)
+	if s.font.Bold {
+		s.page.WriteString(` font-weight="bold"`)
+	}
+	if s.font.Italic {
+		s.page.WriteString(` font-style="italic"`)
+	}
+	if width > 0 {
+		fmt.Fprintf(s.page, ` textLength="%s" lengthAdjust="spacingAndGlyphs"`, num(width))
+	}
+	fmt.Fprintf(s.page, ` fill="%s">%s</text>`+"\n", hex(s.textColor), escape(text))
+	return nil
+}
+
+func (s *SVG) GetTextExtentPoint32(text string) (uint32, uint32, error) {
+	f := fonts.Lookup(s.font.Name, s.font.Bold, s.font.Italic)
+	w, h, err := f.Metrics(text, float64(s.font.Height))
+	if err != nil {
+		return 0, 0, err
+	}
+	return uint32(w + 0.5), uint32(h + 0.5), nil
+}
+
+func (s *SVG) MoveTo(x, y uint32) error {
+	s.pos = image.Point{X: int(x), Y: int(y)}
+	return nil
+}
+
+func (s *SVG) LineTo(x, y uint32) error {
+	if s.page == nil {
+		return errNoPage
+	}
+	fmt.Fprintf(s.page, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="black" stroke-width="1"/>`+"\n",
+		s.pos.X, s.pos.Y, x, y)
+	s.pos = image.Point{X: int(x), Y: int(y)}
+	return nil
+}
+
+func (s *SVG) DrawImage(x, y, width, height uint32, img image.Image) error {
+	if s.page == nil {
+		return errNoPage
+	}
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		return err
+	}
+	fmt.Fprintf(s.page, `<image x="%d" y="%d" width="%d" height="%d" preserveAspectRatio="none" xlink:href="data:image/png;base64,%s"/>`+"\n",
+		x, y, width, height, base64.StdEncoding.EncodeToString(b.Bytes()))
+	return nil
+}
+
+func (s *SVG) SetFont(fontName string) error {
+	s.font.Name = fontName
+	return nil
+}
+
+func (s *SVG) SetTextSize(size int32) (int32, error) {
+	old := s.font.Height
+	s.font.Height = size
+	return old, nil
+}
+
+func (s *SVG) SetBoldFont(bold bool) error {
+	s.font.Bold = bold
+	return nil
+}
+
+func (s *SVG) SetItalicFont(italic bool) error {
+	s.font.Italic = italic
+	return nil
+}
+
+func (s *SVG) SetTextColor(c color.Color) (color.Color, error) {
+	old := s.textColor
+	s.textColor = c
+	return old, nil
+}
+
+func (s *SVG) GetDeviceCaps(index device.Cap) (uint32, error) {
+	return s.size.Caps(s.dpi, index)
+}
+
+func escape(text string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
+
+func hex(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+}
+
+func num(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
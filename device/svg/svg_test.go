@@ -0,0 +1,75 @@
+package svg
+
+import (
+	"encoding/xml"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/sipkg/golang-win32-printer/device"
+)
+
+type svgDoc struct {
+	ViewBox string `xml:"viewBox,attr"`
+	Texts   []struct {
+		X          string `xml:"x,attr"`
+		FontFamily string `xml:"font-family,attr"`
+		FontWeight string `xml:"font-weight,attr"`
+		Fill       string `xml:"fill,attr"`
+		Text       string `xml:",chardata"`
+	} `xml:"text"`
+	Lines []struct {
+		X1 string `xml:"x1,attr"`
+		Y2 string `xml:"y2,attr"`
+	} `xml:"line"`
+	Images []struct {
+		Href string `xml:"href,attr"`
+	} `xml:"image"`
+}
+
+func TestPage(t *testing.T) {
+	s := New(device.A4, 300)
+	s.StartDoc("test")
+	s.StartPage()
+	s.SetFont("Courier New")
+	s.SetBoldFont(true)
+	s.SetTextColor(color.RGBA{R: 200, G: 20, B: 80, A: 255})
+	if err := s.TextOut(350, 100, "2 x <Article>   à 1.00 €"); err != nil {
+		t.Fatal(err)
+	}
+	s.MoveTo(0, 300)
+	s.LineTo(2480, 300)
+	if err := s.DrawImage(0, 0, 10, 10, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	s.EndPage()
+	s.StartPage()
+	s.EndPage()
+	s.EndDoc()
+
+	if len(s.Pages) != 2 {
+		t.Fatalf("len(Pages) = %d, want 2", len(s.Pages))
+	}
+	var doc svgDoc
+	if err := xml.Unmarshal(s.Pages[0], &doc); err != nil {
+		t.Fatalf("page is not valid XML: %v\n%s", err, s.Pages[0])
+	}
+	if doc.ViewBox != "0 0 2480 3508" {
+		t.Errorf("viewBox = %q", doc.ViewBox)
+	}
+	if len(doc.Texts) != 1 {
+		t.Fatalf("got %d text elements, want 1", len(doc.Texts))
+	}
+	text := doc.Texts[0]
+	if text.Text != "2 x <Article>   à 1.00 €" || text.X != "350" || text.FontWeight != "bold" ||
+		text.Fill != "#c81450" || text.FontFamily != "'Courier New', monospace" {
+		t.Errorf("text = %+v", text)
+	}
+	if len(doc.Lines) != 1 || doc.Lines[0].X1 != "0" || doc.Lines[0].Y2 != "300" {
+		t.Errorf("lines = %+v", doc.Lines)
+	}
+	if len(doc.Images) != 1 || !strings.HasPrefix(doc.Images[0].Href, "data:image/png;base64,") {
+		t.Errorf("images = %+v", doc.Images)
+	}
+}
@@ -0,0 +1,252 @@
+// Package epl composes labels in EPL2, the page mode language of Eltron and
+// older Zebra desktop printers.
+//
+// The methods of Writer drawing fields have the names and arguments of those
+// of zpl.Writer, so that the same label can be written in both languages.
+// Positions and sizes are in dots. Errors are sticky and can be checked once
+// with Err.
+package epl
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"strings"
+
+	"github.com/sipkg/golang-win32-printer/image/mono"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Rotation is the clockwise rotation of a field.
+type Rotation byte
+
+const (
+	Rotate0   Rotation = 0
+	Rotate90  Rotation = 1
+	Rotate180 Rotation = 2
+	Rotate270 Rotation = 3
+)
+
+// Font selects a resident font of the A command.
+type Font struct {
+	// Number is the font, from 1 (the smallest) to 5, 2 when zero.
+	Number int
+	// Rotation is the rotation of the text.
+	Rotation Rotation
+	// Width and Height multiply the size of the characters, 1 when zero.
+	Width, Height int
+	// Reverse prints white text on black.
+	Reverse bool
+}
+
+// Writer writes EPL2 commands to an io.Writer.
+type Writer struct {
+	w      io.Writer
+	err    error
+	copies int
+}
+
+// NewWriter returns a Writer on w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Err returns the first error met while writing.
+func (e *Writer) Err() error {
+	return e.err
+}
+
+// Write sends b to the printer unchanged.
+func (e *Writer) Write(b []byte) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	n, err := e.w.Write(b)
+	if err != nil {
+		e.err = err
+	}
+	return n, err
+}
+
+func (e *Writer) printf(format string, a ...any) error {
+	_, err := fmt.Fprintf(e, format, a...)
+	return err
+}
+
+// Start begins a label: it clears the image buffer with N and selects the
+// Windows 1252 code page, in which text is encoded, with I8.
+func (e *Writer) Start() error {
+	e.copies = 1
+	return e.printf("\nN\nI8,A,001\n")
+}
+
+// PrintWidth sets the width of the label with q.
+func (e *Writer) PrintWidth(dots int) error {
+	return e.printf("q%d\n", dots)
+}
+
+// LabelLength sets the length of the label, and the gap between labels,
+// with Q. A gap of zero selects continuous media.
+func (e *Writer) LabelLength(dots, gap int) error {
+	return e.printf("Q%d,%d\n", dots, gap)
+}
+
+// Quantity prints n copies of the label when it ends.
+func (e *Writer) Quantity(n int) error {
+	e.copies = n
+	return e.err
+}
+
+// End prints the label with P.
+func (e *Writer) End() error {
+	if e.copies > 1 {
+		return e.printf("P1,%d\n", e.copies)
+	}
+	return e.printf("P1\n")
+}
+
+// quote returns s encoded in Windows 1252 between double quotes, escaping
+// quotes and backslashes. Characters the code page lacks become '?'.
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch c, ok := charmap.Windows1252.EncodeRune(r); {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x80:
+			b.WriteByte(byte(r))
+		case ok:
+			b.WriteByte(c)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func one(n int) int {
+	if n <= 0 {
+		return 1
+	}
+	return n
+}
+
+func reverse(on bool) byte {
+	if on {
+		return 'R'
+	}
+	return 'N'
+}
+
+// Text prints s at x, y with font.
+func (e *Writer) Text(x, y int, font Font, s string) error {
+	number := font.Number
+	if number == 0 {
+		number = 2
+	}
+	return e.printf("A%d,%d,%d,%d,%d,%d,%c,%s\n", x, y, font.Rotation, number,
+		one(font.Width), one(font.Height), reverse(font.Reverse), quote(s))
+}
+
+// Box draws a rectangle with X, its top left corner at x, y, with borders
+// thickness dots wide.
+func (e *Writer) Box(x, y, width, height, thickness int) error {
+	return e.printf("X%d,%d,%d,%d,%d\n", x, y, thickness, x+width, y+height)
+}
+
+// HLine draws a horizontal line from x, y with LO.
+func (e *Writer) HLine(x, y, length, thickness int) error {
+	return e.printf("LO%d,%d,%d,%d\n", x, y, length, thickness)
+}
+
+// VLine draws a vertical line from x, y with LO.
+func (e *Writer) VLine(x, y, length, thickness int) error {
+	return e.printf("LO%d,%d,%d,%d\n", x, y, thickness, length)
+}
+
+// BarcodeOptions tells how to print a barcode.
+type BarcodeOptions struct {
+	// Rotation is the rotation of the barcode.
+	Rotation Rotation
+	// Height is the height of the bars in dots. Zero means 100.
+	Height int
+	// ModuleWidth is the width of the narrowest bar in dots. Zero means 2.
+	ModuleWidth int
+	// HideText leaves out the human readable interpretation.
+	HideText bool
+}
+
+func (o *BarcodeOptions) orDefault() BarcodeOptions {
+	opts := BarcodeOptions{}
+	if o != nil {
+		opts = *o
+	}
+	if opts.Height <= 0 {
+		opts.Height = 100
+	}
+	if opts.ModuleWidth <= 0 {
+		opts.ModuleWidth = 2
+	}
+	return opts
+}
+
+// barcode prints a barcode of symbology kind, such as "1" for Code 128,
+// with B.
+func (e *Writer) barcode(x, y int, kind string, data string, opts *BarcodeOptions) error {
+	o := opts.orDefault()
+	text := byte('B')
+	if o.HideText {
+		text = 'N'
+	}
+	return e.printf("B%d,%d,%d,%s,%d,%d,%d,%c,%s\n", x, y, o.Rotation, kind,
+		o.ModuleWidth, 2*o.ModuleWidth, o.Height, text, quote(data))
+}
+
+// Code128 prints data as a Code 128 barcode, the printer choosing the code
+// sets. opts may be nil.
+func (e *Writer) Code128(x, y int, data string, opts *BarcodeOptions) error {
+	return e.barcode(x, y, "1", data, opts)
+}
+
+// EAN13 prints an EAN-13 barcode. data holds 12 digits, the printer adding
+// the check digit. opts may be nil.
+func (e *Writer) EAN13(x, y int, data string, opts *BarcodeOptions) error {
+	return e.barcode(x, y, "E30", data, opts)
+}
+
+// EAN8 prints an EAN-8 barcode. data holds 7 digits, the printer adding the
+// check digit. opts may be nil.
+func (e *Writer) EAN8(x, y int, data string, opts *BarcodeOptions) error {
+	return e.barcode(x, y, "E80", data, opts)
+}
+
+var errEmptyImage = errors.New("epl: empty image")
+
+// Graphic prints img at x, y with GW. Images other than *mono.Image are
+// thresholded at mid gray: convert them with mono.Convert beforehand to
+// dither them.
+func (e *Writer) Graphic(x, y int, img image.Image) error {
+	m, ok := img.(*mono.Image)
+	if !ok {
+		m = mono.Convert(img, nil)
+	}
+	b := m.Bounds()
+	if b.Empty() {
+		return errEmptyImage
+	}
+	stride := (b.Dx() + 7) / 8
+	cmd := []byte(fmt.Sprintf("GW%d,%d,%d,%d,", x, y, stride, b.Dy()))
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		// GW prints the clear bits: invert the rows, padding included.
+		for _, p := range m.Row(y)[:stride] {
+			cmd = append(cmd, ^p)
+		}
+	}
+	cmd = append(cmd, '\n')
+	_, err := e.Write(cmd)
+	return err
+}
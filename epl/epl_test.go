@@ -0,0 +1,64 @@
+package epl
+
+import (
+	"bytes"
+	"image"
+	"testing"
+
+	"github.com/sipkg/golang-win32-printer/image/mono"
+)
+
+func TestLabel(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewWriter(&buf)
+	e.Start()
+	e.PrintWidth(812)
+	e.LabelLength(406, 24)
+	e.Text(30, 40, Font{Number: 4}, `Café "42" \ 5 €`)
+	e.Text(30, 80, Font{Rotation: Rotate90, Width: 2, Height: 3, Reverse: true}, "Lot")
+	e.Box(0, 0, 780, 380, 4)
+	e.HLine(0, 120, 780, 2)
+	e.VLine(400, 120, 260, 3)
+	e.Code128(30, 150, "ABC-123", nil)
+	e.EAN13(420, 150, "400638133393", &BarcodeOptions{Height: 60, ModuleWidth: 3, HideText: true})
+	e.Quantity(2)
+	e.End()
+	if err := e.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := "\nN\nI8,A,001\n" +
+		"q812\n" +
+		"Q406,24\n" +
+		"A30,40,0,4,1,1,N,\"Caf\xe9 \\\"42\\\" \\\\ 5 \x80\"\n" +
+		"A30,80,1,2,2,3,R,\"Lot\"\n" +
+		"X0,0,4,780,380\n" +
+		"LO0,120,780,2\n" +
+		"LO400,120,3,260\n" +
+		"B30,150,0,1,2,4,100,B,\"ABC-123\"\n" +
+		"B420,150,0,E30,3,6,60,N,\"400638133393\"\n" +
+		"P1,2\n"
+	if got := buf.String(); got != want {
+		t.Errorf("label is\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestGraphic(t *testing.T) {
+	img := mono.New(image.Rect(0, 0, 10, 2))
+	img.SetBlack(0, 0, true)
+	img.SetBlack(9, 0, true)
+	for x := 0; x < 10; x++ {
+		img.SetBlack(x, 1, true)
+	}
+	var buf bytes.Buffer
+	e := NewWriter(&buf)
+	if err := e.Graphic(5, 6, img); err != nil {
+		t.Fatal(err)
+	}
+	want := "GW5,6,2,2,\x7f\xbf\x00\x3f\n"
+	if got := buf.String(); got != want {
+		t.Errorf("graphic is %q, want %q", got, want)
+	}
+	if err := e.Graphic(0, 0, mono.New(image.Rectangle{})); err == nil {
+		t.Error("empty graphic accepted")
+	}
+}
@@ -0,0 +1,448 @@
+package emulator
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/sipkg/golang-win32-printer/image/mono"
+)
+
+// Barcode systems of GS k, in the function B numbering. Function A uses the
+// same systems numbered from 0.
+const (
+	upcA    = 65
+	upcE    = 66
+	ean13   = 67
+	ean8    = 68
+	code39  = 69
+	itf     = 70
+	codabar = 71
+	code93  = 72
+	code128 = 73
+)
+
+var errBarcodeData = errors.New("invalid barcode data")
+
+// barcode prints GS k m d1...dk NUL or GS k m n d1...dn.
+func (e *Emulator) barcode(b []byte) int {
+	if len(b) < 3 {
+		return 0
+	}
+	m := int(b[2])
+	var data []byte
+	var n int
+	if m <= 6 {
+		i := 3
+		for i < len(b) && b[i] != 0 {
+			i++
+		}
+		if i == len(b) {
+			return 0
+		}
+		data, n = b[3:i], i+1
+		m += upcA
+	} else {
+		if len(b) < 4 {
+			return 0
+		}
+		n = 4 + int(b[3])
+		if len(b) < n {
+			return 0
+		}
+		data = b[4:n]
+	}
+	bars, text, err := encodeBarcode(m, data, e.st.barcodeWidth)
+	if err != nil {
+		e.skip(fmt.Sprintf("GS k %d: %v", m, err))
+		return n
+	}
+	e.printBlock(e.barcodeImage(bars, text))
+	return n
+}
+
+// encodeBarcode returns the widths in dots of the bars and spaces of a
+// barcode, starting with a bar, and its human readable text.
+func encodeBarcode(m int, data []byte, module int) ([]int, string, error) {
+	switch m {
+	case upcA:
+		if len(data) != 11 && len(data) != 12 {
+			return nil, "", errBarcodeData
+		}
+		bars, err := encodeEAN(append([]byte{'0'}, data...), module)
+		return bars, eanText(data, 12), err
+	case ean13:
+		if len(data) != 12 && len(data) != 13 {
+			return nil, "", errBarcodeData
+		}
+		bars, err := encodeEAN(data, module)
+		return bars, eanText(data, 13), err
+	case ean8:
+		if len(data) != 7 && len(data) != 8 {
+			return nil, "", errBarcodeData
+		}
+		bars, err := encodeEAN(data, module)
+		return bars, eanText(data, 8), err
+	case code39:
+		bars, err := encodeCode39(data, module)
+		return bars, "*" + string(data) + "*", err
+	case itf:
+		bars, err := encodeITF(data, module)
+		return bars, string(data), err
+	case code128:
+		return encodeCode128(data, module)
+	}
+	return nil, "", errors.New("unsupported barcode system")
+}
+
+// barcodeImage draws bars at the barcode height, with the human readable
+// text above or below as selected by GS H.
+func (e *Emulator) barcodeImage(bars []int, text string) *mono.Image {
+	width := 0
+	for _, w := range bars {
+		width += w
+	}
+	var label []*mono.Image
+	labelWidth, labelHeight := 0, 0
+	if e.st.hri != 0 {
+		for _, r := range text {
+			g := e.glyph(r, e.st.hriFontB, false)
+			label = append(label, g)
+			labelWidth += g.Rect.Dx()
+			labelHeight = g.Rect.Dy()
+		}
+	}
+	above := e.st.hri == 1 || e.st.hri == 3
+	below := e.st.hri == 2 || e.st.hri == 3
+	top := 0
+	if above {
+		top = labelHeight
+	}
+	h := top + e.st.barcodeHeight
+	if below {
+		h += labelHeight
+	}
+	img := mono.New(image.Rect(0, 0, max(width, labelWidth), h))
+	x := (img.Rect.Dx() - width) / 2
+	for i, w := range bars {
+		if i%2 == 0 {
+			for y := top; y < top+e.st.barcodeHeight; y++ {
+				for dx := 0; dx < w; dx++ {
+					img.SetBlack(x+dx, y, true)
+				}
+			}
+		}
+		x += w
+	}
+	drawLabel := func(y int) {
+		x := (img.Rect.Dx() - labelWidth) / 2
+		for _, g := range label {
+			for gy := 0; gy < g.Rect.Dy(); gy++ {
+				for gx := 0; gx < g.Rect.Dx(); gx++ {
+					if g.Black(gx, gy) {
+						img.SetBlack(x+gx, y+gy, true)
+					}
+				}
+			}
+			x += g.Rect.Dx()
+		}
+	}
+	if above {
+		drawLabel(0)
+	}
+	if below {
+		drawLabel(top + e.st.barcodeHeight)
+	}
+	return img
+}
+
+// modules converts a string of '1' (bar) and '0' (space) modules into
+// widths.
+func modules(s string, module int) []int {
+	var widths []int
+	for i := 0; i < len(s); {
+		j := i
+		for j < len(s) && s[j] == s[i] {
+			j++
+		}
+		widths = append(widths, (j-i)*module)
+		i = j
+	}
+	return widths
+}
+
+var (
+	eanL = [10]string{"0001101", "0011001", "0010011", "0111101", "0100011",
+		"0110001", "0101111", "0111011", "0110111", "0001011"}
+	eanG = [10]string{"0100111", "0110011", "0011011", "0100001", "0011101",
+		"0111001", "0000101", "0010001", "0001001", "0010111"}
+	// eanParity tells which of the six left digits of an EAN-13 use the G
+	// code, from its first digit.
+	eanParity = [10]string{"LLLLLL", "LLGLGG", "LLGGLG", "LLGGGL", "LGLLGG",
+		"LGGLLG", "LGGGLL", "LGLGLG", "LGLGGL", "LGGLGL"}
+)
+
+// eanCheck returns the check digit of the digits of an EAN or UPC code.
+func eanCheck(digits []byte) byte {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i -= 2 {
+		sum += 3 * int(digits[i]-'0')
+		if i > 0 {
+			sum += int(digits[i-1] - '0')
+		}
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// eanText returns data with its check digit, n digits long.
+func eanText(data []byte, n int) string {
+	if len(data) == n {
+		return string(data)
+	}
+	return string(data) + string(eanCheck(data))
+}
+
+// encodeEAN encodes 12 or 13 digits as EAN-13, 7 or 8 as EAN-8. The check
+// digit is computed when missing, and checked when given.
+func encodeEAN(data []byte, module int) ([]int, error) {
+	for _, c := range data {
+		if c < '0' || c > '9' {
+			return nil, errBarcodeData
+		}
+	}
+	n := 13
+	if len(data) <= 8 {
+		n = 8
+	}
+	digits := make([]byte, n)
+	copy(digits, data)
+	if len(data) == n-1 {
+		digits[n-1] = eanCheck(data)
+	} else if digits[n-1] != eanCheck(digits[:n-1]) {
+		return nil, errors.New("bad check digit")
+	}
+	var s strings.Builder
+	s.WriteString("101")
+	left, right := digits[:4], digits[4:]
+	parity := "LLLL"
+	if n == 13 {
+		left, right = digits[1:7], digits[7:]
+		parity = eanParity[digits[0]-'0']
+	}
+	for i, c := range left {
+		if parity[i] == 'G' {
+			s.WriteString(eanG[c-'0'])
+		} else {
+			s.WriteString(eanL[c-'0'])
+		}
+	}
+	s.WriteString("01010")
+	for _, c := range right {
+		// The R code is the complement of the L code.
+		for _, m := range eanL[c-'0'] {
+			s.WriteByte('0' + '1' - byte(m))
+		}
+	}
+	s.WriteString("101")
+	return modules(s.String(), module), nil
+}
+
+// code39Chars lists the Code 39 characters, whose patterns follow in
+// code39Patterns: nine bits for bars and spaces, set when wide.
+const code39Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ-. $/+%*"
+
+var code39Patterns = [...]uint16{
+	0x034, 0x121, 0x061, 0x160, 0x031, 0x130, 0x070, 0x025, 0x124, 0x064,
+	0x109, 0x049, 0x148, 0x019, 0x118, 0x058, 0x00d, 0x10c, 0x04c, 0x01c,
+	0x103, 0x043, 0x142, 0x013, 0x112, 0x052, 0x007, 0x106, 0x046, 0x016,
+	0x181, 0x0c1, 0x1c0, 0x091, 0x190, 0x0d0, 0x085, 0x184, 0x0c4, 0x0a8,
+	0x0a2, 0x08a, 0x02a, 0x094,
+}
+
+// wide returns the width of a wide element for a narrow one of module dots,
+// in the 2.5 ratio of ESC/POS printers.
+func wide(module int) int {
+	return max(module*5/2, module+1)
+}
+
+func encodeCode39(data []byte, module int) ([]int, error) {
+	var widths []int
+	add := func(c byte) error {
+		i := strings.IndexByte(code39Chars, c)
+		if i < 0 {
+			return errBarcodeData
+		}
+		for bit := 8; bit >= 0; bit-- {
+			if code39Patterns[i]&(1<<bit) != 0 {
+				widths = append(widths, wide(module))
+			} else {
+				widths = append(widths, module)
+			}
+		}
+		// Gap between characters.
+		widths = append(widths, module)
+		return nil
+	}
+	add('*')
+	for _, c := range data {
+		if c == '*' {
+			return nil, errBarcodeData
+		}
+		if err := add(c); err != nil {
+			return nil, err
+		}
+	}
+	add('*')
+	return widths[:len(widths)-1], nil
+}
+
+// itfPatterns gives the five elements of each digit, set when wide.
+var itfPatterns = [10]string{"00110", "10001", "01001", "11000", "00101",
+	"10100", "01100", "00011", "10010", "01010"}
+
+func encodeITF(data []byte, module int) ([]int, error) {
+	if len(data) == 0 || len(data)%2 != 0 {
+		return nil, errBarcodeData
+	}
+	width := func(bit byte) int {
+		if bit == '1' {
+			return wide(module)
+		}
+		return module
+	}
+	widths := []int{module, module, module, module}
+	for i := 0; i < len(data); i += 2 {
+		a, b := data[i], data[i+1]
+		if a < '0' || a > '9' || b < '0' || b > '9' {
+			return nil, errBarcodeData
+		}
+		for j := 0; j < 5; j++ {
+			widths = append(widths, width(itfPatterns[a-'0'][j]), width(itfPatterns[b-'0'][j]))
+		}
+	}
+	return append(widths, wide(module), module, module), nil
+}
+
+// code128Patterns gives the bar and space widths, in modules, of the Code
+// 128 symbols; the last one is the stop pattern.
+var code128Patterns = [...]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+// Code 128 symbols switching code sets and functions.
+const (
+	c128CodeC  = 99
+	c128CodeB  = 100
+	c128CodeA  = 101
+	c128FNC1   = 102
+	c128StartA = 103
+	c128Shift  = 98
+	c128FNC2   = 97
+	c128FNC3   = 96
+)
+
+// encodeCode128 encodes data in the ESC/POS form: it starts with "{A", "{B"
+// or "{C" selecting the code set, "{" introduces set changes and functions
+// and in code set C each byte is a value from 0 to 99.
+func encodeCode128(data []byte, module int) ([]int, string, error) {
+	if len(data) < 2 || data[0] != '{' || data[1] < 'A' || data[1] > 'C' {
+		return nil, "", errBarcodeData
+	}
+	set := data[1]
+	values := []int{c128StartA + int(set-'A')}
+	var text strings.Builder
+	shift := false
+	for i := 2; i < len(data); i++ {
+		c := data[i]
+		cur := set
+		if shift {
+			cur = 'A' + 'B' - set
+			shift = false
+		}
+		if c == '{' && i+1 < len(data) && data[i+1] != '{' {
+			i++
+			switch data[i] {
+			case 'A':
+				values = append(values, c128CodeA)
+			case 'B':
+				values = append(values, c128CodeB)
+			case 'C':
+				values = append(values, c128CodeC)
+			case 'S':
+				if set == 'C' {
+					return nil, "", errBarcodeData
+				}
+				values = append(values, c128Shift)
+				shift = true
+				continue
+			case '1':
+				values = append(values, c128FNC1)
+				continue
+			case '2':
+				values = append(values, c128FNC2)
+				continue
+			case '3':
+				values = append(values, c128FNC3)
+				continue
+			case '4':
+				if set == 'C' {
+					return nil, "", errBarcodeData
+				}
+				// FNC4 is the code set switch to the set itself.
+				values = append(values, c128CodeA-int(set-'A'))
+				continue
+			default:
+				return nil, "", errBarcodeData
+			}
+			set = data[i]
+			continue
+		}
+		if c == '{' {
+			// "{{" is a literal brace.
+			i++
+		}
+		switch {
+		case cur == 'C':
+			if c > 99 {
+				return nil, "", errBarcodeData
+			}
+			values = append(values, int(c))
+			fmt.Fprintf(&text, "%02d", c)
+			continue
+		case cur == 'A' && c < 32:
+			values = append(values, int(c)+64)
+		case cur == 'A' && c < 96, cur == 'B' && c >= 32 && c < 128:
+			values = append(values, int(c)-32)
+		default:
+			return nil, "", errBarcodeData
+		}
+		if c >= 32 {
+			text.WriteByte(c)
+		} else {
+			text.WriteByte(' ')
+		}
+	}
+	sum := values[0]
+	for i, v := range values[1:] {
+		sum += (i + 1) * v
+	}
+	values = append(values, sum%103, len(code128Patterns)-1)
+	var widths []int
+	for _, v := range values {
+		for _, w := range code128Patterns[v] {
+			widths = append(widths, int(w-'0')*module)
+		}
+	}
+	return widths, text.String(), nil
+}
@@ -0,0 +1,762 @@
+// Package emulator interprets ESC/POS byte streams like a receipt printer,
+// printing onto paper kept in memory, so that receipts can be checked in
+// tests and raw jobs previewed without a printer.
+//
+// The emulator follows the standard mode commands written by package escpos
+// and the common Epson ones: text with fonts A and B, emphasis, underline,
+// reverse printing, character sizes, alignment, margins and tabs, raster
+// and column bit images, 1D barcodes and cuts, drawn as markers. Other
+// commands, such as page mode, QR codes and stored images, are skipped and
+// reported.
+package emulator
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/sipkg/golang-win32-printer/device/fonts"
+	"github.com/sipkg/golang-win32-printer/escpos"
+	"github.com/sipkg/golang-win32-printer/image/bgr"
+	"github.com/sipkg/golang-win32-printer/image/mono"
+)
+
+// DefaultLineSpacing is the line feed in dots of ESC 2, 1/6 inch at 203 dpi.
+const DefaultLineSpacing = 34
+
+// Unsupported is a command the emulator skipped or only approximated.
+type Unsupported struct {
+	// Offset is the position of the command in the stream.
+	Offset  int
+	Command string
+}
+
+// UnsupportedError lists the commands the emulator could not print.
+type UnsupportedError struct {
+	Commands []Unsupported
+}
+
+func (e *UnsupportedError) Error() string {
+	first := e.Commands[0]
+	return fmt.Sprintf("escpos: %d unsupported commands, first %s at %d",
+		len(e.Commands), first.Command, first.Offset)
+}
+
+var errTruncated = errors.New("escpos: stream ends inside a command")
+
+// Render prints data on paper width dots wide and returns the result. Cuts
+// are drawn as red dashed lines. Commands that could not be printed are
+// reported in an *UnsupportedError, along with the image.
+func Render(data []byte, width int) (*bgr.BGRImage, error) {
+	e := New(width)
+	e.Write(data)
+	img := e.Image()
+	if len(e.pending) > 0 {
+		return img, errTruncated
+	}
+	if len(e.unsupported) > 0 {
+		return img, &UnsupportedError{Commands: e.unsupported}
+	}
+	return img, nil
+}
+
+// Emulator is a virtual receipt printer. It is an io.Writer, so that an
+// escpos.Writer or a copy of a raw job can print on it.
+type Emulator struct {
+	width       int
+	paper       *mono.Image
+	cuts        []int
+	unsupported []Unsupported
+
+	// pending holds the start of a command split across writes.
+	pending []byte
+	offset  int
+
+	line  []*mono.Image
+	lineX int
+	st    state
+	faces map[faceKey]font.Face
+	cells map[glyphKey]*mono.Image
+}
+
+type state struct {
+	fontB        bool
+	bold         bool
+	underline    int
+	inverse      bool
+	width        int
+	height       int
+	rightSpacing int
+	align        escpos.Alignment
+	lineSpacing  int
+	codePage     escpos.CodePage
+	leftMargin   int
+	areaWidth    int
+
+	barcodeHeight int
+	barcodeWidth  int
+	hri           byte
+	hriFontB      bool
+}
+
+// New returns an emulator printing on paper width dots wide, such as
+// escpos.Dots80mm.
+func New(width int) *Emulator {
+	e := &Emulator{
+		width: width,
+		paper: mono.New(image.Rect(0, 0, width, 0)),
+		faces: map[faceKey]font.Face{},
+		cells: map[glyphKey]*mono.Image{},
+	}
+	e.reset()
+	return e
+}
+
+func (e *Emulator) reset() {
+	e.line, e.lineX = nil, 0
+	e.st = state{
+		width:         1,
+		height:        1,
+		lineSpacing:   DefaultLineSpacing,
+		codePage:      escpos.PC437,
+		areaWidth:     e.width,
+		barcodeHeight: 162,
+		barcodeWidth:  3,
+	}
+}
+
+// Paper returns the dots printed so far. Text not yet ended by a line feed
+// is not printed.
+func (e *Emulator) Paper() *mono.Image {
+	return e.paper
+}
+
+// Cuts returns the positions of the cuts, in rows of Paper.
+func (e *Emulator) Cuts() []int {
+	return e.cuts
+}
+
+// Unsupported returns the commands skipped so far.
+func (e *Emulator) Unsupported() []Unsupported {
+	return e.unsupported
+}
+
+// Image returns the paper as a BGRImage, with the cuts drawn as red dashed
+// lines.
+func (e *Emulator) Image() *bgr.BGRImage {
+	h := e.paper.Rect.Dy()
+	for _, y := range e.cuts {
+		h = max(h, y+1)
+	}
+	img := bgr.NewBGRImage(image.Rect(0, 0, e.width, h))
+	draw.Draw(img, img.Rect, image.White, image.Point{}, draw.Src)
+	for y := 0; y < e.paper.Rect.Dy(); y++ {
+		for x := 0; x < e.width; x++ {
+			if e.paper.Black(x, y) {
+				img.Set(x, y, color.Black)
+			}
+		}
+	}
+	red := color.RGBA{R: 0xff, A: 0xff}
+	for _, y := range e.cuts {
+		for x := 0; x < e.width; x++ {
+			if x/4%2 == 0 {
+				img.Set(x, y, red)
+			}
+		}
+	}
+	return img
+}
+
+// Write interprets b. Commands split across calls are completed by the next
+// ones. It never fails.
+func (e *Emulator) Write(b []byte) (int, error) {
+	e.pending = append(e.pending, b...)
+	for len(e.pending) > 0 {
+		n := e.step(e.pending)
+		if n == 0 {
+			break
+		}
+		e.pending = e.pending[n:]
+		e.offset += n
+	}
+	if len(e.pending) == 0 {
+		e.pending = nil
+	}
+	return len(b), nil
+}
+
+func (e *Emulator) skip(command string) {
+	e.unsupported = append(e.unsupported, Unsupported{Offset: e.offset, Command: command})
+}
+
+// step interprets the command at the start of b and returns its length, or
+// 0 when b ends before the command does.
+func (e *Emulator) step(b []byte) int {
+	switch c := b[0]; {
+	case c == escpos.LF:
+		e.printLine(e.st.lineSpacing)
+		return 1
+	case c == escpos.HT:
+		e.tab()
+		return 1
+	case c == escpos.ESC:
+		return e.esc(b)
+	case c == escpos.GS:
+		return e.gs(b)
+	case c == escpos.DLE:
+		return e.dle(b)
+	case c == 0x1c:
+		return e.fs(b)
+	case c < 0x20 || c == 0x7f:
+		// CR and other controls are ignored.
+		return 1
+	default:
+		e.char(c)
+		return 1
+	}
+}
+
+// need returns n when b holds n bytes, 0 otherwise.
+func need(b []byte, n int) int {
+	if len(b) < n {
+		return 0
+	}
+	return n
+}
+
+func (e *Emulator) esc(b []byte) int {
+	if len(b) < 2 {
+		return 0
+	}
+	switch b[1] {
+	case '@':
+		e.reset()
+		return 2
+	case '2':
+		e.st.lineSpacing = DefaultLineSpacing
+		return 2
+	case 'i', 'm':
+		e.cut(0)
+		return 2
+	case 'L', 'S', 'W', 'T', 0x0c:
+		return e.pageMode(b)
+	case 'D':
+		// Tab positions, up to 32 ended by NUL.
+		for i := 2; i < len(b) && i < 35; i++ {
+			if b[i] == 0 {
+				e.skip("ESC D")
+				return i + 1
+			}
+		}
+		if len(b) >= 35 {
+			e.skip("ESC D")
+			return 35
+		}
+		return 0
+	case '$', '\\', 'c':
+		if len(b) < 4 {
+			return 0
+		}
+		n := int(b[2]) | int(b[3])<<8
+		switch b[1] {
+		case '$':
+			e.moveTo(n)
+		case '\\':
+			e.moveTo(e.lineX + int(int16(n)))
+		}
+		return 4
+	case 'p':
+		// Drawer kick.
+		return need(b, 5)
+	case '*':
+		return e.bitImage(b)
+	case '&':
+		return e.userChars(b)
+	}
+	if len(b) < 3 {
+		return 0
+	}
+	n := b[2]
+	switch b[1] {
+	case '!':
+		e.st.fontB = n&0x01 != 0
+		e.st.bold = n&0x08 != 0
+		e.st.height = 1 + int(n>>4&1)
+		e.st.width = 1 + int(n>>5&1)
+		e.st.underline = int(n >> 7)
+	case 'E', 'G':
+		e.st.bold = n&1 != 0
+	case '-':
+		e.st.underline = min(int(n%48), 2)
+	case 'a':
+		e.st.align = escpos.Alignment(n % 48)
+	case 'd':
+		e.printLine(e.st.lineSpacing)
+		for i := 1; i < int(n); i++ {
+			e.printLine(e.st.lineSpacing)
+		}
+	case 'J':
+		e.printLine(int(n))
+	case '3':
+		e.st.lineSpacing = int(n)
+	case 't':
+		if escpos.CodePage(n).Charmap() == nil {
+			e.skip(fmt.Sprintf("ESC t %d", n))
+		} else {
+			e.st.codePage = escpos.CodePage(n)
+		}
+	case 'M':
+		e.st.fontB = n%48 == 1
+	case ' ':
+		e.st.rightSpacing = int(n)
+	case 'R', 'U', '=', '%', 'r', 'K', 'e':
+		// International character sets, unidirectional printing, peripheral
+		// selection, user defined characters, colors and reverse feeds have
+		// no visible effect here.
+	case '{':
+		if n&1 != 0 {
+			e.skip("ESC {")
+		}
+	case 'V':
+		if n%48 != 0 {
+			e.skip("ESC V")
+		}
+	default:
+		e.skip(fmt.Sprintf("ESC %q", b[1]))
+		return 2
+	}
+	return 3
+}
+
+func (e *Emulator) gs(b []byte) int {
+	if len(b) < 2 {
+		return 0
+	}
+	switch b[1] {
+	case 'V':
+		if len(b) < 3 {
+			return 0
+		}
+		switch b[2] {
+		case 0, 1, '0', '1':
+			e.cut(0)
+			return 3
+		case 65, 66, 97, 98, 103, 104:
+			if len(b) < 4 {
+				return 0
+			}
+			e.cut(int(b[3]))
+			return 4
+		}
+		e.skip("GS V")
+		return 3
+	case 'v':
+		return e.raster(b)
+	case 'k':
+		return e.barcode(b)
+	case 'L', 'W', 'P':
+		if len(b) < 4 {
+			return 0
+		}
+		n := int(b[2]) | int(b[3])<<8
+		switch b[1] {
+		case 'L':
+			e.st.leftMargin = min(n, e.width)
+		case 'W':
+			e.st.areaWidth = n
+		}
+		return 4
+	case '(':
+		if len(b) < 5 {
+			return 0
+		}
+		n := 5 + (int(b[3]) | int(b[4])<<8)
+		if len(b) < n {
+			return 0
+		}
+		e.skip(fmt.Sprintf("GS ( %c", b[2]))
+		return n
+	case '*':
+		if len(b) < 4 {
+			return 0
+		}
+		n := 4 + int(b[2])*int(b[3])*8
+		if len(b) < n {
+			return 0
+		}
+		e.skip("GS *")
+		return n
+	case '8':
+		if len(b) < 7 {
+			return 0
+		}
+		n := 7 + (int(b[3]) | int(b[4])<<8 | int(b[5])<<16 | int(b[6])<<24)
+		if len(b) < n {
+			return 0
+		}
+		e.skip("GS 8 " + string(b[2]))
+		return n
+	}
+	if len(b) < 3 {
+		return 0
+	}
+	n := b[2]
+	switch b[1] {
+	case '!':
+		e.st.width = 1 + int(n>>4&7)
+		e.st.height = 1 + int(n&7)
+	case 'B':
+		e.st.inverse = n&1 != 0
+	case 'h':
+		e.st.barcodeHeight = max(int(n), 1)
+	case 'w':
+		e.st.barcodeWidth = max(int(n), 1)
+	case 'H':
+		e.st.hri = n % 48
+	case 'f':
+		e.st.hriFontB = n%48 == 1
+	case 'a', 'r', 'I', 'b':
+		// Status requests and smoothing.
+	case '/':
+		e.skip("GS /")
+	default:
+		e.skip(fmt.Sprintf("GS %q", b[1]))
+		return 2
+	}
+	return 3
+}
+
+func (e *Emulator) dle(b []byte) int {
+	if len(b) < 2 {
+		return 0
+	}
+	switch b[1] {
+	case escpos.EOT, 0x05:
+		return need(b, 3)
+	case 0x14:
+		return need(b, 5)
+	}
+	e.skip(fmt.Sprintf("DLE %#x", b[1]))
+	return 2
+}
+
+func (e *Emulator) fs(b []byte) int {
+	if len(b) < 2 {
+		return 0
+	}
+	switch b[1] {
+	case '.', '&':
+		// Kanji mode.
+		return 2
+	case '!', 'C', '-':
+		return need(b, 3)
+	case 'p':
+		if len(b) < 4 {
+			return 0
+		}
+		e.skip("FS p")
+		return 4
+	}
+	e.skip(fmt.Sprintf("FS %q", b[1]))
+	return 2
+}
+
+func (e *Emulator) pageMode(b []byte) int {
+	var n int
+	switch b[1] {
+	case 'L', 'S', 0x0c:
+		n = 2
+	case 'T':
+		n = 3
+	case 'W':
+		n = 10
+	}
+	if len(b) < n {
+		return 0
+	}
+	e.skip(fmt.Sprintf("ESC %q", b[1]))
+	return n
+}
+
+// userChars skips the definition of user defined characters,
+// ESC & y c1 c2 followed by a width and y*width bytes per character.
+func (e *Emulator) userChars(b []byte) int {
+	if len(b) < 5 {
+		return 0
+	}
+	y, c1, c2 := int(b[2]), int(b[3]), int(b[4])
+	n := 5
+	for c := c1; c <= c2; c++ {
+		if len(b) <= n {
+			return 0
+		}
+		n += 1 + y*int(b[n])
+	}
+	if len(b) < n {
+		return 0
+	}
+	e.skip("ESC &")
+	return n
+}
+
+// printArea returns the left edge and the width of the printable area.
+func (e *Emulator) printArea() (int, int) {
+	return e.st.leftMargin, max(min(e.st.areaWidth, e.width-e.st.leftMargin), 1)
+}
+
+// printLine prints the line buffer and feeds the paper by feed dots, or by
+// the height of the line when larger.
+func (e *Emulator) printLine(feed int) {
+	h := 0
+	for _, item := range e.line {
+		h = max(h, item.Rect.Dy())
+	}
+	y := e.paper.Rect.Max.Y
+	e.grow(max(h, feed))
+	x := e.alignedX(e.lineX)
+	for _, item := range e.line {
+		e.blit(item, x, y+h-item.Rect.Dy())
+		x += item.Rect.Dx()
+	}
+	e.line, e.lineX = nil, 0
+}
+
+// printBlock prints img on its own, aligned like text.
+func (e *Emulator) printBlock(img *mono.Image) {
+	if len(e.line) > 0 {
+		e.printLine(e.st.lineSpacing)
+	}
+	y := e.paper.Rect.Max.Y
+	e.grow(img.Rect.Dy())
+	e.blit(img, e.alignedX(img.Rect.Dx()), y)
+}
+
+func (e *Emulator) alignedX(width int) int {
+	left, area := e.printArea()
+	switch e.st.align {
+	case escpos.Center:
+		return left + max(area-width, 0)/2
+	case escpos.Right:
+		return left + max(area-width, 0)
+	}
+	return left
+}
+
+// grow adds h blank rows at the end of the paper.
+func (e *Emulator) grow(h int) {
+	if h <= 0 {
+		return
+	}
+	e.paper.Pix = append(e.paper.Pix, make([]uint8, h*e.paper.Stride)...)
+	e.paper.Rect.Max.Y += h
+}
+
+// blit prints the black dots of img with its top left corner at x, y.
+// Dots past the edges of the paper are lost.
+func (e *Emulator) blit(img *mono.Image, x, y int) {
+	b := img.Rect
+	for dy := 0; dy < b.Dy(); dy++ {
+		for dx := 0; dx < b.Dx(); dx++ {
+			if img.Black(b.Min.X+dx, b.Min.Y+dy) {
+				e.paper.SetBlack(x+dx, y+dy, true)
+			}
+		}
+	}
+}
+
+// add appends item to the line buffer, printing the buffer first when item
+// does not fit.
+func (e *Emulator) add(item *mono.Image) {
+	_, area := e.printArea()
+	if e.lineX > 0 && e.lineX+item.Rect.Dx() > area {
+		e.printLine(e.st.lineSpacing)
+	}
+	e.line = append(e.line, item)
+	e.lineX += item.Rect.Dx()
+}
+
+// moveTo moves the print position of the current line to x dots from the
+// left margin.
+func (e *Emulator) moveTo(x int) {
+	_, area := e.printArea()
+	if x > e.lineX && x <= area {
+		e.line = append(e.line, mono.New(image.Rect(0, 0, x-e.lineX, 0)))
+		e.lineX = x
+	}
+}
+
+// tab moves to the next multiple of 8 characters of the current font.
+func (e *Emulator) tab() {
+	w := e.cellWidth()
+	e.moveTo((e.lineX/(8*w) + 1) * 8 * w)
+}
+
+func (e *Emulator) cellWidth() int {
+	if e.st.fontB {
+		return 9
+	}
+	return 12
+}
+
+func (e *Emulator) char(c byte) {
+	r := rune(c)
+	if c >= 0x80 {
+		r = e.st.codePage.Charmap().DecodeByte(c)
+	}
+	cell := e.glyph(r, e.st.fontB, e.st.bold)
+	cw, ch := cell.Rect.Dx(), cell.Rect.Dy()
+	w, h := e.st.width, e.st.height
+	item := mono.New(image.Rect(0, 0, (cw+e.st.rightSpacing)*w, ch*h))
+	for y := 0; y < ch*h; y++ {
+		for x := 0; x < cw*w; x++ {
+			item.SetBlack(x, y, cell.Black(x/w, y/h))
+		}
+	}
+	for i := 0; i < e.st.underline*h; i++ {
+		y := ch*h - 1 - i
+		for x := 0; x < item.Rect.Dx(); x++ {
+			item.SetBlack(x, y, true)
+		}
+	}
+	if e.st.inverse {
+		for i := range item.Pix {
+			item.Pix[i] = ^item.Pix[i]
+		}
+		// Keep the padding bits clear.
+		if r := item.Rect.Dx() % 8; r != 0 {
+			for y := 0; y < item.Rect.Dy(); y++ {
+				item.Row(y)[item.Stride-1] &= 0xff << (8 - r)
+			}
+		}
+	}
+	e.add(item)
+}
+
+type faceKey struct{ fontB, bold bool }
+
+type glyphKey struct {
+	r           rune
+	fontB, bold bool
+}
+
+// glyph returns the dots of r in a 12x24 font A or 9x17 font B cell.
+func (e *Emulator) glyph(r rune, fontB, bold bool) *mono.Image {
+	k := glyphKey{r, fontB, bold}
+	if g, ok := e.cells[k]; ok {
+		return g
+	}
+	w, h, size, baseline := 12, 24, 20.0, 19
+	if fontB {
+		w, h, size, baseline = 9, 17, 15.0, 13
+	}
+	face, ok := e.faces[faceKey{fontB, bold}]
+	if !ok {
+		var err error
+		face, err = fonts.Lookup("Courier New", bold, false).Face(size)
+		if err != nil {
+			face = nil
+		}
+		e.faces[faceKey{fontB, bold}] = face
+	}
+	g := mono.New(image.Rect(0, 0, w, h))
+	if face != nil {
+		mask := image.NewAlpha(g.Rect)
+		d := font.Drawer{Dst: mask, Src: image.Opaque, Face: face, Dot: fixed.P(0, baseline)}
+		d.DrawString(string(r))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				g.SetBlack(x, y, mask.AlphaAt(x, y).A >= 0x80)
+			}
+		}
+	}
+	e.cells[k] = g
+	return g
+}
+
+// cut prints the line buffer, feeds n dots and records a cut.
+func (e *Emulator) cut(n int) {
+	if len(e.line) > 0 {
+		e.printLine(e.st.lineSpacing)
+	}
+	e.grow(n)
+	e.cuts = append(e.cuts, e.paper.Rect.Max.Y)
+}
+
+// raster prints GS v 0 m xL xH yL yH d1...dk.
+func (e *Emulator) raster(b []byte) int {
+	if len(b) < 8 {
+		return 0
+	}
+	stride := int(b[4]) | int(b[5])<<8
+	rows := int(b[6]) | int(b[7])<<8
+	n := 8 + stride*rows
+	if len(b) < n {
+		return 0
+	}
+	if b[2] != '0' {
+		e.skip("GS v " + string(b[2]))
+		return n
+	}
+	m := b[3] % 48
+	w, h := 1+int(m&1), 1+int(m>>1&1)
+	src := &mono.Image{Pix: b[8:n], Stride: stride, Rect: image.Rect(0, 0, stride*8, rows)}
+	e.printBlock(scale(src, w, h))
+	return n
+}
+
+// bitImage adds ESC * m nL nH d1...dk to the line buffer.
+func (e *Emulator) bitImage(b []byte) int {
+	if len(b) < 5 {
+		return 0
+	}
+	mode := escpos.BitImageMode(b[2])
+	cols := int(b[3]) | int(b[4])<<8
+	dots, w, h := 8, 2, 3
+	switch mode {
+	case escpos.SingleDensity8:
+	case escpos.DoubleDensity8:
+		w = 1
+	case escpos.SingleDensity24:
+		dots, h = 24, 1
+	case escpos.DoubleDensity24:
+		dots, w, h = 24, 1, 1
+	default:
+		e.skip(fmt.Sprintf("ESC * %d", mode))
+		return 2
+	}
+	colBytes := dots / 8
+	n := 5 + cols*colBytes
+	if len(b) < n {
+		return 0
+	}
+	src := mono.New(image.Rect(0, 0, cols, dots))
+	for x := 0; x < cols; x++ {
+		for y := 0; y < dots; y++ {
+			if b[5+x*colBytes+y/8]&(0x80>>(y%8)) != 0 {
+				src.SetBlack(x, y, true)
+			}
+		}
+	}
+	e.add(scale(src, w, h))
+	return n
+}
+
+// scale returns src stretched w times horizontally and h times vertically.
+func scale(src *mono.Image, w, h int) *mono.Image {
+	b := src.Rect
+	dst := mono.New(image.Rect(0, 0, b.Dx()*w, b.Dy()*h))
+	for y := 0; y < dst.Rect.Dy(); y++ {
+		for x := 0; x < dst.Rect.Dx(); x++ {
+			if src.Black(b.Min.X+x/w, b.Min.Y+y/h) {
+				dst.SetBlack(x, y, true)
+			}
+		}
+	}
+	return dst
+}
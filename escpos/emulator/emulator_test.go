@@ -0,0 +1,208 @@
+package emulator
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/sipkg/golang-win32-printer/escpos"
+	"github.com/sipkg/golang-win32-printer/image/mono"
+)
+
+// inkX returns the leftmost and rightmost black columns of rows y0 to y1 of
+// m, -1 when blank.
+func inkX(m *mono.Image, y0, y1 int) (int, int) {
+	left, right := -1, -1
+	for y := y0; y < y1; y++ {
+		for x := 0; x < m.Rect.Dx(); x++ {
+			if m.Black(x, y) {
+				if left < 0 || x < left {
+					left = x
+				}
+				right = max(right, x)
+			}
+		}
+	}
+	return left, right
+}
+
+func receipt() []byte {
+	var b bytes.Buffer
+	p := escpos.NewWriter(&b)
+	p.Init()
+	p.Align(escpos.Center)
+	p.Line("HELLO")
+	p.Align(escpos.Right)
+	p.Line("X")
+	p.Align(escpos.Left)
+	p.Size(2, 2)
+	p.Line("B")
+	p.FeedAndCut(escpos.PartialCut, 10)
+	return b.Bytes()
+}
+
+func TestText(t *testing.T) {
+	e := New(escpos.Dots80mm)
+	e.Write(receipt())
+	paper := e.Paper()
+	if h := paper.Rect.Dy(); h != 2*DefaultLineSpacing+48+10 {
+		t.Errorf("paper is %d dots long", h)
+	}
+	if cuts := e.Cuts(); len(cuts) != 1 || cuts[0] != paper.Rect.Dy() {
+		t.Errorf("cuts at %v", cuts)
+	}
+	left, right := inkX(paper, 0, DefaultLineSpacing)
+	if mid := (left + right) / 2; mid < 280 || mid > 296 {
+		t.Errorf("centered line spans %d to %d", left, right)
+	}
+	if left, _ := inkX(paper, DefaultLineSpacing, 2*DefaultLineSpacing); left < escpos.Dots80mm-12 {
+		t.Errorf("right aligned line starts at %d", left)
+	}
+	left, right = inkX(paper, 2*DefaultLineSpacing, 2*DefaultLineSpacing+48)
+	if left < 0 || left > 4 || right-left < 12 {
+		t.Errorf("double size line spans %d to %d", left, right)
+	}
+	if len(e.Unsupported()) != 0 {
+		t.Errorf("unsupported commands %v", e.Unsupported())
+	}
+}
+
+func TestSplitWrites(t *testing.T) {
+	data := receipt()
+	whole := New(escpos.Dots80mm)
+	whole.Write(data)
+	split := New(escpos.Dots80mm)
+	for i := range data {
+		split.Write(data[i : i+1])
+	}
+	if !bytes.Equal(whole.Paper().Pix, split.Paper().Pix) {
+		t.Error("byte by byte writes print differently")
+	}
+}
+
+func testPattern(w, h int) *mono.Image {
+	m := mono.New(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		m.SetBlack(y%w, y, true)
+		m.SetBlack(w-1, y, true)
+	}
+	return m
+}
+
+func equalDots(t *testing.T, paper, want *mono.Image, at image.Point) {
+	t.Helper()
+	b := want.Rect
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			if paper.Black(at.X+x, at.Y+y) != want.Black(x, y) {
+				t.Fatalf("dot %d,%d differs", x, y)
+			}
+		}
+	}
+}
+
+func TestImages(t *testing.T) {
+	img := testPattern(20, 24)
+	var b bytes.Buffer
+	p := escpos.NewWriter(&b)
+	p.RasterImage(img, nil)
+	p.ColumnImage(img, escpos.DoubleDensity24, nil)
+	p.Align(escpos.Right)
+	p.RasterImage(img, &escpos.ImageOptions{BandHeight: 5})
+
+	e := New(escpos.Dots80mm)
+	e.Write(b.Bytes())
+	if h := e.Paper().Rect.Dy(); h != 3*24 {
+		t.Fatalf("paper is %d dots long", h)
+	}
+	equalDots(t, e.Paper(), img, image.Point{})
+	equalDots(t, e.Paper(), img, image.Point{Y: 24})
+	// Raster rows are padded to whole bytes.
+	equalDots(t, e.Paper(), img, image.Point{X: escpos.Dots80mm - 24, Y: 48})
+}
+
+func TestBarcode(t *testing.T) {
+	var b bytes.Buffer
+	b.Write([]byte{escpos.GS, 'h', 50, escpos.GS, 'w', 2, escpos.GS, 'H', 2})
+	b.Write([]byte{escpos.GS, 'k', 67, 12})
+	b.WriteString("400638133393")
+	e := New(escpos.Dots80mm)
+	e.Write(b.Bytes())
+	paper := e.Paper()
+	if h := paper.Rect.Dy(); h != 50+24 {
+		t.Errorf("barcode is %d dots high", h)
+	}
+	left, right := inkX(paper, 0, 50)
+	if right-left+1 != 95*2 {
+		t.Errorf("barcode spans %d to %d", left, right)
+	}
+	if len(e.Unsupported()) != 0 {
+		t.Errorf("unsupported commands %v", e.Unsupported())
+	}
+}
+
+func TestEncodeBarcode(t *testing.T) {
+	tests := []struct {
+		m       int
+		data    string
+		modules int
+		text    string
+	}{
+		{ean13, "400638133393", 95, "4006381333931"},
+		{ean13, "4006381333931", 95, "4006381333931"},
+		{upcA, "03600029145", 95, "036000291452"},
+		{ean8, "9638507", 67, "96385074"},
+		{code128, "{BHi", 57, "Hi"},
+		{code128, "{C\x0c\x22", 57, "1234"},
+		{itf, "1234", 4 + 2*10 + 3, "1234"},
+		{code39, "A1", 4*9 + 3, "*A1*"},
+	}
+	for _, tt := range tests {
+		bars, text, err := encodeBarcode(tt.m, []byte(tt.data), 1)
+		if err != nil {
+			t.Errorf("%d %q: %v", tt.m, tt.data, err)
+			continue
+		}
+		n := len(bars)
+		if tt.m == code128 || tt.m <= ean8 {
+			n = 0
+			for _, w := range bars {
+				n += w
+			}
+		}
+		if n != tt.modules || text != tt.text {
+			t.Errorf("%d %q: %d modules %q, want %d %q", tt.m, tt.data, n, text, tt.modules, tt.text)
+		}
+	}
+	for _, data := range []string{"4006381333932", "40063813339x"} {
+		if _, _, err := encodeBarcode(ean13, []byte(data), 1); err == nil {
+			t.Errorf("EAN-13 %q accepted", data)
+		}
+	}
+	for i, p := range code128Patterns[:len(code128Patterns)-1] {
+		sum := 0
+		for _, w := range p {
+			sum += int(w - '0')
+		}
+		if sum != 11 {
+			t.Errorf("Code 128 symbol %d is %d modules wide", i, sum)
+		}
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	qr := []byte{escpos.GS, '(', 'k', 3, 0, 49, 67, 4, 'H', 'i', '\n'}
+	img, err := Render(qr, escpos.Dots58mm)
+	var unsupported *UnsupportedError
+	if !errors.As(err, &unsupported) || !strings.Contains(err.Error(), "GS ( k") {
+		t.Errorf("Render returned %v", err)
+	}
+	if img.Rect.Dx() != escpos.Dots58mm {
+		t.Errorf("image is %d dots wide", img.Rect.Dx())
+	}
+	if _, err := Render([]byte{escpos.GS, 'v', '0', 0, 1}, escpos.Dots58mm); err != errTruncated {
+		t.Errorf("Render of a truncated stream returned %v", err)
+	}
+}
@@ -0,0 +1,328 @@
+// Package escpos encodes the ESC/POS command language spoken by most receipt
+// printers.
+//
+// A Writer turns method calls into ESC/POS bytes on any io.Writer: a
+// printer.RawJob, a network connection or a bytes.Buffer in tests. Errors are
+// sticky: once a write fails, every later call returns the same error
+// without writing, so a sequence of commands can be checked once with Err.
+package escpos
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Control characters starting ESC/POS commands.
+const (
+	HT  = 0x09
+	LF  = 0x0A
+	DLE = 0x10
+	EOT = 0x04
+	ESC = 0x1B
+	GS  = 0x1D
+)
+
+// Alignment is the justification set with ESC a.
+type Alignment byte
+
+const (
+	Left   Alignment = 0
+	Center Alignment = 1
+	Right  Alignment = 2
+)
+
+// UnderlineMode is the underline thickness set with ESC -.
+type UnderlineMode byte
+
+const (
+	NoUnderline     UnderlineMode = 0
+	SingleUnderline UnderlineMode = 1
+	DoubleUnderline UnderlineMode = 2
+)
+
+// CutMode selects a full or partial cut with GS V.
+type CutMode byte
+
+const (
+	FullCut    CutMode = 0
+	PartialCut CutMode = 1
+)
+
+// CodePage is a character code table selected with ESC t, numbered as on
+// Epson printers.
+type CodePage byte
+
+const (
+	PC437   CodePage = 0
+	PC850   CodePage = 2
+	PC860   CodePage = 3
+	PC863   CodePage = 4
+	PC865   CodePage = 5
+	WPC1252 CodePage = 16
+	PC866   CodePage = 17
+	PC852   CodePage = 18
+	PC858   CodePage = 19
+)
+
+var charmaps = map[CodePage]*charmap.Charmap{
+	PC437:   charmap.CodePage437,
+	PC850:   charmap.CodePage850,
+	PC860:   charmap.CodePage860,
+	PC863:   charmap.CodePage863,
+	PC865:   charmap.CodePage865,
+	WPC1252: charmap.Windows1252,
+	PC866:   charmap.CodePage866,
+	PC852:   charmap.CodePage852,
+	PC858:   charmap.CodePage858,
+}
+
+// Charmap returns the encoding of the code page, nil when not supported.
+func (cp CodePage) Charmap() *charmap.Charmap {
+	return charmaps[cp]
+}
+
+// Writer writes ESC/POS commands to an io.Writer.
+type Writer struct {
+	w        io.Writer
+	err      error
+	codePage CodePage
+	width    byte
+	height   byte
+}
+
+// NewWriter returns a Writer encoding text in code page PC437, the power on
+// default of ESC/POS printers.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w, codePage: PC437, width: 1, height: 1}
+}
+
+// Err returns the first error met while writing.
+func (p *Writer) Err() error {
+	return p.err
+}
+
+// Write sends b to the printer unchanged, so that a Writer can be handed to
+// code producing its own commands.
+func (p *Writer) Write(b []byte) (int, error) {
+	if p.err != nil {
+		return 0, p.err
+	}
+	n, err := p.w.Write(b)
+	if err != nil {
+		p.err = err
+	}
+	return n, err
+}
+
+func (p *Writer) command(b ...byte) error {
+	_, err := p.Write(b)
+	return err
+}
+
+// Init resets the printer to its power on state with ESC @.
+func (p *Writer) Init() error {
+	p.codePage, p.width, p.height = PC437, 1, 1
+	return p.command(ESC, '@')
+}
+
+// Text prints s encoded in the current code page, without a line feed.
+// Characters the code page lacks are printed as '?'.
+func (p *Writer) Text(s string) error {
+	cm := charmaps[p.codePage]
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r < 0x80 {
+			b = append(b, byte(r))
+			continue
+		}
+		c, ok := cm.EncodeRune(r)
+		if !ok {
+			c = '?'
+		}
+		b = append(b, c)
+	}
+	_, err := p.Write(b)
+	return err
+}
+
+// Line prints s followed by a line feed.
+func (p *Writer) Line(s string) error {
+	if err := p.Text(s); err != nil {
+		return err
+	}
+	return p.LineFeed()
+}
+
+// LineFeed prints the buffer and feeds one line.
+func (p *Writer) LineFeed() error {
+	return p.command(LF)
+}
+
+// Feed prints the buffer and feeds n lines with ESC d.
+func (p *Writer) Feed(n byte) error {
+	return p.command(ESC, 'd', n)
+}
+
+// LineSpacing sets the line spacing to n motion units with ESC 3.
+func (p *Writer) LineSpacing(n byte) error {
+	return p.command(ESC, '3', n)
+}
+
+// DefaultLineSpacing restores the default line spacing with ESC 2.
+func (p *Writer) DefaultLineSpacing() error {
+	return p.command(ESC, '2')
+}
+
+// Align sets the justification of the following lines with ESC a.
+func (p *Writer) Align(a Alignment) error {
+	return p.command(ESC, 'a', byte(a))
+}
+
+// Bold turns emphasized mode on or off with ESC E.
+func (p *Writer) Bold(on bool) error {
+	return p.command(ESC, 'E', flag(on))
+}
+
+// Underline sets the underline mode with ESC -.
+func (p *Writer) Underline(mode UnderlineMode) error {
+	return p.command(ESC, '-', byte(mode))
+}
+
+// Inverse turns white on black printing on or off with GS B.
+func (p *Writer) Inverse(on bool) error {
+	return p.command(GS, 'B', flag(on))
+}
+
+// Size sets the character width and height multipliers, from 1 to 8, with
+// GS !.
+func (p *Writer) Size(width, height byte) error {
+	if width < 1 || width > 8 || height < 1 || height > 8 {
+		return fmt.Errorf("escpos: character size %dx%d out of range", width, height)
+	}
+	p.width, p.height = width, height
+	return p.command(GS, '!', (width-1)<<4|(height-1))
+}
+
+// DoubleWidth turns double width characters on or off, keeping the height.
+func (p *Writer) DoubleWidth(on bool) error {
+	return p.Size(1+flag(on), p.height)
+}
+
+// DoubleHeight turns double height characters on or off, keeping the width.
+func (p *Writer) DoubleHeight(on bool) error {
+	return p.Size(p.width, 1+flag(on))
+}
+
+// CodePage selects the character code table used by the printer and by Text.
+func (p *Writer) CodePage(cp CodePage) error {
+	if _, ok := charmaps[cp]; !ok {
+		return fmt.Errorf("escpos: unsupported code page %d", cp)
+	}
+	p.codePage = cp
+	return p.command(ESC, 't', byte(cp))
+}
+
+// Cut cuts the paper at the current position with GS V.
+func (p *Writer) Cut(mode CutMode) error {
+	return p.command(GS, 'V', byte(mode))
+}
+
+// FeedAndCut feeds the paper n motion units past the cutting position, so
+// that the last line printed is not cut, and cuts it with GS V.
+func (p *Writer) FeedAndCut(mode CutMode, n byte) error {
+	return p.command(GS, 'V', 65+byte(mode), n)
+}
+
+func flag(on bool) byte {
+	if on {
+		return 1
+	}
+	return 0
+}
+
+// StatusKind selects the status byte returned by DLE EOT.
+type StatusKind byte
+
+const (
+	PrinterStatus StatusKind = 1
+	OfflineStatus StatusKind = 2
+	ErrorStatus   StatusKind = 3
+	PaperStatus   StatusKind = 4
+)
+
+// Status is a decoded DLE EOT status byte. Only the fields of its Kind are
+// meaningful.
+type Status struct {
+	Kind StatusKind
+
+	// PrinterStatus. Whether a high drawer kick-out connector pin means
+	// open or closed depends on the drawer.
+	DrawerPinHigh bool
+	Offline       bool
+	// OfflineStatus.
+	CoverOpen  bool
+	FeedButton bool
+	PaperOut   bool
+	Error      bool
+	// ErrorStatus.
+	CutterError     bool
+	Unrecoverable   bool
+	AutoRecoverable bool
+	// PaperStatus.
+	PaperNearEnd bool
+	PaperEnd     bool
+}
+
+var errBadStatus = errors.New("escpos: not a status byte")
+
+// RequestStatus asks the printer for a real time status byte with DLE EOT.
+// The printer answers at once, even when busy or offline.
+func (p *Writer) RequestStatus(kind StatusKind) error {
+	return p.command(DLE, EOT, byte(kind))
+}
+
+// ParseStatus decodes the byte a printer answers to RequestStatus.
+func ParseStatus(kind StatusKind, b byte) (Status, error) {
+	// Bits 1 and 4 are always set, bits 0 and 7 always clear.
+	if b&0x93 != 0x12 {
+		return Status{}, errBadStatus
+	}
+	s := Status{Kind: kind}
+	bit := func(n uint) bool { return b&(1<<n) != 0 }
+	switch kind {
+	case PrinterStatus:
+		s.DrawerPinHigh = bit(2)
+		s.Offline = bit(3)
+	case OfflineStatus:
+		s.CoverOpen = bit(2)
+		s.FeedButton = bit(3)
+		s.PaperOut = bit(5)
+		s.Error = bit(6)
+	case ErrorStatus:
+		s.CutterError = bit(3)
+		s.Unrecoverable = bit(5)
+		s.AutoRecoverable = bit(6)
+	case PaperStatus:
+		s.PaperNearEnd = bit(2) || bit(3)
+		s.PaperEnd = bit(5) || bit(6)
+	default:
+		return Status{}, fmt.Errorf("escpos: unknown status kind %d", kind)
+	}
+	return s, nil
+}
+
+// QueryStatus sends a status request on rw, a bidirectional connection to
+// the printer, and decodes its answer.
+func QueryStatus(rw io.ReadWriter, kind StatusKind) (Status, error) {
+	if err := NewWriter(rw).RequestStatus(kind); err != nil {
+		return Status{}, err
+	}
+	var b [1]byte
+	if _, err := io.ReadFull(rw, b[:]); err != nil {
+		return Status{}, fmt.Errorf("escpos: reading status: %w", err)
+	}
+	return ParseStatus(kind, b[0])
+}
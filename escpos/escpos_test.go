@@ -0,0 +1,121 @@
+package escpos
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestCommands(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(p *Writer) error
+		want []byte
+	}{
+		{"Init", (*Writer).Init, []byte{ESC, '@'}},
+		{"Bold", func(p *Writer) error { return p.Bold(true) }, []byte{ESC, 'E', 1}},
+		{"Underline", func(p *Writer) error { return p.Underline(DoubleUnderline) }, []byte{ESC, '-', 2}},
+		{"Inverse", func(p *Writer) error { return p.Inverse(true) }, []byte{GS, 'B', 1}},
+		{"Size", func(p *Writer) error { return p.Size(2, 3) }, []byte{GS, '!', 0x12}},
+		{"DoubleHeight", func(p *Writer) error { return p.DoubleHeight(true) }, []byte{GS, '!', 0x01}},
+		{"DoubleWidth", func(p *Writer) error { return p.DoubleWidth(true) }, []byte{GS, '!', 0x10}},
+		{"Align", func(p *Writer) error { return p.Align(Right) }, []byte{ESC, 'a', 2}},
+		{"Feed", func(p *Writer) error { return p.Feed(3) }, []byte{ESC, 'd', 3}},
+		{"LineSpacing", func(p *Writer) error { return p.LineSpacing(30) }, []byte{ESC, '3', 30}},
+		{"Cut", func(p *Writer) error { return p.Cut(PartialCut) }, []byte{GS, 'V', 1}},
+		{"FeedAndCut", func(p *Writer) error { return p.FeedAndCut(FullCut, 80) }, []byte{GS, 'V', 65, 80}},
+		{"CodePage", func(p *Writer) error { return p.CodePage(PC858) }, []byte{ESC, 't', 19}},
+		{"RequestStatus", func(p *Writer) error { return p.RequestStatus(PaperStatus) }, []byte{DLE, EOT, 4}},
+		{"Line", func(p *Writer) error { return p.Line("Total") }, []byte("Total\n")},
+	}
+	for _, tt := range tests {
+		var b bytes.Buffer
+		if err := tt.call(NewWriter(&b)); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+		if !bytes.Equal(b.Bytes(), tt.want) {
+			t.Errorf("%s wrote % x, want % x", tt.name, b.Bytes(), tt.want)
+		}
+	}
+}
+
+func TestSizeKeepsOtherDimension(t *testing.T) {
+	var b bytes.Buffer
+	p := NewWriter(&b)
+	p.DoubleWidth(true)
+	p.DoubleHeight(true)
+	p.DoubleWidth(false)
+	want := []byte{GS, '!', 0x10, GS, '!', 0x11, GS, '!', 0x01}
+	if !bytes.Equal(b.Bytes(), want) {
+		t.Errorf("wrote % x, want % x", b.Bytes(), want)
+	}
+	if err := p.Size(9, 1); err == nil {
+		t.Error("Size(9, 1) accepted")
+	}
+}
+
+func TestText(t *testing.T) {
+	var b bytes.Buffer
+	p := NewWriter(&b)
+	p.Text("à 2€")
+	p.CodePage(PC858)
+	p.Text("à 2€")
+	want := []byte{0x85, ' ', '2', '?', ESC, 't', 19, 0x85, ' ', '2', 0xD5}
+	if !bytes.Equal(b.Bytes(), want) {
+		t.Errorf("wrote % x, want % x", b.Bytes(), want)
+	}
+}
+
+type failingWriter struct{ n int }
+
+func (f *failingWriter) Write(b []byte) (int, error) {
+	f.n++
+	return 0, errors.New("offline")
+}
+
+func TestStickyError(t *testing.T) {
+	f := &failingWriter{}
+	p := NewWriter(f)
+	p.Init()
+	if err := p.Bold(true); err == nil || err != p.Err() {
+		t.Errorf("Bold() error = %v, Err() = %v", err, p.Err())
+	}
+	if f.n != 1 {
+		t.Errorf("%d writes after an error, want none", f.n-1)
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+	s, err := ParseStatus(PaperStatus, 0x12|0x0C)
+	if err != nil || !s.PaperNearEnd || s.PaperEnd {
+		t.Errorf("ParseStatus(paper near end) = %+v, %v", s, err)
+	}
+	s, err = ParseStatus(OfflineStatus, 0x12|0x04|0x20)
+	if err != nil || !s.CoverOpen || !s.PaperOut || s.Error {
+		t.Errorf("ParseStatus(cover open, paper out) = %+v, %v", s, err)
+	}
+	if _, err := ParseStatus(PrinterStatus, 0xFF); err == nil {
+		t.Error("ParseStatus() accepted 0xff")
+	}
+}
+
+// printerConn answers status requests with a fixed byte.
+type printerConn struct {
+	bytes.Buffer
+	answer []byte
+}
+
+func (c *printerConn) Read(b []byte) (int, error) {
+	return copy(b, c.answer), nil
+}
+
+func TestQueryStatus(t *testing.T) {
+	c := &printerConn{answer: []byte{0x12 | 0x08}}
+	s, err := QueryStatus(c, PrinterStatus)
+	if err != nil || !s.Offline {
+		t.Errorf("QueryStatus() = %+v, %v", s, err)
+	}
+	if !bytes.Equal(c.Bytes(), []byte{DLE, EOT, 1}) {
+		t.Errorf("request = % x", c.Bytes())
+	}
+}
@@ -0,0 +1,141 @@
+package escpos
+
+import (
+	"errors"
+	"image"
+
+	"github.com/sipkg/golang-win32-printer/image/mono"
+)
+
+// Printable widths in dots of 203 dpi receipt printers.
+const (
+	Dots80mm = 576
+	Dots58mm = 384
+)
+
+// BitImageMode is the density of column format images printed with ESC *.
+// Single density modes print each column twice as wide.
+type BitImageMode byte
+
+const (
+	SingleDensity8  BitImageMode = 0
+	DoubleDensity8  BitImageMode = 1
+	SingleDensity24 BitImageMode = 32
+	DoubleDensity24 BitImageMode = 33
+)
+
+// ImageOptions tells how images are fitted to the printer.
+type ImageOptions struct {
+	// Width is the printable width in dots. Wider images are cropped on
+	// the right. Zero means Dots80mm.
+	Width int
+	// BandHeight is the number of rows sent per GS v 0 command, so that each
+	// command fits the receive buffer of the printer. Zero means 256.
+	BandHeight int
+}
+
+var errEmptyImage = errors.New("escpos: empty image")
+
+func (o *ImageOptions) width() int {
+	if o == nil || o.Width <= 0 {
+		return Dots80mm
+	}
+	return o.Width
+}
+
+func (o *ImageOptions) bandHeight() int {
+	if o == nil || o.BandHeight <= 0 {
+		return 256
+	}
+	return o.BandHeight
+}
+
+// toMono returns img as a black and white image. Images other than
+// *mono.Image are thresholded at mid gray: convert them with mono.Convert
+// beforehand to dither them.
+func toMono(img image.Image) *mono.Image {
+	if m, ok := img.(*mono.Image); ok {
+		return m
+	}
+	return mono.Convert(img, nil)
+}
+
+// RasterImage prints img as raster bit images with GS v 0, one command per
+// band of rows. opts may be nil.
+func (p *Writer) RasterImage(img image.Image, opts *ImageOptions) error {
+	m := toMono(img)
+	b := m.Rect
+	width := min(b.Dx(), opts.width())
+	if width <= 0 || b.Dy() <= 0 {
+		return errEmptyImage
+	}
+	stride := (width + 7) / 8
+	band := opts.bandHeight()
+	for y0 := b.Min.Y; y0 < b.Max.Y; y0 += band {
+		rows := min(band, b.Max.Y-y0)
+		cmd := make([]byte, 8, 8+stride*rows)
+		copy(cmd, []byte{GS, 'v', '0', 0,
+			byte(stride), byte(stride >> 8), byte(rows), byte(rows >> 8)})
+		for y := y0; y < y0+rows; y++ {
+			cmd = append(cmd, m.Row(y)[:stride]...)
+			// Clear the bits of the cropped pixels.
+			if width%8 != 0 {
+				cmd[len(cmd)-1] &= 0xff << (8 - width%8)
+			}
+		}
+		if err := p.command(cmd...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ColumnImage prints img as column format bit images with ESC *, one line
+// of 8 or 24 rows at a time, for printers lacking GS v 0. The line spacing
+// is restored to its default afterwards. opts may be nil; its BandHeight is
+// unused.
+func (p *Writer) ColumnImage(img image.Image, mode BitImageMode, opts *ImageOptions) error {
+	var dots, maxWidth int
+	switch mode {
+	case SingleDensity8:
+		dots, maxWidth = 8, opts.width()/2
+	case DoubleDensity8:
+		dots, maxWidth = 8, opts.width()
+	case SingleDensity24:
+		dots, maxWidth = 24, opts.width()/2
+	case DoubleDensity24:
+		dots, maxWidth = 24, opts.width()
+	default:
+		return errors.New("escpos: unknown bit image mode")
+	}
+	m := toMono(img)
+	b := m.Rect
+	width := min(b.Dx(), maxWidth)
+	if width <= 0 || b.Dy() <= 0 {
+		return errEmptyImage
+	}
+	colBytes := dots / 8
+	// 8 dot modes print at 60 dpi vertically and 24 dot modes at 180 dpi:
+	// both advance 24/180 inch per line.
+	if err := p.LineSpacing(24); err != nil {
+		return err
+	}
+	for y0 := b.Min.Y; y0 < b.Max.Y; y0 += dots {
+		cmd := make([]byte, 5, 5+width*colBytes+1)
+		copy(cmd, []byte{ESC, '*', byte(mode), byte(width), byte(width >> 8)})
+		for x := 0; x < width; x++ {
+			col := make([]byte, colBytes)
+			for dy := 0; dy < dots && y0+dy < b.Max.Y; dy++ {
+				if m.Black(b.Min.X+x, y0+dy) {
+					col[dy/8] |= 0x80 >> (dy % 8)
+				}
+			}
+			cmd = append(cmd, col...)
+		}
+		cmd = append(cmd, LF)
+		if err := p.command(cmd...); err != nil {
+			return err
+		}
+	}
+	return p.DefaultLineSpacing()
+}
@@ -0,0 +1,102 @@
+package escpos
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/sipkg/golang-win32-printer/image/bgr"
+)
+
+// testImage returns a white w x h image whose first column and last row are
+// black.
+func testImage(w, h int) *bgr.BGRImage {
+	img := bgr.NewBGRImage(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.Color(color.White)
+			if x == 0 || y == h-1 {
+				c = color.Black
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestRasterImage(t *testing.T) {
+	var b bytes.Buffer
+	if err := NewWriter(&b).RasterImage(testImage(10, 3), nil); err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{GS, 'v', '0', 0, 2, 0, 3, 0,
+		0x80, 0x00,
+		0x80, 0x00,
+		0xff, 0xc0,
+	}
+	if !bytes.Equal(b.Bytes(), want) {
+		t.Errorf("wrote % x, want % x", b.Bytes(), want)
+	}
+}
+
+func TestRasterImageBandsAndWidth(t *testing.T) {
+	var b bytes.Buffer
+	opts := &ImageOptions{Width: 8, BandHeight: 2}
+	if err := NewWriter(&b).RasterImage(testImage(10, 3), opts); err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{
+		GS, 'v', '0', 0, 1, 0, 2, 0, 0x80, 0x80,
+		GS, 'v', '0', 0, 1, 0, 1, 0, 0xff,
+	}
+	if !bytes.Equal(b.Bytes(), want) {
+		t.Errorf("wrote % x, want % x", b.Bytes(), want)
+	}
+}
+
+func TestRasterImageGeneric(t *testing.T) {
+	// Transparent pixels are left blank.
+	img := image.NewNRGBA(image.Rect(5, 5, 7, 6))
+	img.Set(5, 5, color.NRGBA{A: 0xff})
+	img.Set(6, 5, color.NRGBA{A: 0x10})
+	var b bytes.Buffer
+	if err := NewWriter(&b).RasterImage(img, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{GS, 'v', '0', 0, 1, 0, 1, 0, 0x80}
+	if !bytes.Equal(b.Bytes(), want) {
+		t.Errorf("wrote % x, want % x", b.Bytes(), want)
+	}
+	if err := NewWriter(&b).RasterImage(image.NewGray(image.Rect(0, 0, 0, 0)), nil); err == nil {
+		t.Error("empty image accepted")
+	}
+}
+
+func TestColumnImage(t *testing.T) {
+	var b bytes.Buffer
+	if err := NewWriter(&b).ColumnImage(testImage(3, 10), DoubleDensity8, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{ESC, '3', 24,
+		ESC, '*', 1, 3, 0, 0xff, 0x00, 0x00, LF,
+		ESC, '*', 1, 3, 0, 0xc0, 0x40, 0x40, LF,
+		ESC, '2',
+	}
+	if !bytes.Equal(b.Bytes(), want) {
+		t.Errorf("wrote % x, want % x", b.Bytes(), want)
+	}
+
+	b.Reset()
+	opts := &ImageOptions{Width: 4}
+	if err := NewWriter(&b).ColumnImage(testImage(3, 2), SingleDensity24, opts); err != nil {
+		t.Fatal(err)
+	}
+	want = []byte{ESC, '3', 24,
+		ESC, '*', 32, 2, 0, 0xc0, 0, 0, 0x40, 0, 0, LF,
+		ESC, '2',
+	}
+	if !bytes.Equal(b.Bytes(), want) {
+		t.Errorf("wrote % x, want % x", b.Bytes(), want)
+	}
+}
@@ -1,11 +1,12 @@
 package main
 
 import (
+	"image/color"
 	"log"
-	"syscall"
 
+	"github.com/sipkg/golang-win32-printer/device"
 	"github.com/sipkg/golang-win32-printer/layout"
-	"github.com/sipkg/golang-win32-printer/win32"
+	"github.com/sipkg/golang-win32-printer/printer"
 )
 
 const (
@@ -16,134 +17,135 @@ const (
 func main() {
 	printName := "Microsoft Print to PDF"
 	// printName := "PDF"
-	dc, err := win32.CreateDC(printName)
+	p := &printer.Printer{}
+	err := p.InitPrinter(printName)
 	if err != nil {
 		log.Fatalf("CreateDC failed: %s", err)
 	}
-	err = win32.StartDCPrinter(dc, "gdiDoc")
+	err = p.StartDoc("gdiDoc")
 	if err != nil {
-		log.Fatalf("StartDCPrinter failed: %s", err)
+		log.Fatalf("StartDoc failed: %s", err)
 	}
-	err = win32.StartPage(dc)
+	err = p.StartPage()
 	if err != nil {
 		log.Fatalf("StartPage failed: %s", err)
 	}
 
-	width, err := win32.GetDeviceCaps(dc, win32.HORZRES)
+	width, err := p.GetDeviceCaps(device.HorzRes)
 	if err != nil {
 		log.Fatalf("Retreiving page width failed: %s", err)
 	}
-	_, err = win32.GetDeviceCaps(dc, win32.VERTRES)
+	_, err = p.GetDeviceCaps(device.VertRes)
 	if err != nil {
 		log.Fatalf("Retreiving page height failed: %s", err)
 	}
 
-	oldcol, err := win32.SetTextColor(dc, win32.RGB(0, 0, 0))
+	oldcol, err := p.SetTextColor(color.Black)
 	if err != nil {
 		log.Printf("SetTextColor failed: %s", err)
 	}
 	log.Printf("Before color was %v", oldcol)
 
-	_, err = win32.SetTextSize(dc, textHeight)
+	_, err = p.SetTextSize(textHeight)
 	if err != nil {
 		log.Printf("SetTextSize failed: %s", err)
 	}
 
 	text := "Original Size"
-	textWidth, textHeight, err := win32.GetTextExtentPoint32(syscall.Handle(dc), text)
+	textWidth, textHeight, err := p.GetTextExtentPoint32(text)
 	if err != nil {
 		log.Printf("Get text dimensions failed: %s", err)
 	}
 	x := layout.CenterElement(width, textWidth)
 	startY := uint32(10)
-	err = win32.TextOut(dc, x, startY, text, uint32(len(text)))
+	err = p.TextOut(x, startY, text)
 	if err != nil {
 		log.Printf("TextOut failed: %s", err)
 	}
 
 	startY += textHeight + 20
 
-	originalHeight, err := win32.SetTextSize(dc, int32(textHeight+100))
+	originalHeight, err := p.SetTextSize(int32(textHeight + 100))
 	if err != nil {
 		log.Printf("SetTextSize failed: %s", err)
 	}
 
 	text = "Bigger text"
-	textWidth, textHeight, err = win32.GetTextExtentPoint32(syscall.Handle(dc), text)
+	textWidth, textHeight, err = p.GetTextExtentPoint32(text)
 	if err != nil {
 		log.Printf("Get text dimensions failed: %s", err)
 	}
 	x = layout.CenterElement(width, textWidth)
-	err = win32.TextOut(dc, x, startY, text, uint32(len(text)))
+	err = p.TextOut(x, startY, text)
 	if err != nil {
 		log.Printf("TextOut failed: %s", err)
 	}
 
 	startY += textHeight + 20
 
-	_, err = win32.SetTextSize(dc, originalHeight)
+	_, err = p.SetTextSize(originalHeight)
 	if err != nil {
 		log.Printf("SetTextSize failed: %s", err)
 	}
 
 	text = "Original Size Again"
-	textWidth, _, err = win32.GetTextExtentPoint32(syscall.Handle(dc), text)
+	textWidth, _, err = p.GetTextExtentPoint32(text)
 	if err != nil {
 		log.Printf("Get text dimensions failed: %s", err)
 	}
 	x = layout.CenterElement(width, textWidth)
-	err = win32.TextOut(dc, x, startY, text, uint32(len(text)))
+	err = p.TextOut(x, startY, text)
 	if err != nil {
 		log.Printf("TextOut failed: %s", err)
 	}
 
 	startY += textHeight + 20
 
-	err = win32.SetBoldFont(dc, true)
+	err = p.SetBoldFont(true)
 	if err != nil {
 		log.Printf("SetBold failed: %s", err)
 	}
 
 	text = "Bolder text"
-	textWidth, _, err = win32.GetTextExtentPoint32(syscall.Handle(dc), text)
+	textWidth, _, err = p.GetTextExtentPoint32(text)
 	if err != nil {
 		log.Printf("Get text dimensions failed: %s", err)
 	}
 	x = layout.CenterElement(width, textWidth)
-	err = win32.TextOut(dc, x, startY, text, uint32(len(text)))
+	err = p.TextOut(x, startY, text)
 	if err != nil {
 		log.Printf("TextOut failed: %s", err)
 	}
 
 	startY += textHeight + 20
 
-	err = win32.SetBoldFont(dc, false)
+	err = p.SetBoldFont(false)
 	if err != nil {
 		log.Printf("SetBold failed: %s", err)
 	}
 
 	text = "Thiner text"
-	textWidth, _, err = win32.GetTextExtentPoint32(syscall.Handle(dc), text)
+	textWidth, _, err = p.GetTextExtentPoint32(text)
 	if err != nil {
 		log.Printf("Get text dimensions failed: %s", err)
 	}
 	x = layout.CenterElement(width, textWidth)
-	err = win32.TextOut(dc, x, startY, text, uint32(len(text)))
+	err = p.TextOut(x, startY, text)
 	if err != nil {
 		log.Printf("TextOut failed: %s", err)
 	}
 
 	startY += textHeight + 20
 
-	err = win32.EndPage(dc)
+	err = p.EndPage()
 	if err != nil {
 		log.Printf("EndPage failed: %s", err)
 	}
-	err = win32.EndDoc(dc)
+	err = p.EndDoc()
 	if err != nil {
 		log.Printf("EndDoc failed: %s", err)
 	}
-	err = win32.DeleteDC(dc)
+	err = p.Close()
 	if err != nil {
 		log.Printf("DeleteDC failed: %s", err)
 	}
@@ -7,15 +7,14 @@
 package main
 
 import (
-	"image"
-	"image/draw"
+	"image/color"
 	"image/png"
 	"log"
 	"os"
-	"syscall"
 
-	"github.com/sipkg/golang-win32-printer/image/bgr"
+	"github.com/sipkg/golang-win32-printer/device"
 	"github.com/sipkg/golang-win32-printer/layout"
+	"github.com/sipkg/golang-win32-printer/printer"
 	"github.com/sipkg/golang-win32-printer/win32"
 )
 
@@ -27,25 +26,26 @@ const (
 func main() {
 	printName := "Microsoft Print to PDF"
 	// printName := "PDF"
-	dc, err := win32.CreateDC(printName)
+	p := &printer.Printer{}
+	err := p.InitPrinter(printName)
 	if err != nil {
 		log.Fatalf("CreateDC failed: %s", err)
 	}
-	err = win32.StartDCPrinter(dc, "gdiDoc")
+	err = p.StartDoc("gdiDoc")
 	if err != nil {
-		log.Fatalf("StartDCPrinter failed: %s", err)
+		log.Fatalf("StartDoc failed: %s", err)
 	}
-	err = win32.StartPage(dc)
+	err = p.StartPage()
 	if err != nil {
 		log.Fatalf("StartPage failed: %s", err)
 	}
 
-	win32.SetFont(dc, win32.CourierNew)
-	width, err := win32.GetDeviceCaps(dc, win32.HORZRES)
+	p.SetFont(win32.CourierNew)
+	width, err := p.GetDeviceCaps(device.HorzRes)
 	if err != nil {
 		log.Fatalf("Retreiving page width failed: %s", err)
 	}
-	height, err := win32.GetDeviceCaps(dc, win32.VERTRES)
+	height, err := p.GetDeviceCaps(device.VertRes)
 	if err != nil {
 		log.Fatalf("Retreiving page height failed: %s", err)
 	}
@@ -66,46 +66,43 @@ func main() {
 	imgX := layout.CenterElement(uint32(width), imgWidth)
 	imgY := layout.CenterElement(uint32(height), imgHeight)
 
-	imgbgr := bgr.NewBGRImage(img.Bounds())
-	draw.Draw(imgbgr, img.Bounds(), img, image.Point{0, 0}, draw.Src)
-	src := bgr.ReverseDIB(imgbgr.Pix, img.Bounds().Dx(), img.Bounds().Dy(), 24)
-	err = win32.DrawDIImage(dc, imgX, imgY, imgWidth, imgHeight, 0, 0, int32(img.Bounds().Dx()), int32(img.Bounds().Dy()), src)
+	err = p.DrawImage(imgX, imgY, imgWidth, imgHeight, img)
 	if err != nil {
 		log.Printf("DrawDIImage failed: %s", err)
 	}
 
-	oldcol, err := win32.SetTextColor(dc, win32.RGB(200, 20, 80))
+	oldcol, err := p.SetTextColor(color.RGBA{R: 200, G: 20, B: 80, A: 255})
 	if err != nil {
 		log.Printf("SetTextColor failed: %s", err)
 	}
 	log.Printf("Before color was %v", oldcol)
 
-	_, err = win32.SetTextSize(dc, textHeight)
+	_, err = p.SetTextSize(textHeight)
 	if err != nil {
 		log.Printf("SetTextSize failed: %s", err)
 	}
 
 	text := "Hello world"
-	textWidth, _, err := win32.GetTextExtentPoint32(syscall.Handle(dc), text)
+	textWidth, _, err := p.GetTextExtentPoint32(text)
 	if err != nil {
 		log.Printf("Get text dimensions failed: %s", err)
 	}
 	x := layout.CenterElement(width, textWidth)
 	startY := uint32(10)
-	err = win32.TextOut(dc, x, startY, text, uint32(len(text)))
+	err = p.TextOut(x, startY, text)
 	if err != nil {
 		log.Printf("TextOut failed: %s", err)
 	}
 
-	err = win32.EndPage(dc)
+	err = p.EndPage()
 	if err != nil {
 		log.Printf("EndPage failed: %s", err)
 	}
-	err = win32.EndDoc(dc)
+	err = p.EndDoc()
 	if err != nil {
 		log.Printf("EndDoc failed: %s", err)
 	}
-	err = win32.DeleteDC(dc)
+	err = p.Close()
 	if err != nil {
 		log.Printf("DeleteDC failed: %s", err)
 	}
@@ -7,10 +7,7 @@
 package main
 
 import (
-	"log"
-
 	"github.com/sipkg/golang-win32-printer/ticket"
-	"github.com/sipkg/golang-win32-printer/win32"
 )
 
 var t = ticket.Ticket{
@@ -43,56 +40,5 @@ const (
 func main() {
 	// printName := "Microsoft Print to PDF"
 	printName := "PDF"
-	dc, err := win32.CreateDC(printName)
-	if err != nil {
-		log.Fatalf("CreateDC failed: %s", err)
-	}
-	err = win32.StartDCPrinter(dc, "gdiDoc")
-	if err != nil {
-		log.Fatalf("StartDCPrinter failed: %s", err)
-	}
-	err = win32.StartPage(dc)
-	if err != nil {
-		log.Fatalf("StartPage failed: %s", err)
-	}
-
-	win32.SetFont(dc, win32.CourierNew)
-	width, err := win32.GetDeviceCaps(dc, win32.HORZRES)
-	if err != nil {
-		log.Fatalf("Retreiving page width failed: %s", err)
-	}
-	_, err = win32.GetDeviceCaps(dc, win32.VERTRES)
-	if err != nil {
-		log.Fatalf("Retreiving page height failed: %s", err)
-	}
-
-	oldcol, err := win32.SetTextColor(dc, win32.RGB(0, 0, 0))
-	if err != nil {
-		log.Printf("SetTextColor failed: %s", err)
-	}
-	log.Printf("Before color was %v", oldcol)
-
-	_, err = win32.SetTextSize(dc, textHeight)
-	if err != nil {
-		log.Printf("SetTextSize failed: %s", err)
-	}
-
-	headerStopsY := ticket.DrawHeader(dc, width, margin, pdv)
-
-	tabStopsY, totalArticles, aPayer := ticket.DrawArticlesTab(dc, width, margin, headerStopsY, t)
-
-	ticket.DrawFooter(dc, width, margin, tabStopsY, totalArticles, aPayer)
-
-	err = win32.EndPage(dc)
-	if err != nil {
-		log.Printf("EndPage failed: %s", err)
-	}
-	err = win32.EndDoc(dc)
-	if err != nil {
-		log.Printf("EndDoc failed: %s", err)
-	}
-	err = win32.DeleteDC(dc)
-	if err != nil {
-		log.Printf("DeleteDC failed: %s", err)
-	}
+	ticket.PrintA4(printName, margin, textHeight, pdv, t)
 }
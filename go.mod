@@ -5,4 +5,5 @@ go 1.21.4
 require (
 	golang.org/x/image v0.23.0
 	golang.org/x/sys v0.29.0
+	golang.org/x/text v0.21.0
 )
@@ -27,7 +27,7 @@ func (B *BGRImage) At(x, y int) color.Color {
 	}
 	i := B.PixOffset(x, y)
 	s := B.Pix[i : i+3 : i+3] // Small cap improves performance, see https://golang.org/issue/27857
-	return color2.BGR{s[0], s[1], s[2]}
+	return color2.BGR{B: s[0], G: s[1], R: s[2]}
 }
 
 func (p *BGRImage) PixOffset(x, y int) int {
@@ -13,7 +13,7 @@ func (B BGR) RGBA() (r, g, b, a uint32) {
 	g |= g << 8
 	b = uint32(B.B)
 	b |= b << 8
-	a = 0xFF
+	a = 0xFFFF
 	return
 }
 
@@ -24,6 +24,6 @@ var (
 			return BGR{b, g, r}
 		}
 		r, g, b, _ := c.RGBA()
-		return BGR{uint8(b), uint8(g), uint8(r)}
+		return BGR{uint8(b >> 8), uint8(g >> 8), uint8(r >> 8)}
 	})
 )
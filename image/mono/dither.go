@@ -0,0 +1,165 @@
+package mono
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/sipkg/golang-win32-printer/image/bgr"
+)
+
+// Method is the algorithm turning gray levels into black and white dots.
+type Method int
+
+const (
+	// Threshold prints pixels darker than Options.Threshold. Best for text
+	// and line art.
+	Threshold Method = iota
+	// Bayer compares pixels to an 8x8 ordered dither matrix, giving a
+	// regular cross hatch pattern.
+	Bayer
+	// FloydSteinberg diffuses the error of each pixel to its neighbours.
+	FloydSteinberg
+	// Atkinson diffuses only 3/4 of the error, which keeps more contrast
+	// on thermal printers, whose dots bleed.
+	Atkinson
+)
+
+// Options tells how Convert adjusts and dithers an image.
+type Options struct {
+	Method Method
+	// Threshold is the gray level, from 0 to 255, under which a pixel is
+	// black with the Threshold method. Zero means 128.
+	Threshold uint8
+	// Brightness, from -1 to 1, is added to the gray levels.
+	Brightness float64
+	// Contrast, from -1 to 1, stretches (when positive) or squeezes the gray
+	// levels around mid gray.
+	Contrast float64
+}
+
+// Gray returns the 16 bit luminance of c laid over white paper.
+func Gray(c color.Color) uint32 {
+	r, g, b, a := c.RGBA()
+	r += 0xffff - a
+	g += 0xffff - a
+	b += 0xffff - a
+	// Same weights as color.Gray16Model.
+	return (19595*r + 38470*g + 7471*b + 1<<15) >> 16
+}
+
+// Convert returns src converted to black and white. opts may be nil, for a
+// plain threshold at mid gray.
+func Convert(src image.Image, opts *Options) *Image {
+	if opts == nil {
+		opts = &Options{}
+	}
+	b := src.Bounds()
+	dst := New(b)
+	w, h := b.Dx(), b.Dy()
+	levels := grayLevels(src, opts)
+	switch opts.Method {
+	case Bayer:
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				t := (float32(bayer8[y%8][x%8]) + 0.5) * 255 / 64
+				dst.SetBlack(b.Min.X+x, b.Min.Y+y, levels[y*w+x] < t)
+			}
+		}
+	case FloydSteinberg:
+		diffuse(dst, levels, floydSteinberg)
+	case Atkinson:
+		diffuse(dst, levels, atkinson)
+	default:
+		t := float32(opts.Threshold)
+		if t == 0 {
+			t = 128
+		}
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				dst.SetBlack(b.Min.X+x, b.Min.Y+y, levels[y*w+x] < t)
+			}
+		}
+	}
+	return dst
+}
+
+// grayLevels returns the gray levels of src, from 0 to 255, row by row,
+// with brightness and contrast applied.
+func grayLevels(src image.Image, opts *Options) []float32 {
+	b := src.Bounds()
+	w := b.Dx()
+	levels := make([]float32, w*b.Dy())
+	contrast := min(max(opts.Contrast, -1), 0.99)
+	factor := float32((1 + contrast) / (1 - contrast))
+	offset := float32(opts.Brightness * 255)
+	bgrSrc, isBGR := src.(*bgr.BGRImage)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			var v float32
+			if isBGR {
+				i := bgrSrc.PixOffset(x, y)
+				s := bgrSrc.Pix[i : i+3 : i+3]
+				v = (0.114*float32(s[0]) + 0.587*float32(s[1]) + 0.299*float32(s[2]))
+			} else {
+				v = float32(Gray(src.At(x, y))) / 0x101
+			}
+			v = (v-128)*factor + 128 + offset
+			levels[(y-b.Min.Y)*w+x-b.Min.X] = min(max(v, 0), 255)
+		}
+	}
+	return levels
+}
+
+var bayer8 = [8][8]uint8{
+	{0, 32, 8, 40, 2, 34, 10, 42},
+	{48, 16, 56, 24, 50, 18, 58, 26},
+	{12, 44, 4, 36, 14, 46, 6, 38},
+	{60, 28, 52, 20, 62, 30, 54, 22},
+	{3, 35, 11, 43, 1, 33, 9, 41},
+	{51, 19, 59, 27, 49, 17, 57, 25},
+	{15, 47, 7, 39, 13, 45, 5, 37},
+	{63, 31, 55, 23, 61, 29, 53, 21},
+}
+
+// kernel spreads the error of a pixel to the neighbours at dx, dy.
+type kernel []struct {
+	dx, dy int
+	weight float32
+}
+
+var floydSteinberg = kernel{
+	{1, 0, 7.0 / 16},
+	{-1, 1, 3.0 / 16},
+	{0, 1, 5.0 / 16},
+	{1, 1, 1.0 / 16},
+}
+
+var atkinson = kernel{
+	{1, 0, 1.0 / 8},
+	{2, 0, 1.0 / 8},
+	{-1, 1, 1.0 / 8},
+	{0, 1, 1.0 / 8},
+	{1, 1, 1.0 / 8},
+	{0, 2, 1.0 / 8},
+}
+
+func diffuse(dst *Image, levels []float32, k kernel) {
+	b := dst.Rect
+	w, h := b.Dx(), b.Dy()
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := levels[y*w+x]
+			black := v < 128
+			dst.SetBlack(b.Min.X+x, b.Min.Y+y, black)
+			if !black {
+				v -= 255
+			}
+			for _, n := range k {
+				nx, ny := x+n.dx, y+n.dy
+				if nx >= 0 && nx < w && ny < h {
+					levels[ny*w+nx] += v * n.weight
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Package mono provides a packed 1 bit per pixel image, the format of
+// monochrome thermal and laser printers, and dithering to convert other
+// images into it.
+package mono
+
+import (
+	"image"
+	"image/color"
+)
+
+// Palette is the color model of Image: paper white, then ink black.
+var Palette = color.Palette{color.White, color.Black}
+
+// Image is a 1 bit per pixel image. Each row is packed most significant bit
+// first, a set bit meaning a black dot, as ESC/POS, ZPL and PCL expect, and
+// as in a 1 bpp DIB with Palette as color table.
+type Image struct {
+	Pix    []uint8
+	Stride int
+	Rect   image.Rectangle
+}
+
+// New returns a white image of bounds r.
+func New(r image.Rectangle) *Image {
+	stride := (r.Dx() + 7) / 8
+	return &Image{
+		Pix:    make([]uint8, stride*r.Dy()),
+		Stride: stride,
+		Rect:   r,
+	}
+}
+
+func (m *Image) ColorModel() color.Model {
+	return Palette
+}
+
+func (m *Image) Bounds() image.Rectangle {
+	return m.Rect
+}
+
+func (m *Image) At(x, y int) color.Color {
+	if m.Black(x, y) {
+		return color.Black
+	}
+	return color.White
+}
+
+// Black tells whether the pixel at x, y is black. Pixels out of bounds are
+// white.
+func (m *Image) Black(x, y int) bool {
+	if !(image.Point{X: x, Y: y}.In(m.Rect)) {
+		return false
+	}
+	i, bit := m.PixOffset(x, y)
+	return m.Pix[i]&bit != 0
+}
+
+// PixOffset returns the index of the byte holding the pixel at x, y and the
+// mask of its bit.
+func (m *Image) PixOffset(x, y int) (int, uint8) {
+	dx := x - m.Rect.Min.X
+	return (y-m.Rect.Min.Y)*m.Stride + dx/8, 0x80 >> (dx % 8)
+}
+
+// Set sets the pixel at x, y to black when c, laid over white paper, is
+// darker than mid gray.
+func (m *Image) Set(x, y int, c color.Color) {
+	m.SetBlack(x, y, Gray(c) < 0x8000)
+}
+
+// SetBlack sets the pixel at x, y to black or white.
+func (m *Image) SetBlack(x, y int, black bool) {
+	if !(image.Point{X: x, Y: y}.In(m.Rect)) {
+		return
+	}
+	i, bit := m.PixOffset(x, y)
+	if black {
+		m.Pix[i] |= bit
+	} else {
+		m.Pix[i] &^= bit
+	}
+}
+
+// Row returns the packed pixels of row y, nil when out of bounds.
+func (m *Image) Row(y int) []uint8 {
+	if y < m.Rect.Min.Y || y >= m.Rect.Max.Y {
+		return nil
+	}
+	i := (y - m.Rect.Min.Y) * m.Stride
+	return m.Pix[i : i+m.Stride : i+m.Stride]
+}
+
+// SubImage returns a copy of the part of m within r. Unlike the images of
+// the standard library, it does not share pixels with m, so that its rows
+// always start on a byte boundary and end with clear padding bits.
+func (m *Image) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(m.Rect)
+	sub := New(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			sub.SetBlack(x, y, m.Black(x, y))
+		}
+	}
+	return sub
+}
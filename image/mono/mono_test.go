@@ -0,0 +1,108 @@
+package mono
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/sipkg/golang-win32-printer/image/bgr"
+)
+
+func TestImage(t *testing.T) {
+	m := New(image.Rect(2, 1, 12, 3))
+	if m.Stride != 2 || len(m.Pix) != 4 {
+		t.Fatalf("stride %d, %d bytes", m.Stride, len(m.Pix))
+	}
+	m.Set(2, 1, color.Black)
+	m.Set(11, 2, color.Gray{Y: 0x40})
+	m.Set(3, 1, color.NRGBA{A: 0x10})
+	m.Set(20, 20, color.Black)
+	want := []uint8{0x80, 0x00, 0x00, 0x40}
+	for i, b := range m.Pix {
+		if b != want[i] {
+			t.Errorf("Pix = % x, want % x", m.Pix, want)
+			break
+		}
+	}
+	if m.At(11, 2) != color.Black || m.At(3, 1) != color.White {
+		t.Error("At returned the wrong colors")
+	}
+	sub := m.SubImage(image.Rect(5, 2, 12, 3)).(*Image)
+	if sub.Stride != 1 || sub.Pix[0] != 0x02 {
+		t.Errorf("SubImage = %+v", sub)
+	}
+}
+
+// blackRatio returns the proportion of black pixels of m.
+func blackRatio(m *Image) float64 {
+	n := 0
+	b := m.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if m.Black(x, y) {
+				n++
+			}
+		}
+	}
+	return float64(n) / float64(b.Dx()*b.Dy())
+}
+
+func uniform(level uint8) *bgr.BGRImage {
+	img := bgr.NewBGRImage(image.Rect(0, 0, 64, 64))
+	for i := range img.Pix {
+		img.Pix[i] = level
+	}
+	return img
+}
+
+func TestConvert(t *testing.T) {
+	for _, method := range []Method{Bayer, FloydSteinberg, Atkinson} {
+		for _, level := range []uint8{0x40, 0x80, 0xc0} {
+			got := blackRatio(Convert(uniform(level), &Options{Method: method}))
+			want := 1 - float64(level)/255
+			if method == Atkinson {
+				// Atkinson loses a quarter of the error, so mid tones lean
+				// towards their nearest extreme.
+				if d := got - want; d > 0.15 || d < -0.15 {
+					t.Errorf("method %d, level %#x: %.2f black, want about %.2f", method, level, got, want)
+				}
+				continue
+			}
+			if d := got - want; d > 0.03 || d < -0.03 {
+				t.Errorf("method %d, level %#x: %.2f black, want %.2f", method, level, got, want)
+			}
+		}
+	}
+}
+
+func TestConvertThreshold(t *testing.T) {
+	if r := blackRatio(Convert(uniform(0x70), nil)); r != 1 {
+		t.Errorf("dark gray: %.2f black, want 1", r)
+	}
+	if r := blackRatio(Convert(uniform(0x70), &Options{Threshold: 0x60})); r != 0 {
+		t.Errorf("dark gray under a lower threshold: %.2f black, want 0", r)
+	}
+	if r := blackRatio(Convert(uniform(0x70), &Options{Brightness: 0.2})); r != 0 {
+		t.Errorf("brightened dark gray: %.2f black, want 0", r)
+	}
+	if r := blackRatio(Convert(uniform(0x90), &Options{Contrast: -1})); r != 0 {
+		t.Errorf("light gray without contrast: %.2f black, want 0", r)
+	}
+	if r := blackRatio(Convert(uniform(0x7e), &Options{Contrast: 1})); r != 1 {
+		t.Errorf("dark gray with full contrast: %.2f black, want 1", r)
+	}
+
+	// Other image types give the same result as BGRImage.
+	src := uniform(0x60)
+	gray := image.NewGray(src.Rect)
+	for i := range gray.Pix {
+		gray.Pix[i] = 0x60
+	}
+	a := Convert(src, &Options{Method: FloydSteinberg})
+	b := Convert(gray, &Options{Method: FloydSteinberg})
+	for i := range a.Pix {
+		if a.Pix[i] != b.Pix[i] {
+			t.Fatalf("BGRImage and Gray differ at byte %d", i)
+		}
+	}
+}
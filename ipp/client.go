@@ -0,0 +1,319 @@
+package ipp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+
+	"github.com/sipkg/golang-win32-printer/win32"
+)
+
+// Document formats accepted by most IPP printers.
+const (
+	FormatPDF         = "application/pdf"
+	FormatPostScript  = "application/postscript"
+	FormatPWGRaster   = "image/pwg-raster"
+	FormatText        = "text/plain"
+	FormatOctetStream = "application/octet-stream"
+)
+
+// Job states of the job-state attribute.
+const (
+	JobPending           = 3
+	JobPendingHeld       = 4
+	JobProcessing        = 5
+	JobProcessingStopped = 6
+	JobCanceled          = 7
+	JobAborted           = 8
+	JobCompleted         = 9
+)
+
+// Printer states of the printer-state attribute.
+const (
+	PrinterIdle       = 3
+	PrinterProcessing = 4
+	PrinterStopped    = 5
+)
+
+// StatusError is a response whose status is not successful.
+type StatusError struct {
+	Code    uint16
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("ipp: status %#04x: %s", e.Code, e.Message)
+	}
+	return fmt.Sprintf("ipp: status %#04x", e.Code)
+}
+
+// Client sends IPP requests. The zero value is usable.
+type Client struct {
+	// HTTP is the client used for requests, http.DefaultClient when nil.
+	HTTP *http.Client
+	// Username is sent as requesting-user-name when not empty.
+	Username string
+
+	requestID atomic.Uint32
+}
+
+// httpURL returns the HTTP URL of an ipp or ipps URI, on port 631 when it
+// has none.
+func httpURL(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("ipp: %w", err)
+	}
+	switch u.Scheme {
+	case "ipp":
+		u.Scheme = "http"
+	case "ipps":
+		u.Scheme = "https"
+	case "http", "https":
+	default:
+		return "", fmt.Errorf("ipp: unsupported URI scheme %q", u.Scheme)
+	}
+	if u.Port() == "" {
+		u.Host = net.JoinHostPort(u.Hostname(), "631")
+	}
+	return u.String(), nil
+}
+
+// NewRequest returns a request for op on the printer at uri, with the
+// operation attributes identifying the printer and the user.
+func (c *Client) NewRequest(op uint16, uri string) *Message {
+	m := NewRequest(op, c.requestID.Add(1))
+	m.Add(TagOperation, NewAttribute("printer-uri", TagURI, uri))
+	if c.Username != "" {
+		m.Add(TagOperation, NewAttribute("requesting-user-name", TagName, c.Username))
+	}
+	return m
+}
+
+// Do sends req to uri, followed by doc when not nil, and returns the
+// response. A response with an error status is returned along with a
+// *StatusError.
+func (c *Client) Do(uri string, req *Message, doc io.Reader) (*Message, error) {
+	return c.DoContext(context.Background(), uri, req, doc)
+}
+
+// DoContext is Do, the request being abandoned when ctx is done.
+func (c *Client) DoContext(ctx context.Context, uri string, req *Message, doc io.Reader) (*Message, error) {
+	target, err := httpURL(uri)
+	if err != nil {
+		return nil, err
+	}
+	head, err := req.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	var body io.Reader = bytes.NewReader(head)
+	if doc != nil {
+		body = io.MultiReader(body, doc)
+	}
+	hreq, err := http.NewRequestWithContext(ctx, http.MethodPost, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("ipp: %w", err)
+	}
+	hreq.Header.Set("Content-Type", "application/ipp")
+	hc := c.HTTP
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	hresp, err := hc.Do(hreq)
+	if err != nil {
+		return nil, fmt.Errorf("ipp: %w", err)
+	}
+	defer hresp.Body.Close()
+	if hresp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ipp: HTTP status %s", hresp.Status)
+	}
+	resp, err := Decode(hresp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Code >= 0x0100 {
+		return resp, &StatusError{
+			Code:    resp.Code,
+			Message: resp.Group(TagOperation).Get("status-message").String(),
+		}
+	}
+	return resp, nil
+}
+
+// jobRequest returns a Print-Job or Validate-Job request.
+func (c *Client) jobRequest(op uint16, uri, format, jobName string, attrs []Attribute) *Message {
+	req := c.NewRequest(op, uri)
+	if jobName != "" {
+		req.Add(TagOperation, NewAttribute("job-name", TagName, jobName))
+	}
+	if format != "" {
+		req.Add(TagOperation, NewAttribute("document-format", TagMimeType, format))
+	}
+	for _, a := range attrs {
+		req.Add(TagJob, a)
+	}
+	return req
+}
+
+// PrintJob prints doc, in the given format, on the printer at uri and
+// returns the id of the job. attrs are job template attributes, such as
+// copies or media, and may be nil.
+func (c *Client) PrintJob(uri string, doc io.Reader, format, jobName string, attrs []Attribute) (int, error) {
+	resp, err := c.Do(uri, c.jobRequest(OpPrintJob, uri, format, jobName, attrs), doc)
+	if err != nil {
+		return 0, err
+	}
+	return resp.Group(TagJob).Get("job-id").Int(), nil
+}
+
+// ValidateJob asks the printer at uri whether it would accept a job in the
+// given format with attrs.
+func (c *Client) ValidateJob(uri, format string, attrs []Attribute) error {
+	_, err := c.Do(uri, c.jobRequest(OpValidateJob, uri, format, "", attrs), nil)
+	return err
+}
+
+// GetPrinterAttributes returns the attributes of the printer at uri,
+// restricted to the requested ones or their groups, such as "all", when
+// given.
+func (c *Client) GetPrinterAttributes(uri string, requested ...string) (Attributes, error) {
+	return c.GetPrinterAttributesContext(context.Background(), uri, requested...)
+}
+
+// GetPrinterAttributesContext is GetPrinterAttributes, the request being
+// abandoned when ctx is done.
+func (c *Client) GetPrinterAttributesContext(ctx context.Context, uri string, requested ...string) (Attributes, error) {
+	req := c.NewRequest(OpGetPrinterAttributes, uri)
+	if len(requested) > 0 {
+		req.Add(TagOperation, NewAttribute("requested-attributes", TagKeyword, strs(requested)...))
+	}
+	resp, err := c.DoContext(ctx, uri, req, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Group(TagPrinter), nil
+}
+
+func strs(s []string) []any {
+	v := make([]any, len(s))
+	for i := range s {
+		v[i] = s[i]
+	}
+	return v
+}
+
+// Job is a job returned by GetJobs.
+type Job struct {
+	ID           int
+	URI          string
+	Name         string
+	Owner        string
+	State        int
+	StateReasons []string
+	// Pages is the number of impressions printed so far.
+	Pages int
+	// Size is the size of the document in kilobytes.
+	Size int
+}
+
+var jobAttributes = []string{"job-id", "job-uri", "job-name", "job-originating-user-name",
+	"job-state", "job-state-reasons", "job-impressions-completed", "job-k-octets"}
+
+// GetJobs returns the jobs of the printer at uri. which is "not-completed"
+// (the default when empty), "completed" or, with CUPS, "all".
+func (c *Client) GetJobs(uri, which string) ([]Job, error) {
+	req := c.NewRequest(OpGetJobs, uri)
+	if which != "" {
+		req.Add(TagOperation, NewAttribute("which-jobs", TagKeyword, which))
+	}
+	req.Add(TagOperation, NewAttribute("requested-attributes", TagKeyword, strs(jobAttributes)...))
+	resp, err := c.Do(uri, req, nil)
+	if err != nil {
+		return nil, err
+	}
+	var jobs []Job
+	for _, g := range resp.Groups {
+		if g.Tag != TagJob {
+			continue
+		}
+		as := Attributes(g.Attributes)
+		jobs = append(jobs, Job{
+			ID:           as.Get("job-id").Int(),
+			URI:          as.Get("job-uri").String(),
+			Name:         as.Get("job-name").String(),
+			Owner:        as.Get("job-originating-user-name").String(),
+			State:        as.Get("job-state").Int(),
+			StateReasons: as.Get("job-state-reasons").Strings(),
+			Pages:        as.Get("job-impressions-completed").Int(),
+			Size:         as.Get("job-k-octets").Int(),
+		})
+	}
+	return jobs, nil
+}
+
+// CancelJob cancels the job of the printer at uri.
+func (c *Client) CancelJob(uri string, jobID int) error {
+	req := c.NewRequest(OpCancelJob, uri)
+	req.Add(TagOperation, NewAttribute("job-id", TagInteger, int32(jobID)))
+	_, err := c.Do(uri, req, nil)
+	return err
+}
+
+// printerInfo describes a printer the way win32.EnumPrinters does: the
+// name is the URI to print to.
+func printerInfo(serverURI string, as Attributes) win32.PrinterInfo {
+	info := win32.PrinterInfo{
+		PrinterName: as.Get("printer-uri-supported").String(),
+		Attributes:  win32.PRINTER_ATTRIBUTE_NETWORK,
+	}
+	if info.PrinterName == "" {
+		info.PrinterName = serverURI
+	}
+	if u, err := url.Parse(info.PrinterName); err == nil {
+		info.ServerName = u.Hostname()
+	}
+	if shared := as.Get("printer-is-shared"); shared != nil && len(shared.Values) > 0 {
+		if v, _ := shared.Values[0].Value.(bool); v {
+			info.Attributes |= win32.PRINTER_ATTRIBUTE_SHARED
+		}
+	}
+	return info
+}
+
+var printerInfoAttributes = []string{"printer-uri-supported", "printer-name", "printer-is-shared"}
+
+// PrinterInfo returns the description of the printer at uri, as listed by
+// Printer.EnumPrinter.
+func (c *Client) PrinterInfo(uri string) (win32.PrinterInfo, error) {
+	as, err := c.GetPrinterAttributes(uri, printerInfoAttributes...)
+	if err != nil {
+		return win32.PrinterInfo{}, err
+	}
+	return printerInfo(uri, as), nil
+}
+
+// EnumPrinters lists the queues of the CUPS server at serverURI, such as
+// "ipp://printserver", like Printer.EnumPrinter lists the Windows ones. The
+// names returned are the printer URIs to pass to PrintJob.
+func (c *Client) EnumPrinters(serverURI string) ([]win32.PrinterInfo, error) {
+	req := NewRequest(OpCUPSGetPrinters, c.requestID.Add(1))
+	req.Add(TagOperation, NewAttribute("requested-attributes", TagKeyword, strs(printerInfoAttributes)...))
+	resp, err := c.Do(serverURI, req, nil)
+	if err != nil {
+		return nil, err
+	}
+	var infos []win32.PrinterInfo
+	for _, g := range resp.Groups {
+		if g.Tag == TagPrinter {
+			infos = append(infos, printerInfo(serverURI, g.Attributes))
+		}
+	}
+	return infos, nil
+}
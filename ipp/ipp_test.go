@@ -0,0 +1,208 @@
+package ipp
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipkg/golang-win32-printer/win32"
+)
+
+func TestMessageRoundTrip(t *testing.T) {
+	m := NewRequest(OpPrintJob, 7)
+	m.Add(TagOperation, NewAttribute("printer-uri", TagURI, "ipp://printer/ipp/print"))
+	m.Add(TagJob, NewAttribute("copies", TagInteger, int32(2)))
+	m.Add(TagJob, NewAttribute("sides", TagKeyword, "one-sided", "two-sided-long-edge"))
+	m.Add(TagJob, NewAttribute("print-color-mode-default", TagNoValue))
+	m.Add(TagJob, NewAttribute("job-name", TagNameLang, LangString{"fr", "Ticket"}))
+	m.Add(TagJob, NewAttribute("printer-resolution", TagResolution, Resolution{300, 300, 3}))
+	m.Add(TagJob, NewAttribute("page-ranges", TagRange, Range{1, 4}))
+	m.Add(TagJob, NewAttribute("duplex", TagBoolean, true))
+	m.Add(TagJob, NewAttribute("date", TagDateTime, time.Date(2024, 3, 1, 12, 30, 5, 0, time.FixedZone("", 3600))))
+	m.Add(TagJob, NewAttribute("media-col", TagBeginCollection, []Attribute{
+		NewAttribute("media-size", TagBeginCollection, []Attribute{
+			NewAttribute("x-dimension", TagInteger, int32(21000)),
+			NewAttribute("y-dimension", TagInteger, int32(29700)),
+		}),
+		NewAttribute("media-type", TagKeyword, "stationery"),
+	}))
+	b, err := m.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decode(io.MultiReader(bytes.NewReader(b), strings.NewReader("%PDF")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Attributes without values decode with a no-value one.
+	m.Groups[1].Attributes[2].Values = []Value{{Tag: TagNoValue}}
+	if !reflect.DeepEqual(got.Groups, m.Groups) || got.Code != m.Code || got.RequestID != 7 {
+		t.Errorf("decoded %+v\nwant %+v", got, m)
+	}
+	if _, err := Decode(bytes.NewReader(b[:len(b)-1])); err == nil {
+		t.Error("truncated message decoded")
+	}
+}
+
+// server is an IPP printer stand-in answering with the response returned by
+// handle.
+func server(t *testing.T, handle func(req *Message, doc []byte) *Message) string {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/ipp" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		req, err := Decode(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		doc, _ := io.ReadAll(r.Body)
+		resp := handle(req, doc)
+		resp.RequestID = req.RequestID
+		b, _ := resp.MarshalBinary()
+		w.Header().Set("Content-Type", "application/ipp")
+		w.Write(b)
+	}))
+	t.Cleanup(s.Close)
+	return strings.Replace(s.URL, "http://", "ipp://", 1) + "/ipp/print"
+}
+
+func response(status uint16) *Message {
+	return NewRequest(status, 0)
+}
+
+func TestPrintJob(t *testing.T) {
+	var got *Message
+	var gotDoc []byte
+	uri := server(t, func(req *Message, doc []byte) *Message {
+		got, gotDoc = req, doc
+		resp := response(StatusOK)
+		resp.Add(TagJob, NewAttribute("job-id", TagInteger, int32(42)))
+		return resp
+	})
+	c := &Client{Username: "caisse1"}
+	id, err := c.PrintJob(uri, strings.NewReader("%PDF-1.4"), FormatPDF, "ticket",
+		[]Attribute{NewAttribute("copies", TagInteger, int32(2))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 42 {
+		t.Errorf("job id = %d, want 42", id)
+	}
+	if got.Code != OpPrintJob || string(gotDoc) != "%PDF-1.4" {
+		t.Errorf("server received operation %#x with %q", got.Code, gotDoc)
+	}
+	op := got.Group(TagOperation)
+	names := []string{}
+	for _, a := range op {
+		names = append(names, a.Name)
+	}
+	want := []string{"attributes-charset", "attributes-natural-language", "printer-uri",
+		"requesting-user-name", "job-name", "document-format"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("operation attributes %v, want %v", names, want)
+	}
+	if op.Get("printer-uri").String() != uri || op.Get("document-format").String() != FormatPDF {
+		t.Errorf("operation attributes %+v", op)
+	}
+	if got.Group(TagJob).Get("copies").Int() != 2 {
+		t.Errorf("job attributes %+v", got.Group(TagJob))
+	}
+}
+
+func TestErrors(t *testing.T) {
+	uri := server(t, func(req *Message, doc []byte) *Message {
+		resp := response(StatusDocumentFormat)
+		resp.Add(TagOperation, NewAttribute("status-message", TagText, "unsupported format"))
+		return resp
+	})
+	err := (&Client{}).ValidateJob(uri, "application/x-foo", nil)
+	var se *StatusError
+	if !errors.As(err, &se) || se.Code != StatusDocumentFormat || se.Message != "unsupported format" {
+		t.Errorf("ValidateJob error = %v", err)
+	}
+	if err := (&Client{}).CancelJob("lpd://printer", 1); err == nil {
+		t.Error("CancelJob accepted an lpd URI")
+	}
+}
+
+func TestGetJobs(t *testing.T) {
+	var canceled int
+	uri := server(t, func(req *Message, doc []byte) *Message {
+		resp := response(StatusOK)
+		switch req.Code {
+		case OpGetJobs:
+			for _, id := range []int32{1, 2} {
+				resp.Groups = append(resp.Groups, Group{Tag: TagJob, Attributes: []Attribute{
+					NewAttribute("job-id", TagInteger, id),
+					NewAttribute("job-name", TagName, "ticket"),
+					NewAttribute("job-state", TagEnum, int32(JobPendingHeld)),
+					NewAttribute("job-state-reasons", TagKeyword, "job-hold-until-specified", "printer-stopped"),
+					NewAttribute("job-k-octets", TagInteger, int32(12)),
+				}})
+			}
+		case OpCancelJob:
+			canceled = req.Group(TagOperation).Get("job-id").Int()
+		default:
+			return response(StatusOperationNotAllowed)
+		}
+		return resp
+	})
+	c := &Client{}
+	jobs, err := c.GetJobs(uri, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Job{ID: 2, Name: "ticket", State: JobPendingHeld,
+		StateReasons: []string{"job-hold-until-specified", "printer-stopped"}, Size: 12}
+	if len(jobs) != 2 || !reflect.DeepEqual(jobs[1], want) {
+		t.Errorf("jobs = %+v", jobs)
+	}
+	if err := c.CancelJob(uri, 2); err != nil || canceled != 2 {
+		t.Errorf("CancelJob: %v, canceled %d", err, canceled)
+	}
+}
+
+func TestEnumPrinters(t *testing.T) {
+	uri := server(t, func(req *Message, doc []byte) *Message {
+		resp := response(StatusOK)
+		switch req.Code {
+		case OpCUPSGetPrinters:
+			for _, name := range []string{"caisse", "etiquettes"} {
+				resp.Groups = append(resp.Groups, Group{Tag: TagPrinter, Attributes: []Attribute{
+					NewAttribute("printer-uri-supported", TagURI, "ipp://cups.local:631/printers/"+name),
+					NewAttribute("printer-name", TagName, name),
+					NewAttribute("printer-is-shared", TagBoolean, name == "caisse"),
+				}})
+			}
+		case OpGetPrinterAttributes:
+			resp.Add(TagPrinter, NewAttribute("printer-name", TagName, "direct"))
+		}
+		return resp
+	})
+	c := &Client{}
+	infos, err := c.EnumPrinters(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []win32.PrinterInfo{
+		{PrinterName: "ipp://cups.local:631/printers/caisse", ServerName: "cups.local",
+			Attributes: win32.PRINTER_ATTRIBUTE_NETWORK | win32.PRINTER_ATTRIBUTE_SHARED},
+		{PrinterName: "ipp://cups.local:631/printers/etiquettes", ServerName: "cups.local",
+			Attributes: win32.PRINTER_ATTRIBUTE_NETWORK},
+	}
+	if !reflect.DeepEqual(infos, want) {
+		t.Errorf("EnumPrinters = %+v", infos)
+	}
+	info, err := c.PrinterInfo(uri)
+	if err != nil || info.PrinterName != uri || info.ServerName != "127.0.0.1" {
+		t.Errorf("PrinterInfo = %+v, %v", info, err)
+	}
+}
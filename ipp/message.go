@@ -0,0 +1,505 @@
+// Package ipp is an Internet Printing Protocol client, to print on network
+// printers and CUPS queues without a Windows spooler.
+//
+// Message encodes and decodes the binary IPP messages of RFC 8010. Client
+// sends them over HTTP and wraps the operations needed to print: Print-Job,
+// Validate-Job, Get-Printer-Attributes, Get-Jobs and Cancel-Job, plus the
+// CUPS-Get-Printers extension listing the queues of a server.
+package ipp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Tag is a delimiter or value tag.
+type Tag byte
+
+// Delimiter tags starting attribute groups.
+const (
+	TagOperation   Tag = 0x01
+	TagJob         Tag = 0x02
+	TagEnd         Tag = 0x03
+	TagPrinter     Tag = 0x04
+	TagUnsupported Tag = 0x05
+)
+
+// Value tags.
+const (
+	TagUnsupportedValue Tag = 0x10
+	TagUnknown          Tag = 0x12
+	TagNoValue          Tag = 0x13
+	TagInteger          Tag = 0x21
+	TagBoolean          Tag = 0x22
+	TagEnum             Tag = 0x23
+	TagOctetString      Tag = 0x30
+	TagDateTime         Tag = 0x31
+	TagResolution       Tag = 0x32
+	TagRange            Tag = 0x33
+	TagBeginCollection  Tag = 0x34
+	TagTextLang         Tag = 0x35
+	TagNameLang         Tag = 0x36
+	TagEndCollection    Tag = 0x37
+	TagText             Tag = 0x41
+	TagName             Tag = 0x42
+	TagKeyword          Tag = 0x44
+	TagURI              Tag = 0x45
+	TagURIScheme        Tag = 0x46
+	TagCharset          Tag = 0x47
+	TagLanguage         Tag = 0x48
+	TagMimeType         Tag = 0x49
+	TagMemberName       Tag = 0x4a
+)
+
+// Operations.
+const (
+	OpPrintJob             uint16 = 0x0002
+	OpValidateJob          uint16 = 0x0004
+	OpCancelJob            uint16 = 0x0008
+	OpGetJobs              uint16 = 0x000a
+	OpGetPrinterAttributes uint16 = 0x000b
+	OpCUPSGetPrinters      uint16 = 0x4002
+)
+
+// Status codes.
+const (
+	StatusOK                  uint16 = 0x0000
+	StatusOKIgnored           uint16 = 0x0001
+	StatusBadRequest          uint16 = 0x0400
+	StatusOperationNotAllowed uint16 = 0x0401
+	StatusNotFound            uint16 = 0x0406
+	StatusDocumentFormat      uint16 = 0x040a
+	StatusInternalError       uint16 = 0x0500
+)
+
+// Resolution is the value of a resolution attribute.
+type Resolution struct {
+	X, Y int32
+	// Units is 3 for dots per inch, 4 for dots per centimeter.
+	Units byte
+}
+
+// Range is the value of a rangeOfInteger attribute.
+type Range struct {
+	Lower, Upper int32
+}
+
+// LangString is the value of a textWithLanguage or nameWithLanguage
+// attribute.
+type LangString struct {
+	Language, Text string
+}
+
+// Value is one value of an attribute. Its Go type depends on Tag: int32
+// for integers and enums, bool, []byte for octet strings, time.Time,
+// Resolution, Range, LangString, []Attribute for collections, string for
+// the other character string tags and nil for the out of band ones.
+type Value struct {
+	Tag   Tag
+	Value any
+}
+
+// Attribute is a named attribute and its values. An attribute without
+// values is encoded with the no-value tag.
+type Attribute struct {
+	Name   string
+	Values []Value
+}
+
+// NewAttribute returns an attribute whose values all have tag t.
+func NewAttribute(name string, t Tag, values ...any) Attribute {
+	a := Attribute{Name: name}
+	for _, v := range values {
+		a.Values = append(a.Values, Value{Tag: t, Value: v})
+	}
+	return a
+}
+
+// String returns the first value of a string attribute, "" otherwise.
+func (a *Attribute) String() string {
+	if a == nil || len(a.Values) == 0 {
+		return ""
+	}
+	switch v := a.Values[0].Value.(type) {
+	case string:
+		return v
+	case LangString:
+		return v.Text
+	}
+	return ""
+}
+
+// Strings returns the string values of a.
+func (a *Attribute) Strings() []string {
+	if a == nil {
+		return nil
+	}
+	var s []string
+	for _, v := range a.Values {
+		switch v := v.Value.(type) {
+		case string:
+			s = append(s, v)
+		case LangString:
+			s = append(s, v.Text)
+		}
+	}
+	return s
+}
+
+// Int returns the first value of an integer or enum attribute, 0 otherwise.
+func (a *Attribute) Int() int {
+	if a == nil || len(a.Values) == 0 {
+		return 0
+	}
+	if v, ok := a.Values[0].Value.(int32); ok {
+		return int(v)
+	}
+	return 0
+}
+
+// Group is an attribute group, such as the operation or job attributes.
+type Group struct {
+	Tag        Tag
+	Attributes []Attribute
+}
+
+// Message is an IPP request or response.
+type Message struct {
+	// Version is the major and minor version, 1.1 when zero.
+	Version [2]byte
+	// Code is the operation of a request, the status of a response.
+	Code      uint16
+	RequestID uint32
+	Groups    []Group
+}
+
+// NewRequest returns a request for op with the operation attributes every
+// request starts with.
+func NewRequest(op uint16, requestID uint32) *Message {
+	return &Message{
+		Version:   [2]byte{1, 1},
+		Code:      op,
+		RequestID: requestID,
+		Groups: []Group{{Tag: TagOperation, Attributes: []Attribute{
+			NewAttribute("attributes-charset", TagCharset, "utf-8"),
+			NewAttribute("attributes-natural-language", TagLanguage, "en"),
+		}}},
+	}
+}
+
+// Add appends a to the last group of tag g, adding the group when missing.
+func (m *Message) Add(g Tag, a Attribute) {
+	for i := len(m.Groups) - 1; i >= 0; i-- {
+		if m.Groups[i].Tag == g {
+			m.Groups[i].Attributes = append(m.Groups[i].Attributes, a)
+			return
+		}
+	}
+	m.Groups = append(m.Groups, Group{Tag: g, Attributes: []Attribute{a}})
+}
+
+// Group returns the attributes of the first group of tag g.
+func (m *Message) Group(g Tag) Attributes {
+	for _, group := range m.Groups {
+		if group.Tag == g {
+			return group.Attributes
+		}
+	}
+	return nil
+}
+
+// Attributes is a list of attributes.
+type Attributes []Attribute
+
+// Get returns the attribute called name, nil when missing.
+func (as Attributes) Get(name string) *Attribute {
+	for i := range as {
+		if as[i].Name == name {
+			return &as[i]
+		}
+	}
+	return nil
+}
+
+// MarshalBinary encodes m.
+func (m *Message) MarshalBinary() ([]byte, error) {
+	e := encoder{}
+	version := m.Version
+	if version == [2]byte{} {
+		version = [2]byte{1, 1}
+	}
+	e.b = append(e.b, version[0], version[1])
+	e.b = binary.BigEndian.AppendUint16(e.b, m.Code)
+	e.b = binary.BigEndian.AppendUint32(e.b, m.RequestID)
+	for _, g := range m.Groups {
+		e.b = append(e.b, byte(g.Tag))
+		for _, a := range g.Attributes {
+			e.attribute(a)
+		}
+	}
+	e.b = append(e.b, byte(TagEnd))
+	return e.b, e.err
+}
+
+type encoder struct {
+	b   []byte
+	err error
+}
+
+func (e *encoder) field(s string) {
+	if len(s) > 0xffff && e.err == nil {
+		e.err = errors.New("ipp: value too long")
+	}
+	e.b = binary.BigEndian.AppendUint16(e.b, uint16(len(s)))
+	e.b = append(e.b, s...)
+}
+
+func (e *encoder) attribute(a Attribute) {
+	if len(a.Values) == 0 {
+		e.b = append(e.b, byte(TagNoValue))
+		e.field(a.Name)
+		e.field("")
+		return
+	}
+	for i, v := range a.Values {
+		name := ""
+		if i == 0 {
+			name = a.Name
+		}
+		e.value(name, v)
+	}
+}
+
+func (e *encoder) value(name string, v Value) {
+	e.b = append(e.b, byte(v.Tag))
+	e.field(name)
+	be := binary.BigEndian
+	var b []byte
+	switch x := v.Value.(type) {
+	case nil:
+	case int32:
+		b = be.AppendUint32(b, uint32(x))
+	case int:
+		b = be.AppendUint32(b, uint32(int32(x)))
+	case bool:
+		b = append(b, 0)
+		if x {
+			b[0] = 1
+		}
+	case string:
+		b = []byte(x)
+	case []byte:
+		b = x
+	case time.Time:
+		_, offset := x.Zone()
+		dir := byte('+')
+		if offset < 0 {
+			dir, offset = '-', -offset
+		}
+		b = be.AppendUint16(b, uint16(x.Year()))
+		b = append(b, byte(x.Month()), byte(x.Day()), byte(x.Hour()), byte(x.Minute()),
+			byte(x.Second()), byte(x.Nanosecond()/1e8), dir, byte(offset/3600), byte(offset%3600/60))
+	case Resolution:
+		b = be.AppendUint32(b, uint32(x.X))
+		b = be.AppendUint32(b, uint32(x.Y))
+		b = append(b, x.Units)
+	case Range:
+		b = be.AppendUint32(b, uint32(x.Lower))
+		b = be.AppendUint32(b, uint32(x.Upper))
+	case LangString:
+		b = be.AppendUint16(b, uint16(len(x.Language)))
+		b = append(b, x.Language...)
+		b = be.AppendUint16(b, uint16(len(x.Text)))
+		b = append(b, x.Text...)
+	case []Attribute:
+		e.field("")
+		for _, member := range x {
+			e.b = append(e.b, byte(TagMemberName))
+			e.field("")
+			e.field(member.Name)
+			for _, mv := range member.Values {
+				e.value("", mv)
+			}
+		}
+		e.b = append(e.b, byte(TagEndCollection))
+		e.field("")
+		e.field("")
+		return
+	default:
+		if e.err == nil {
+			e.err = fmt.Errorf("ipp: attribute %q: unsupported value type %T", name, v.Value)
+		}
+	}
+	e.field(string(b))
+}
+
+var errMalformed = errors.New("ipp: malformed message")
+
+// Decode reads a message from r. It reads no further than the end of the
+// attributes, leaving r at the start of the document data of a request.
+func Decode(r io.Reader) (*Message, error) {
+	d := decoder{r: r}
+	var head [8]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return nil, fmt.Errorf("ipp: reading header: %w", err)
+	}
+	m := &Message{
+		Version:   [2]byte{head[0], head[1]},
+		Code:      binary.BigEndian.Uint16(head[2:]),
+		RequestID: binary.BigEndian.Uint32(head[4:]),
+	}
+	var group *Group
+	var last *Attribute
+	for {
+		tag, err := d.byte()
+		if err != nil {
+			return nil, fmt.Errorf("ipp: reading attributes: %w", err)
+		}
+		if tag == byte(TagEnd) {
+			return m, nil
+		}
+		if tag < 0x10 {
+			m.Groups = append(m.Groups, Group{Tag: Tag(tag)})
+			group, last = &m.Groups[len(m.Groups)-1], nil
+			continue
+		}
+		if group == nil {
+			return nil, errMalformed
+		}
+		name, v, err := d.value(Tag(tag))
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			if last == nil {
+				return nil, errMalformed
+			}
+			last.Values = append(last.Values, v)
+			continue
+		}
+		group.Attributes = append(group.Attributes, Attribute{Name: name, Values: []Value{v}})
+		last = &group.Attributes[len(group.Attributes)-1]
+	}
+}
+
+type decoder struct {
+	r   io.Reader
+	buf [1]byte
+}
+
+func (d *decoder) byte() (byte, error) {
+	_, err := io.ReadFull(d.r, d.buf[:])
+	return d.buf[0], err
+}
+
+func (d *decoder) field() ([]byte, error) {
+	var n [2]byte
+	if _, err := io.ReadFull(d.r, n[:]); err != nil {
+		return nil, errMalformed
+	}
+	b := make([]byte, binary.BigEndian.Uint16(n[:]))
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		return nil, errMalformed
+	}
+	return b, nil
+}
+
+// value reads the name and value following tag t.
+func (d *decoder) value(t Tag) (string, Value, error) {
+	name, err := d.field()
+	if err != nil {
+		return "", Value{}, err
+	}
+	b, err := d.field()
+	if err != nil {
+		return "", Value{}, err
+	}
+	v := Value{Tag: t}
+	be := binary.BigEndian
+	size := func(n int) error {
+		if len(b) != n {
+			return fmt.Errorf("ipp: attribute %q: %d bytes value, want %d", name, len(b), n)
+		}
+		return nil
+	}
+	switch {
+	case t == TagInteger || t == TagEnum:
+		err = size(4)
+		if err == nil {
+			v.Value = int32(be.Uint32(b))
+		}
+	case t == TagBoolean:
+		err = size(1)
+		if err == nil {
+			v.Value = b[0] != 0
+		}
+	case t == TagDateTime:
+		err = size(11)
+		if err == nil {
+			offset := (int(b[9])*3600 + int(b[10])*60)
+			if b[8] == '-' {
+				offset = -offset
+			}
+			v.Value = time.Date(int(be.Uint16(b)), time.Month(b[2]), int(b[3]), int(b[4]), int(b[5]),
+				int(b[6]), int(b[7])*1e8, time.FixedZone("", offset))
+		}
+	case t == TagResolution:
+		err = size(9)
+		if err == nil {
+			v.Value = Resolution{int32(be.Uint32(b)), int32(be.Uint32(b[4:])), b[8]}
+		}
+	case t == TagRange:
+		err = size(8)
+		if err == nil {
+			v.Value = Range{int32(be.Uint32(b)), int32(be.Uint32(b[4:]))}
+		}
+	case t == TagTextLang || t == TagNameLang:
+		if len(b) < 4 {
+			return "", v, errMalformed
+		}
+		n := int(be.Uint16(b))
+		if len(b) < 4+n || len(b) != 4+n+int(be.Uint16(b[2+n:])) {
+			return "", v, errMalformed
+		}
+		v.Value = LangString{string(b[2 : 2+n]), string(b[4+n:])}
+	case t == TagBeginCollection:
+		v.Value, err = d.collection()
+	case t == TagOctetString:
+		v.Value = b
+	case t < 0x20:
+		// Out of band values.
+	default:
+		v.Value = string(b)
+	}
+	return string(name), v, err
+}
+
+// collection reads the members of a collection up to its end.
+func (d *decoder) collection() ([]Attribute, error) {
+	var members []Attribute
+	for {
+		tag, err := d.byte()
+		if err != nil {
+			return nil, errMalformed
+		}
+		_, v, err := d.value(Tag(tag))
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case Tag(tag) == TagEndCollection:
+			return members, nil
+		case Tag(tag) == TagMemberName:
+			name, _ := v.Value.(string)
+			members = append(members, Attribute{Name: name})
+		case len(members) == 0:
+			return nil, errMalformed
+		default:
+			m := &members[len(members)-1]
+			m.Values = append(m.Values, v)
+		}
+	}
+}
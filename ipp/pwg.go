@@ -0,0 +1,140 @@
+package ipp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+
+	"github.com/sipkg/golang-win32-printer/image/bgr"
+)
+
+// Offsets of the fields of a PWG raster page header used by WritePWGRaster.
+const (
+	pwgHWResolution  = 276
+	pwgPageSize      = 352
+	pwgWidth         = 372
+	pwgHeight        = 376
+	pwgBitsPerColor  = 384
+	pwgBitsPerPixel  = 388
+	pwgBytesPerLine  = 392
+	pwgColorSpace    = 400
+	pwgNumColors     = 420
+	pwgTotalPages    = 452
+	pwgCrossFeed     = 456
+	pwgFeed          = 460
+	pwgHeaderSize    = 1796
+	pwgColorSpaceRGB = 19
+)
+
+// WritePWGRaster writes pages as an 8 bit sRGB PWG raster document
+// (FormatPWGRaster) at dpi dots per inch, the format of IPP Everywhere
+// printers. Pages rendered by the raster backend are written without
+// conversion.
+func WritePWGRaster(w io.Writer, pages []image.Image, dpi int) error {
+	if len(pages) == 0 {
+		return errors.New("ipp: no pages")
+	}
+	if dpi <= 0 {
+		return fmt.Errorf("ipp: invalid resolution %d dpi", dpi)
+	}
+	if _, err := io.WriteString(w, "RaS2"); err != nil {
+		return err
+	}
+	for _, page := range pages {
+		var buf bytes.Buffer
+		b := page.Bounds()
+		buf.Write(pwgHeader(b.Dx(), b.Dy(), dpi, len(pages)))
+		src, ok := page.(*bgr.BGRImage)
+		if !ok {
+			src = bgr.NewBGRImage(b)
+			for y := b.Min.Y; y < b.Max.Y; y++ {
+				for x := b.Min.X; x < b.Max.X; x++ {
+					src.Set(x, y, page.At(x, y))
+				}
+			}
+		}
+		rgb := make([]byte, 3*b.Dx())
+		var prev []byte
+		repeat := 0
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			i := src.PixOffset(b.Min.X, y)
+			row := src.Pix[i : i+3*b.Dx()]
+			for x := 0; x < len(row); x += 3 {
+				rgb[x], rgb[x+1], rgb[x+2] = row[x+2], row[x+1], row[x]
+			}
+			if prev != nil && bytes.Equal(rgb, prev) && repeat < 255 {
+				repeat++
+				continue
+			}
+			if prev != nil {
+				packLine(&buf, prev, repeat)
+			}
+			prev, repeat = append(prev[:0], rgb...), 0
+		}
+		if prev != nil {
+			packLine(&buf, prev, repeat)
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func pwgHeader(width, height, dpi, pages int) []byte {
+	h := make([]byte, pwgHeaderSize)
+	copy(h, "PwgRaster")
+	put := func(offset, v int) {
+		binary.BigEndian.PutUint32(h[offset:], uint32(v))
+	}
+	put(pwgHWResolution, dpi)
+	put(pwgHWResolution+4, dpi)
+	put(pwgPageSize, width*72/dpi)
+	put(pwgPageSize+4, height*72/dpi)
+	put(pwgWidth, width)
+	put(pwgHeight, height)
+	put(pwgBitsPerColor, 8)
+	put(pwgBitsPerPixel, 24)
+	put(pwgBytesPerLine, 3*width)
+	put(pwgColorSpace, pwgColorSpaceRGB)
+	put(pwgNumColors, 3)
+	put(pwgTotalPages, pages)
+	put(pwgCrossFeed, 1)
+	put(pwgFeed, 1)
+	return h
+}
+
+// packLine writes a line repeated repeat more times, its pixels compressed
+// in runs: a count c below 128 repeats the next pixel c+1 times, a count
+// 257-n is followed by n different pixels.
+func packLine(buf *bytes.Buffer, line []byte, repeat int) {
+	buf.WriteByte(byte(repeat))
+	n := len(line) / 3
+	pixel := func(i int) []byte { return line[3*i : 3*i+3] }
+	for i := 0; i < n; {
+		run := 1
+		for i+run < n && run < 128 && bytes.Equal(pixel(i), pixel(i+run)) {
+			run++
+		}
+		if run > 1 {
+			buf.WriteByte(byte(run - 1))
+			buf.Write(pixel(i))
+			i += run
+			continue
+		}
+		lit := 1
+		for i+lit < n && lit < 127 && (i+lit+1 >= n || !bytes.Equal(pixel(i+lit), pixel(i+lit+1))) {
+			lit++
+		}
+		if lit == 1 {
+			buf.WriteByte(0)
+		} else {
+			buf.WriteByte(byte(257 - lit))
+		}
+		buf.Write(line[3*i : 3*(i+lit)])
+		i += lit
+	}
+}
@@ -0,0 +1,101 @@
+package ipp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/sipkg/golang-win32-printer/image/bgr"
+)
+
+// unpackPage decodes the page starting at b, returning its RGB pixels and
+// the rest of b.
+func unpackPage(t *testing.T, b []byte) (width, height int, pix, rest []byte) {
+	t.Helper()
+	h := b[:pwgHeaderSize]
+	width = int(binary.BigEndian.Uint32(h[pwgWidth:]))
+	height = int(binary.BigEndian.Uint32(h[pwgHeight:]))
+	b = b[pwgHeaderSize:]
+	for len(pix) < 3*width*height {
+		repeat := int(b[0]) + 1
+		b = b[1:]
+		var line []byte
+		for len(line) < 3*width {
+			c := int(b[0])
+			b = b[1:]
+			if c < 128 {
+				for i := 0; i <= c; i++ {
+					line = append(line, b[:3]...)
+				}
+				b = b[3:]
+			} else {
+				n := 3 * (257 - c)
+				line = append(line, b[:n]...)
+				b = b[n:]
+			}
+		}
+		for i := 0; i < repeat; i++ {
+			pix = append(pix, line...)
+		}
+	}
+	return width, height, pix, b
+}
+
+func TestWritePWGRaster(t *testing.T) {
+	page1 := bgr.NewBGRImage(image.Rect(0, 0, 300, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 300; x++ {
+			c := color.RGBA{0xff, 0xff, 0xff, 0xff}
+			if y == 3 {
+				c = color.RGBA{uint8(x), uint8(x / 2), 0x10, 0xff}
+			}
+			page1.Set(x, y, c)
+		}
+	}
+	page2 := image.NewGray(image.Rect(0, 0, 2, 2))
+	page2.Pix = []uint8{0, 0x40, 0x80, 0xff}
+	var buf bytes.Buffer
+	if err := WritePWGRaster(&buf, []image.Image{page1, page2}, 300); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	if string(b[:4]) != "RaS2" || string(b[4:13]) != "PwgRaster" {
+		t.Fatalf("bad document start %q", b[:13])
+	}
+	if size := binary.BigEndian.Uint32(b[4+pwgPageSize:]); size != 72 {
+		t.Errorf("page width %d points, want 72", size)
+	}
+	w, h, pix, rest := unpackPage(t, b[4:])
+	if w != 300 || h != 4 {
+		t.Fatalf("page 1 is %dx%d", w, h)
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, bl, _ := page1.At(x, y).RGBA()
+			i := 3 * (y*w + x)
+			if pix[i] != uint8(r>>8) || pix[i+1] != uint8(g>>8) || pix[i+2] != uint8(bl>>8) {
+				t.Fatalf("page 1 pixel %d,%d = % x", x, y, pix[i:i+3])
+			}
+		}
+	}
+	_, _, pix, rest = unpackPage(t, rest)
+	want := []byte{0, 0, 0, 0x40, 0x40, 0x40, 0x80, 0x80, 0x80, 0xff, 0xff, 0xff}
+	if !bytes.Equal(pix, want) || len(rest) != 0 {
+		t.Errorf("page 2 = % x, %d bytes left", pix, len(rest))
+	}
+}
+
+func TestWritePWGRasterResolution(t *testing.T) {
+	page := image.NewGray(image.Rect(0, 0, 2, 2))
+	for _, dpi := range []int{0, -300} {
+		var buf bytes.Buffer
+		if err := WritePWGRaster(&buf, []image.Image{page}, dpi); err == nil {
+			t.Errorf("WritePWGRaster at %d dpi succeeded", dpi)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("%d bytes written at %d dpi", buf.Len(), dpi)
+		}
+	}
+}
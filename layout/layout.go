@@ -36,9 +36,12 @@ func CenterElementVerticallyFrom(startY, containerHeight, elementHeight uint32)
 	return startY + (containerHeight-elementHeight)/2
 }
 
+// TruncateString shortens str to maxLength characters, the last one being a
+// dot when it was cut.
 func TruncateString(str string, maxLength int) string {
-	if len(str) > maxLength {
-		return str[:maxLength-1] + "."
+	runes := []rune(str)
+	if len(runes) > maxLength {
+		return string(runes[:maxLength-1]) + "."
 	}
 	return str
 }
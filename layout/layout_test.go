@@ -0,0 +1,36 @@
+package layout
+
+import "testing"
+
+func TestAlign(t *testing.T) {
+	if got := CenterElement(100, 40); got != 30 {
+		t.Errorf("CenterElement() = %d, want 30", got)
+	}
+	if got := AlignRight(100, 40); got != 60 {
+		t.Errorf("AlignRight() = %d, want 60", got)
+	}
+	if got := CenterElementFrom(10, 100, 40); got != 40 {
+		t.Errorf("CenterElementFrom() = %d, want 40", got)
+	}
+	if got := AlignBottomFrom(10, 100, 40); got != 70 {
+		t.Errorf("AlignBottomFrom() = %d, want 70", got)
+	}
+}
+
+func TestTruncateString(t *testing.T) {
+	tests := []struct {
+		str  string
+		max  int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"exactly 10", 10, "exactly 10"},
+		{"much too long", 10, "much too ."},
+		{"très très long", 8, "très tr."},
+	}
+	for _, tt := range tests {
+		if got := TruncateString(tt.str, tt.max); got != tt.want {
+			t.Errorf("TruncateString(%q, %d) = %q, want %q", tt.str, tt.max, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+// Package lpr submits print jobs to line printer daemons with the LPD
+// protocol of RFC 1179, spoken by old print servers and many network
+// printers on port 515.
+package lpr
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Port is the TCP port of line printer daemons.
+const Port = "515"
+
+// FileType tells the daemon how to print a data file.
+type FileType byte
+
+const (
+	// Raw prints the file as is, control characters included, as needed by
+	// ESC/POS, ZPL, PCL or PostScript data.
+	Raw FileType = 'l'
+	// Text prints the file as plain text, formatted by the daemon.
+	Text FileType = 'f'
+)
+
+// Job describes a print job.
+type Job struct {
+	// Name is the job name, shown in queue listings.
+	Name string
+	// User is the user submitting the job, "lpr" when empty.
+	User string
+	// Host is the name of the submitting host, os.Hostname when empty.
+	Host string
+	// Copies is the number of copies, 1 when zero.
+	Copies int
+	// Type is the file type, Raw when zero.
+	Type FileType
+}
+
+// Client submits jobs to a line printer daemon.
+type Client struct {
+	// Addr is the address of the daemon, "host" or "host:port", on Port
+	// when no port is given.
+	Addr string
+	// Timeout bounds the whole submission of a job. Zero means 30
+	// seconds.
+	Timeout time.Duration
+	// Dialer opens the connections, one with Timeout when nil. Daemons
+	// enforcing RFC 1179 need a LocalAddr with a source port from 721 to
+	// 731, which requires privileges.
+	Dialer *net.Dialer
+}
+
+var jobNumber atomic.Uint32
+
+func init() {
+	jobNumber.Store(uint32(time.Now().UnixNano() % 1000))
+}
+
+var errNotAcknowledged = errors.New("lpr: command not acknowledged")
+
+// Print sends data to queue as one job.
+func (c *Client) Print(queue string, data []byte, job *Job) error {
+	if job == nil {
+		job = &Job{}
+	}
+	if queue == "" || strings.ContainsAny(queue, " \n") {
+		return fmt.Errorf("lpr: invalid queue name %q", queue)
+	}
+	addr := c.Addr
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, Port)
+	}
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	dialer := c.Dialer
+	if dialer == nil {
+		dialer = &net.Dialer{Timeout: timeout}
+	}
+	conn, err := dialer.Dial("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("lpr: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	host := job.Host
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+	host = clean(host, "localhost")
+	num := fmt.Sprintf("%03d", jobNumber.Add(1)%1000)
+	dataName := "dfA" + num + host
+	s := session{conn: conn, r: bufio.NewReader(conn)}
+
+	// Receive a printer job.
+	s.command("\x02" + queue + "\n")
+	s.file('\x02', "cfA"+num+host, controlFile(job, host, dataName))
+	s.file('\x03', dataName, data)
+	if s.err != nil {
+		return fmt.Errorf("lpr: printing on %s: %w", queue, s.err)
+	}
+	return nil
+}
+
+// controlFile returns the control file of job, printing the data file
+// dataName.
+func controlFile(job *Job, host, dataName string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "H%s\n", host)
+	fmt.Fprintf(&b, "P%s\n", clean(job.User, "lpr"))
+	if job.Name != "" {
+		fmt.Fprintf(&b, "J%s\n", clean(job.Name, ""))
+		fmt.Fprintf(&b, "N%s\n", clean(job.Name, ""))
+	}
+	typ := job.Type
+	if typ == 0 {
+		typ = Raw
+	}
+	for i := 0; i < max(job.Copies, 1); i++ {
+		fmt.Fprintf(&b, "%c%s\n", typ, dataName)
+	}
+	fmt.Fprintf(&b, "U%s\n", dataName)
+	return []byte(b.String())
+}
+
+// clean returns s without the characters ending control file lines,
+// truncated to the 31 characters RFC 1179 allows, or def when empty.
+func clean(s, def string) string {
+	s = strings.Map(func(r rune) rune {
+		if r < ' ' {
+			return -1
+		}
+		return r
+	}, s)
+	if len(s) > 31 {
+		s = s[:31]
+	}
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+// session sends the commands of a job, stopping at the first error.
+type session struct {
+	conn net.Conn
+	r    *bufio.Reader
+	err  error
+}
+
+// command sends cmd and waits for its acknowledgement.
+func (s *session) command(cmd string) {
+	s.send([]byte(cmd))
+	s.ack()
+}
+
+// file sends a control or data file with subcommand sub.
+func (s *session) file(sub byte, name string, data []byte) {
+	s.command(fmt.Sprintf("%c%d %s\n", sub, len(data), name))
+	s.send(data)
+	s.send([]byte{0})
+	s.ack()
+}
+
+func (s *session) send(b []byte) {
+	if s.err != nil {
+		return
+	}
+	_, s.err = s.conn.Write(b)
+}
+
+func (s *session) ack() {
+	if s.err != nil {
+		return
+	}
+	b, err := s.r.ReadByte()
+	switch {
+	case err == io.EOF:
+		s.err = errNotAcknowledged
+	case err != nil:
+		s.err = err
+	case b != 0:
+		s.err = errNotAcknowledged
+	}
+}
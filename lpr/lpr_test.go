@@ -0,0 +1,127 @@
+package lpr
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// received is what a daemon stand-in got from a client.
+type received struct {
+	queue string
+	files map[string][]byte
+	err   error
+}
+
+// daemon accepts one job, refusing its command number refuse (from 1) when
+// not zero.
+func daemon(t *testing.T, refuse int) (string, <-chan received) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	ch := make(chan received, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		rec := received{files: map[string][]byte{}}
+		defer func() { ch <- rec }()
+		n := 0
+		ack := func() bool {
+			n++
+			if n == refuse {
+				conn.Write([]byte{1})
+				return false
+			}
+			conn.Write([]byte{0})
+			return true
+		}
+		line, err := r.ReadString('\n')
+		if err != nil || line[0] != 2 {
+			rec.err = fmt.Errorf("bad command %q", line)
+			return
+		}
+		rec.queue = strings.TrimSuffix(line[1:], "\n")
+		if !ack() {
+			return
+		}
+		for {
+			line, err := r.ReadString('\n')
+			if err == io.EOF {
+				return
+			}
+			fields := strings.Fields(line[1:])
+			size, _ := strconv.Atoi(fields[0])
+			if !ack() {
+				return
+			}
+			data := make([]byte, size+1)
+			if _, err := io.ReadFull(r, data); err != nil || data[size] != 0 {
+				rec.err = fmt.Errorf("bad file %s", fields[1])
+				return
+			}
+			rec.files[fields[1]] = data[:size]
+			if !ack() {
+				return
+			}
+		}
+	}()
+	return l.Addr().String(), ch
+}
+
+func TestPrint(t *testing.T) {
+	addr, ch := daemon(t, 0)
+	c := &Client{Addr: addr}
+	job := &Job{Name: "ticket\n42", User: "caisse", Host: "pos1", Copies: 2}
+	if err := c.Print("raw", []byte("\x1b@hello\n"), job); err != nil {
+		t.Fatal(err)
+	}
+	rec := <-ch
+	if rec.err != nil {
+		t.Fatal(rec.err)
+	}
+	if rec.queue != "raw" || len(rec.files) != 2 {
+		t.Fatalf("daemon received queue %q, %d files", rec.queue, len(rec.files))
+	}
+	var control, data string
+	for name, b := range rec.files {
+		switch {
+		case strings.HasPrefix(name, "cfA") && strings.HasSuffix(name, "pos1"):
+			control = string(b)
+		case strings.HasPrefix(name, "dfA") && strings.HasSuffix(name, "pos1"):
+			data = name
+			if string(b) != "\x1b@hello\n" {
+				t.Errorf("data file %q", b)
+			}
+		default:
+			t.Errorf("unexpected file %s", name)
+		}
+	}
+	want := "Hpos1\nPcaisse\nJticket42\nNticket42\nl" + data + "\nl" + data + "\nU" + data + "\n"
+	if control != want {
+		t.Errorf("control file %q, want %q", control, want)
+	}
+}
+
+func TestPrintRefused(t *testing.T) {
+	for refuse := 1; refuse <= 5; refuse++ {
+		addr, ch := daemon(t, refuse)
+		c := &Client{Addr: addr}
+		if err := c.Print("lp", []byte("x"), &Job{Type: Text}); err == nil {
+			t.Errorf("job accepted although command %d was refused", refuse)
+		}
+		<-ch
+	}
+	if err := (&Client{Addr: "127.0.0.1:1"}).Print("bad queue", nil, nil); err == nil {
+		t.Error("invalid queue name accepted")
+	}
+}
@@ -0,0 +1,109 @@
+package printer
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"unicode/utf16"
+
+	"github.com/sipkg/golang-win32-printer/device"
+	"github.com/sipkg/golang-win32-printer/image/bgr"
+	"github.com/sipkg/golang-win32-printer/win32"
+)
+
+var _ device.Device = (*Printer)(nil)
+
+var errNotInit = errors.New("must call InitPrinter before")
+
+func (p *Printer) TextOut(x, y uint32, text string) error {
+	if !p._init {
+		return errNotInit
+	}
+	// TextOutW counts UTF-16 code units, not bytes.
+	return win32.TextOut(p.hdc, x, y, text, uint32(len(utf16.Encode([]rune(text)))))
+}
+
+func (p *Printer) GetTextExtentPoint32(text string) (uint32, uint32, error) {
+	if !p._init {
+		return 0, 0, errNotInit
+	}
+	return win32.GetTextExtentPoint32(p.hdc, text)
+}
+
+func (p *Printer) MoveTo(x, y uint32) error {
+	if !p._init {
+		return errNotInit
+	}
+	_, err := win32.MoveTo(p.hdc, x, y)
+	return err
+}
+
+func (p *Printer) LineTo(x, y uint32) error {
+	if !p._init {
+		return errNotInit
+	}
+	return win32.LineTo(p.hdc, x, y)
+}
+
+// DrawImage converts img to a 24 bits DIB and stretches it onto the page.
+func (p *Printer) DrawImage(x, y, width, height uint32, img image.Image) error {
+	if !p._init {
+		return errNotInit
+	}
+	b := img.Bounds()
+	src, ok := img.(*bgr.BGRImage)
+	if !ok {
+		src = bgr.NewBGRImage(image.Rect(0, 0, b.Dx(), b.Dy()))
+		draw.Draw(src, src.Rect, img, b.Min, draw.Src)
+	}
+	bits := bgr.ReverseDIB(src.Pix, b.Dx(), b.Dy(), 24)
+	return win32.DrawDIImage(p.hdc, x, y, width, height, 0, 0, int32(b.Dx()), int32(b.Dy()), bits)
+}
+
+func (p *Printer) SetFont(fontName string) error {
+	if !p._init {
+		return errNotInit
+	}
+	return win32.SetFont(p.hdc, fontName)
+}
+
+func (p *Printer) SetTextSize(size int32) (int32, error) {
+	if !p._init {
+		return 0, errNotInit
+	}
+	return win32.SetTextSize(p.hdc, size)
+}
+
+func (p *Printer) SetBoldFont(bold bool) error {
+	if !p._init {
+		return errNotInit
+	}
+	return win32.SetBoldFont(p.hdc, bold)
+}
+
+func (p *Printer) SetItalicFont(italic bool) error {
+	if !p._init {
+		return errNotInit
+	}
+	return win32.SetItalicFont(p.hdc, italic)
+}
+
+func (p *Printer) SetTextColor(c color.Color) (color.Color, error) {
+	if !p._init {
+		return nil, errNotInit
+	}
+	r, g, b, _ := c.RGBA()
+	old, err := win32.SetTextColor(p.hdc, win32.RGB(byte(r>>8), byte(g>>8), byte(b>>8)))
+	if err != nil {
+		return nil, err
+	}
+	return color.RGBA{R: byte(old), G: byte(old >> 8), B: byte(old >> 16), A: 0xFF}, nil
+}
+
+func (p *Printer) GetDeviceCaps(index device.Cap) (uint32, error) {
+	if !p._init {
+		return 0, errNotInit
+	}
+	return win32.GetDeviceCaps(p.hdc, win32.PropType(index))
+}
@@ -0,0 +1,102 @@
+package printer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// FileJob is a raw print job written to a file, to capture the output of a
+// job, or to a character device such as /dev/usb/lp0 or a serial port, to
+// print on a locally attached printer without a queue.
+type FileJob struct {
+	f      *os.File
+	device bool
+	closed bool
+}
+
+var _ io.ReadWriteCloser = (*FileJob)(nil)
+
+var errNotDevice = errors.New("printer: reading from a file job")
+
+// NewFileJob opens path for a job. Character devices are opened for reading
+// too, so that bidirectional printers can answer status requests; regular
+// files are created or truncated.
+func NewFileJob(path string) (*FileJob, error) {
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+		f, err := os.OpenFile(path, os.O_RDWR, 0)
+		if err != nil {
+			// Unidirectional ports can only be written.
+			f, err = os.OpenFile(path, os.O_WRONLY, 0)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("printer: %w", err)
+		}
+		return &FileJob{f: f, device: true}, nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("printer: %w", err)
+	}
+	return &FileJob{f: f}, nil
+}
+
+// Write sends b to the file or device.
+func (j *FileJob) Write(b []byte) (int, error) {
+	if j.closed {
+		return 0, os.ErrClosed
+	}
+	return j.f.Write(b)
+}
+
+// Read reads the bytes sent back by a device. It fails on regular files.
+func (j *FileJob) Read(b []byte) (int, error) {
+	if j.closed {
+		return 0, os.ErrClosed
+	}
+	if !j.device {
+		return 0, errNotDevice
+	}
+	return j.f.Read(b)
+}
+
+// Close ends the job, closing the file.
+func (j *FileJob) Close() error {
+	if j.closed {
+		return os.ErrClosed
+	}
+	j.closed = true
+	return j.f.Close()
+}
+
+// OpenRaw starts a raw job named docName on target, which is one of:
+//
+//   - "file:" followed by the path of a file or device, or an absolute
+//     Unix path such as "/dev/usb/lp0", for a FileJob;
+//   - "socket://host[:port]", for a NetJob with default options;
+//   - the name of an installed printer, for a RawJob of DatatypeRAW.
+func OpenRaw(target, docName string) (io.WriteCloser, error) {
+	// The jobs are returned through locals, so that a failure returns a nil
+	// interface rather than one holding a nil pointer.
+	switch {
+	case strings.HasPrefix(target, "file:"), strings.HasPrefix(target, "/"):
+		j, err := NewFileJob(strings.TrimPrefix(target, "file:"))
+		if err != nil {
+			return nil, err
+		}
+		return j, nil
+	case strings.HasPrefix(target, "socket://"):
+		j, err := NewNetJob(strings.TrimSuffix(strings.TrimPrefix(target, "socket://"), "/"), nil)
+		if err != nil {
+			return nil, err
+		}
+		return j, nil
+	}
+	j, err := NewRawJob(target, docName, DatatypeRAW)
+	if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
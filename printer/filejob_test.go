@@ -0,0 +1,90 @@
+package printer
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/sipkg/golang-win32-printer/escpos"
+)
+
+func TestFileJob(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ticket.bin")
+	os.WriteFile(path, []byte("previous job, longer than the next one"), 0o644)
+	j, err := OpenRaw("file:"+path, "ticket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := escpos.NewWriter(j)
+	p.Init()
+	p.Line("Total")
+	p.Cut(escpos.FullCut)
+	if err := p.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := j.(*FileJob).Read(make([]byte, 1)); err == nil {
+		t.Error("Read from a file job succeeded")
+	}
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := j.Write([]byte("x")); err == nil {
+		t.Error("Write after Close succeeded")
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte("\x1b@Total\n\x1dV\x00")
+	if !bytes.Equal(got, want) {
+		t.Errorf("file holds %q, want %q", got, want)
+	}
+}
+
+func TestFileJobDevice(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no /dev/null")
+	}
+	j, err := OpenRaw("/dev/null", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	fj := j.(*FileJob)
+	if !fj.device {
+		t.Error("/dev/null not opened as a device")
+	}
+	if _, err := fj.Write([]byte("x")); err != nil {
+		t.Error(err)
+	}
+	if n, _ := fj.Read(make([]byte, 1)); n != 0 {
+		t.Errorf("read %d bytes from /dev/null", n)
+	}
+}
+
+func TestOpenRawSocket(t *testing.T) {
+	addr, received := listen(t, nil)
+	j, err := OpenRaw("socket://"+addr+"/", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.Write([]byte("^XA^XZ"))
+	j.Close()
+	if got := <-received; string(got) != "^XA^XZ" {
+		t.Errorf("printer received %q", got)
+	}
+}
+
+func TestOpenRawError(t *testing.T) {
+	for _, target := range []string{
+		"file:" + filepath.Join(t.TempDir(), "missing", "ticket.bin"),
+		"socket://",
+	} {
+		j, err := OpenRaw(target, "")
+		if err == nil || j != nil {
+			t.Errorf("OpenRaw(%q) = %v, %v, want a nil job and an error", target, j, err)
+		}
+	}
+}
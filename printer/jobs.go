@@ -0,0 +1,84 @@
+package printer
+
+import (
+	"github.com/sipkg/golang-win32-printer/win32"
+)
+
+// The spooler calls used by the job methods, replaced by tests.
+var (
+	enumJobs       = win32.EnumJobs2
+	getJob         = win32.GetJob2
+	setJob         = win32.SetJob
+	defaultPrinter = win32.Default
+)
+
+// withQueue opens the queue of the printer for f, that of the default
+// printer when InitPrinter was given no name, as by Print: OpenPrinter
+// would open the print server instead.
+func (p *Printer) withQueue(f func(h win32.Printer) error) error {
+	if !p._init {
+		return errNotInit
+	}
+	name := p.name
+	if name == "" {
+		var err error
+		if name, err = defaultPrinter(); err != nil {
+			return err
+		}
+	}
+	h, err := openPrinter(name)
+	if err != nil {
+		return err
+	}
+	err = f(h)
+	if cerr := closePrinter(h); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Jobs lists the jobs in the queue of the printer, with their status,
+// pages printed, size and submitter.
+func (p *Printer) Jobs() (jobs []win32.JobInfo2, err error) {
+	err = p.withQueue(func(h win32.Printer) error {
+		jobs, err = enumJobs(h)
+		return err
+	})
+	return jobs, err
+}
+
+// Job describes the job id in the queue of the printer.
+func (p *Printer) Job(id uint32) (job *win32.JobInfo2, err error) {
+	err = p.withQueue(func(h win32.Printer) error {
+		job, err = getJob(h, id)
+		return err
+	})
+	return job, err
+}
+
+func (p *Printer) controlJob(id, command uint32) error {
+	return p.withQueue(func(h win32.Printer) error {
+		return setJob(h, id, command)
+	})
+}
+
+// PauseJob pauses the job id. Controlling the jobs of other users takes
+// the right to manage documents on the printer.
+func (p *Printer) PauseJob(id uint32) error {
+	return p.controlJob(id, win32.JOB_CONTROL_PAUSE)
+}
+
+// ResumeJob resumes the job id paused by PauseJob.
+func (p *Printer) ResumeJob(id uint32) error {
+	return p.controlJob(id, win32.JOB_CONTROL_RESUME)
+}
+
+// RestartJob prints the job id again from its start.
+func (p *Printer) RestartJob(id uint32) error {
+	return p.controlJob(id, win32.JOB_CONTROL_RESTART)
+}
+
+// CancelJob deletes the job id from the queue, even while it prints.
+func (p *Printer) CancelJob(id uint32) error {
+	return p.controlJob(id, win32.JOB_CONTROL_DELETE)
+}
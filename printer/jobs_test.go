@@ -0,0 +1,79 @@
+package printer
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/sipkg/golang-win32-printer/win32"
+)
+
+func TestJobs(t *testing.T) {
+	var calls []string
+	oldOpen, oldClose, oldEnum, oldGet, oldSet, oldDefault := openPrinter, closePrinter, enumJobs, getJob, setJob, defaultPrinter
+	t.Cleanup(func() {
+		openPrinter, closePrinter, enumJobs, getJob, setJob, defaultPrinter = oldOpen, oldClose, oldEnum, oldGet, oldSet, oldDefault
+	})
+	openPrinter = func(name string) (win32.Printer, error) {
+		calls = append(calls, "open "+name)
+		return 7, nil
+	}
+	closePrinter = func(h win32.Printer) error {
+		calls = append(calls, "close")
+		return nil
+	}
+	enumJobs = func(h win32.Printer) ([]win32.JobInfo2, error) {
+		return []win32.JobInfo2{{JobID: 3, UserName: "caissier", Size: 512}}, nil
+	}
+	getJob = func(h win32.Printer, id uint32) (*win32.JobInfo2, error) {
+		return nil, errors.New("no such job")
+	}
+	var commands []uint32
+	setJob = func(h win32.Printer, id, command uint32) error {
+		if h != 7 || id != 3 {
+			t.Errorf("SetJob(%d, %d)", h, id)
+		}
+		commands = append(commands, command)
+		return nil
+	}
+
+	p := &Printer{}
+	if _, err := p.Jobs(); !errors.Is(err, errNotInit) {
+		t.Errorf("Jobs() before InitPrinter: error %v", err)
+	}
+	p.name, p._init = "Caisse", true
+	jobs, err := p.Jobs()
+	if err != nil || len(jobs) != 1 || jobs[0].UserName != "caissier" {
+		t.Fatalf("Jobs() = %+v, %v", jobs, err)
+	}
+	if _, err := p.Job(4); err == nil {
+		t.Error("Job() of a missing job succeeded")
+	}
+	for _, f := range []func(uint32) error{p.PauseJob, p.ResumeJob, p.RestartJob, p.CancelJob} {
+		if err := f(3); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []uint32{win32.JOB_CONTROL_PAUSE, win32.JOB_CONTROL_RESUME, win32.JOB_CONTROL_RESTART, win32.JOB_CONTROL_DELETE}
+	if !reflect.DeepEqual(commands, want) {
+		t.Errorf("commands = %v, want %v", commands, want)
+	}
+	if len(calls) != 12 || calls[0] != "open Caisse" || calls[len(calls)-1] != "close" {
+		t.Errorf("calls = %v", calls)
+	}
+
+	// Print initializes the printer without a name, for the default one.
+	defaultPrinter = func() (string, error) { return "Bureau", nil }
+	calls = nil
+	p.name = ""
+	if _, err := p.Jobs(); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 2 || calls[0] != "open Bureau" {
+		t.Errorf("calls for the default printer = %v", calls)
+	}
+	defaultPrinter = func() (string, error) { return "", win32.ErrUnsupportedPlatform }
+	if _, err := p.Jobs(); !errors.Is(err, win32.ErrUnsupportedPlatform) {
+		t.Errorf("Jobs() without a default printer: error %v", err)
+	}
+}
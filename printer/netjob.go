@@ -0,0 +1,117 @@
+package printer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// RawPort is the TCP port of raw printing, also known as AppSocket or
+// JetDirect: every byte received is printed as is.
+const RawPort = "9100"
+
+// NetOptions tunes the connection of a NetJob. The zero value uses the
+// defaults given for each field.
+type NetOptions struct {
+	// DialTimeout bounds each connection attempt. Zero means 5 seconds.
+	DialTimeout time.Duration
+	// WriteTimeout bounds each Write. Zero means 30 seconds, which leaves
+	// time for a printer to clear its buffer.
+	WriteTimeout time.Duration
+	// ReadTimeout bounds each Read of status bytes. Zero means 2 seconds.
+	ReadTimeout time.Duration
+	// Retries is the number of connection attempts made after the first
+	// one fails. Writes are never retried, since the printer may have
+	// printed part of them.
+	Retries int
+	// RetryDelay is the pause between connection attempts. Zero means 1
+	// second.
+	RetryDelay time.Duration
+}
+
+// orDefault returns d, or def when d is not set.
+func orDefault(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
+}
+
+// dialTimeout opens the connections of NetJob, replaced by tests.
+var dialTimeout = net.DialTimeout
+
+// NetJob is a raw print job sent over TCP to a network printer, typically
+// on RawPort. It needs no installed queue, only the address of the printer.
+//
+// Printers speaking ESC/POS also answer status requests on the same
+// connection, so a NetJob can be handed to escpos.QueryStatus.
+type NetJob struct {
+	conn   net.Conn
+	opts   NetOptions
+	closed bool
+}
+
+var _ io.ReadWriteCloser = (*NetJob)(nil)
+
+// NewNetJob connects to the printer at addr, "host" or "host:port", on
+// RawPort when no port is given. opts may be nil.
+func NewNetJob(addr string, opts *NetOptions) (*NetJob, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, RawPort)
+	}
+	j := &NetJob{}
+	if opts != nil {
+		j.opts = *opts
+	}
+	timeout := orDefault(j.opts.DialTimeout, 5*time.Second)
+	var err error
+	for attempt := 0; attempt <= j.opts.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(orDefault(j.opts.RetryDelay, time.Second))
+		}
+		j.conn, err = dialTimeout("tcp", addr, timeout)
+		if err == nil {
+			return j, nil
+		}
+	}
+	return nil, fmt.Errorf("printer: connecting to %s: %w", addr, err)
+}
+
+var errNetJobClosed = errors.New("printer: network job already closed")
+
+// Write sends b to the printer.
+func (j *NetJob) Write(b []byte) (int, error) {
+	if j.closed {
+		return 0, errNetJobClosed
+	}
+	j.conn.SetWriteDeadline(time.Now().Add(orDefault(j.opts.WriteTimeout, 30*time.Second)))
+	n, err := j.conn.Write(b)
+	if err != nil {
+		return n, fmt.Errorf("printer: sending job: %w", err)
+	}
+	return n, nil
+}
+
+// Read reads the bytes sent back by the printer, such as status bytes.
+func (j *NetJob) Read(b []byte) (int, error) {
+	if j.closed {
+		return 0, errNetJobClosed
+	}
+	j.conn.SetReadDeadline(time.Now().Add(orDefault(j.opts.ReadTimeout, 2*time.Second)))
+	return j.conn.Read(b)
+}
+
+// Close ends the job. The write side is shut down first, so that the printer
+// sees the end of the job even before the connection is torn down.
+func (j *NetJob) Close() error {
+	if j.closed {
+		return errNetJobClosed
+	}
+	j.closed = true
+	if c, ok := j.conn.(interface{ CloseWrite() error }); ok {
+		c.CloseWrite()
+	}
+	return j.conn.Close()
+}
@@ -0,0 +1,90 @@
+package printer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sipkg/golang-win32-printer/escpos"
+)
+
+// listen starts a raw printer stand-in recording what it receives, answering
+// reply to the first byte.
+func listen(t *testing.T, reply []byte) (addr string, received <-chan []byte) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	ch := make(chan []byte, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var data bytes.Buffer
+		b := make([]byte, 1)
+		if _, err := conn.Read(b); err == nil {
+			data.Write(b)
+			conn.Write(reply)
+		}
+		io.Copy(&data, conn)
+		ch <- data.Bytes()
+	}()
+	return l.Addr().String(), ch
+}
+
+func TestNetJob(t *testing.T) {
+	addr, received := listen(t, []byte{0x12})
+	j, err := NewNetJob(addr, &NetOptions{ReadTimeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, err := escpos.QueryStatus(j, escpos.PrinterStatus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Offline {
+		t.Error("printer reported offline")
+	}
+	if _, err := j.Write([]byte("ticket\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Close(); err == nil {
+		t.Error("second Close succeeded")
+	}
+	want := []byte{escpos.DLE, escpos.EOT, 1, 't', 'i', 'c', 'k', 'e', 't', '\n'}
+	select {
+	case got := <-received:
+		if !bytes.Equal(got, want) {
+			t.Errorf("printer received %q, want %q", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("printer received nothing")
+	}
+}
+
+func TestNetJobRetries(t *testing.T) {
+	old := dialTimeout
+	t.Cleanup(func() { dialTimeout = old })
+	var addrs []string
+	refused := errors.New("connection refused")
+	dialTimeout = func(network, addr string, timeout time.Duration) (net.Conn, error) {
+		addrs = append(addrs, addr)
+		return nil, refused
+	}
+	_, err := NewNetJob("printer.local", &NetOptions{Retries: 2, RetryDelay: time.Millisecond})
+	if !errors.Is(err, refused) {
+		t.Errorf("NewNetJob error = %v", err)
+	}
+	if len(addrs) != 3 || addrs[0] != "printer.local:9100" {
+		t.Errorf("dialed %v", addrs)
+	}
+}
@@ -1,11 +1,8 @@
 package printer
 
 import (
-	"errors"
-	"unsafe"
-
+	"github.com/sipkg/golang-win32-printer/device"
 	"github.com/sipkg/golang-win32-printer/win32"
-	"golang.org/x/sys/windows"
 )
 
 const (
@@ -14,6 +11,7 @@ const (
 
 type Printer struct {
 	hdc   win32.HDC
+	name  string
 	_init bool
 }
 
@@ -25,32 +23,20 @@ func (p *Printer) InitPrinter(printerName string) (err error) {
 	if err != nil {
 		return
 	}
+	p.name = printerName
 	p._init = true
 	return
 }
 
 func (p *Printer) EnumPrinter() (info []win32.PrinterInfo, err error) {
-	var need uint32 = 0
-	var returned uint32 = 0
-	err = win32.EnumPrinter(win32.PRINTER_ENUM_LOCAL|win32.PRINTER_ENUM_CONNECTIONS, nil, 4, nil, 0, &need, &returned)
-	if err != nil {
-		return nil, err
-	}
-	buf := make([]byte, need)
-	err = win32.EnumPrinter(win32.PRINTER_ENUM_LOCAL|win32.PRINTER_ENUM_CONNECTIONS, nil, 4, &buf[0], need, &need, &returned)
-	if err != nil {
-		return nil, err
-	}
-	ps := (*[1024]win32.PRINT_INFO_4)(unsafe.Pointer(&buf[0]))[:returned:returned]
-	printInfos := make([]win32.PrinterInfo, 0, returned)
-	for _, p := range ps {
-		printInfos = append(printInfos, win32.PrinterInfo{
-			PrinterName: windows.UTF16PtrToString(p.PrinterName),
-			ServerName:  windows.UTF16PtrToString(p.ServerName),
-			Attributes:  p.Attributes,
-		})
-	}
-	return printInfos, nil
+	return win32.EnumPrinters(win32.PRINTER_ENUM_LOCAL | win32.PRINTER_ENUM_CONNECTIONS)
+}
+
+// EnumPrinterDetails lists the same printers as EnumPrinter with their
+// driver, port, share name, location, status, job count and default
+// DEVMODE.
+func (p *Printer) EnumPrinterDetails() ([]win32.PrinterInfo2, error) {
+	return win32.EnumPrinters2(win32.PRINTER_ENUM_LOCAL | win32.PRINTER_ENUM_CONNECTIONS)
 }
 
 func (p *Printer) GetWidthPixel() (uint32, error) {
@@ -110,61 +96,53 @@ func (p *Printer) GetMarginTop() (uint32, error) {
 }
 
 func (p *Printer) StartDoc(docName string) error {
+	return p.StartDocToFile(docName, "")
+}
+
+// StartDocToFile starts a document whose driver output, such as PCL or
+// PostScript, is written to outputFile instead of being printed.
+func (p *Printer) StartDocToFile(docName, outputFile string) error {
 	if !p._init {
-		return errors.New("must call InitPrinter before")
-	}
-	size := unsafe.Sizeof(win32.DOCINFOA{})
-	doc := &win32.DOCINFOA{
-		Size:     size,
-		DocName:  windows.StringToUTF16Ptr(docName),
-		Output:   nil,
-		DataType: nil,
-		Type:     0,
+		return errNotInit
 	}
-	return win32.StartDoc(p.hdc, doc)
+	return win32.StartDoc(p.hdc, win32.NewDOCINFO(docName, outputFile, ""))
 }
 
 func (p *Printer) StartPage() error {
 	if !p._init {
-		return errors.New("must call InitPrinter before")
+		return errNotInit
 	}
 	return win32.StartPage(p.hdc)
 }
 
 func (p *Printer) EndDoc() error {
 	if !p._init {
-		return errors.New("must call InitPrinter before")
+		return errNotInit
 	}
 	return win32.EndDoc(p.hdc)
 }
 
 func (p *Printer) EndPage() error {
 	if !p._init {
-		return errors.New("must call InitPrinter before")
+		return errNotInit
 	}
 	return win32.EndPage(p.hdc)
 }
 
-type Printable interface {
-	Print(*Printer)
+// Close releases the device context created by InitPrinter.
+func (p *Printer) Close() error {
+	if !p._init {
+		return nil
+	}
+	p._init = false
+	return win32.DeleteDC(p.hdc)
 }
 
+type Printable = device.Printable
+
 func (p *Printer) Print(pt Printable) (err error) {
 	if err = p.InitPrinter(""); err != nil {
 		return
 	}
-	if err = p.StartDoc(DOCNAME); err != nil {
-		return
-	}
-	if err = p.StartPage(); err != nil {
-		return
-	}
-	pt.Print(p)
-	if err = p.EndPage(); err != nil {
-		return
-	}
-	if err = p.EndDoc(); err != nil {
-		return
-	}
-	return
+	return device.Print(p, DOCNAME, pt)
 }
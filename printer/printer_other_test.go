@@ -0,0 +1,20 @@
+//go:build !windows
+
+package printer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sipkg/golang-win32-printer/win32"
+)
+
+func TestInitPrinterUnsupported(t *testing.T) {
+	p := Printer{}
+	if err := p.InitPrinter(""); !errors.Is(err, win32.ErrUnsupportedPlatform) {
+		t.Errorf("InitPrinter() error = %v, want ErrUnsupportedPlatform", err)
+	}
+	if err := p.TextOut(0, 0, "x"); err == nil {
+		t.Error("TextOut() succeeded without a printer")
+	}
+}
@@ -1,32 +1,29 @@
+//go:build windows
+
 package printer
 
 import (
 	"fmt"
-	image2 "image"
-	"image/draw"
 	"image/jpeg"
 	"os"
 	"testing"
 
-	bgr2 "github.com/sipkg/golang-win32-printer/image/bgr"
-	"github.com/sipkg/golang-win32-printer/win32"
+	"github.com/sipkg/golang-win32-printer/device"
 )
 
 type imagePrinter struct{}
 
-func (ip *imagePrinter) Print(p *Printer) {
+func (ip *imagePrinter) Print(c device.Canvas) {
 	file, err := os.Open("C:\\Users\\wangjun\\Desktop\\pdf\\sekiro.png")
 	fmt.Print(err)
 	image, err := jpeg.Decode(file)
 	fmt.Print(err)
-	bgr := bgr2.NewBGRImage(image.Bounds())
-	draw.Draw(bgr, image.Bounds(), image, image2.Point{}, draw.Src)
-	src := bgr2.ReverseDIB(bgr.Pix, image.Bounds().Dx(), image.Bounds().Dy(), 24)
-	left, _ := p.GetMarginLeft()
-	top, _ := p.GetMarginLeft()
-	width, _ := p.GetWidthPixel()
-	height, _ := p.GetHeightPixel()
-	win32.DrawDIImage(p.hdc, left, top, width, height, 0, 0, int32(image.Bounds().Dx()), int32(image.Bounds().Dy()), src)
+	left, _ := c.GetDeviceCaps(device.PhysicalOffsetX)
+	top, _ := c.GetDeviceCaps(device.PhysicalOffsetY)
+	width, _ := c.GetDeviceCaps(device.HorzRes)
+	height, _ := c.GetDeviceCaps(device.VertRes)
+	err = c.DrawImage(left, top, width, height, image)
+	fmt.Print(err)
 }
 
 func TestPrinter(t *testing.T) {
@@ -0,0 +1,116 @@
+package printer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/sipkg/golang-win32-printer/win32"
+)
+
+// Datatypes of a raw job, telling the spooler how to process its bytes.
+const (
+	// DatatypeRAW sends the bytes to the printer untouched, as needed by
+	// ESC/POS, ZPL or PCL streams.
+	DatatypeRAW = "RAW"
+	// DatatypeText lets the print processor render the bytes as plain text.
+	DatatypeText = "TEXT"
+	// DatatypeXPSPass passes an XPS document through to an XPS driver.
+	DatatypeXPSPass = "XPS_PASS"
+)
+
+var errJobClosed = errors.New("printer: raw job already closed")
+
+// The spooler calls used by RawJob, replaced by tests.
+var (
+	openPrinter      = win32.OpenPrinter
+	closePrinter     = win32.ClosePrinter
+	startDocPrinter  = win32.StartDocPrinter
+	endDocPrinter    = win32.EndDocPrinter
+	startPagePrinter = win32.StartPagePrinter
+	endPagePrinter   = win32.EndPagePrinter
+	writePrinter     = win32.WritePrinter
+)
+
+// RawJob is a print job whose bytes are sent to the printer through
+// WritePrinter, bypassing GDI. It is what receipt and label printers speaking
+// ESC/POS, ZPL or EPL need.
+type RawJob struct {
+	handle win32.Printer
+	closed bool
+}
+
+var _ io.WriteCloser = (*RawJob)(nil)
+
+// NewRawJob opens the printer named printerName and starts a one page job
+// named docName of the given datatype, DatatypeRAW when empty.
+func NewRawJob(printerName, docName, datatype string) (*RawJob, error) {
+	return NewRawJobToFile(printerName, docName, datatype, "")
+}
+
+// NewRawJobToFile is NewRawJob, the spooler writing the job to outputFile
+// instead of sending it to the port of the printer when outputFile is not
+// empty.
+func NewRawJobToFile(printerName, docName, datatype, outputFile string) (*RawJob, error) {
+	switch datatype {
+	case "":
+		datatype = DatatypeRAW
+	case DatatypeRAW, DatatypeText, DatatypeXPSPass:
+	default:
+		return nil, fmt.Errorf("printer: unknown datatype %q", datatype)
+	}
+	handle, err := openPrinter(printerName)
+	if err != nil {
+		return nil, fmt.Errorf("printer: OpenPrinter %q: %w", printerName, err)
+	}
+	if err := startDocPrinter(handle, 1, win32.NewDOC_INFO_1(docName, outputFile, datatype)); err != nil {
+		closePrinter(handle)
+		return nil, fmt.Errorf("printer: StartDocPrinter: %w", err)
+	}
+	if err := startPagePrinter(handle); err != nil {
+		endDocPrinter(handle)
+		closePrinter(handle)
+		return nil, fmt.Errorf("printer: StartPagePrinter: %w", err)
+	}
+	return &RawJob{handle: handle}, nil
+}
+
+// Write sends b to the printer, calling WritePrinter again as long as it
+// reports having written only part of it.
+func (j *RawJob) Write(b []byte) (int, error) {
+	if j.closed {
+		return 0, errJobClosed
+	}
+	n := 0
+	for n < len(b) {
+		var written uint32
+		err := writePrinter(j.handle, &b[n], uint32(len(b)-n), &written)
+		n += int(written)
+		if err != nil {
+			return n, fmt.Errorf("printer: WritePrinter: %w", err)
+		}
+		if written == 0 {
+			return n, io.ErrShortWrite
+		}
+	}
+	return n, nil
+}
+
+// Close ends the job, letting the spooler print it, and closes the printer.
+func (j *RawJob) Close() error {
+	if j.closed {
+		return errJobClosed
+	}
+	j.closed = true
+	err := endPagePrinter(j.handle)
+	if e := endDocPrinter(j.handle); err == nil {
+		err = e
+	}
+	if e := closePrinter(j.handle); err == nil {
+		err = e
+	}
+	if err != nil {
+		return fmt.Errorf("printer: closing raw job: %w", err)
+	}
+	return nil
+}
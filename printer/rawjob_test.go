@@ -0,0 +1,115 @@
+package printer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/sipkg/golang-win32-printer/win32"
+)
+
+// fakeSpooler replaces the spooler calls, accepting at most chunk bytes per
+// WritePrinter call.
+type fakeSpooler struct {
+	chunk    int
+	datatype string
+	data     bytes.Buffer
+	calls    []string
+}
+
+func utf16String(p *uint16) string {
+	var s []rune
+	for ; *p != 0; p = (*uint16)(unsafe.Add(unsafe.Pointer(p), 2)) {
+		s = append(s, rune(*p))
+	}
+	return string(s)
+}
+
+func (f *fakeSpooler) install(t *testing.T) {
+	oldOpen, oldClose, oldStartDoc, oldEndDoc := openPrinter, closePrinter, startDocPrinter, endDocPrinter
+	oldStartPage, oldEndPage, oldWrite := startPagePrinter, endPagePrinter, writePrinter
+	t.Cleanup(func() {
+		openPrinter, closePrinter, startDocPrinter, endDocPrinter = oldOpen, oldClose, oldStartDoc, oldEndDoc
+		startPagePrinter, endPagePrinter, writePrinter = oldStartPage, oldEndPage, oldWrite
+	})
+	call := func(name string) func(win32.Printer) error {
+		return func(win32.Printer) error {
+			f.calls = append(f.calls, name)
+			return nil
+		}
+	}
+	openPrinter = func(string) (win32.Printer, error) {
+		f.calls = append(f.calls, "OpenPrinter")
+		return 1, nil
+	}
+	startDocPrinter = func(_ win32.Printer, level uint32, doc *win32.DOC_INFO_1) error {
+		f.calls = append(f.calls, "StartDocPrinter")
+		f.datatype = utf16String(doc.Datatype)
+		return nil
+	}
+	closePrinter = call("ClosePrinter")
+	endDocPrinter = call("EndDocPrinter")
+	startPagePrinter = call("StartPagePrinter")
+	endPagePrinter = call("EndPagePrinter")
+	writePrinter = func(_ win32.Printer, buf *byte, n uint32, written *uint32) error {
+		if int(n) > f.chunk {
+			n = uint32(f.chunk)
+		}
+		f.data.Write(unsafe.Slice(buf, n))
+		*written = n
+		return nil
+	}
+}
+
+func TestRawJob(t *testing.T) {
+	f := &fakeSpooler{chunk: 3}
+	f.install(t)
+	job, err := NewRawJob("POS-80", "ticket", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := io.WriteString(job, "\x1b@Hello\n")
+	if n != 8 || err != nil {
+		t.Errorf("Write() = %d, %v, want 8, nil", n, err)
+	}
+	if err := job.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.data.String(); got != "\x1b@Hello\n" {
+		t.Errorf("printer received %q", got)
+	}
+	if f.datatype != DatatypeRAW {
+		t.Errorf("datatype = %q, want RAW", f.datatype)
+	}
+	want := "OpenPrinter StartDocPrinter StartPagePrinter EndPagePrinter EndDocPrinter ClosePrinter"
+	if got := strings.Join(f.calls, " "); got != want {
+		t.Errorf("calls = %s", got)
+	}
+	if _, err := job.Write([]byte("x")); err == nil {
+		t.Error("Write() after Close succeeded")
+	}
+}
+
+func TestRawJobShortWrite(t *testing.T) {
+	f := &fakeSpooler{chunk: 0}
+	f.install(t)
+	job, err := NewRawJob("POS-80", "ticket", DatatypeText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := job.Write([]byte("abc")); !errors.Is(err, io.ErrShortWrite) {
+		t.Errorf("Write() error = %v, want io.ErrShortWrite", err)
+	}
+	if f.datatype != DatatypeText {
+		t.Errorf("datatype = %q, want TEXT", f.datatype)
+	}
+}
+
+func TestRawJobDatatype(t *testing.T) {
+	if _, err := NewRawJob("POS-80", "ticket", "EMF"); err == nil {
+		t.Error("NewRawJob() accepted an unknown datatype")
+	}
+}
@@ -0,0 +1,281 @@
+package status
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"time"
+)
+
+// SNMP reads the state of a network printer from the Host Resources MIB
+// (RFC 2790) with an SNMPv1 get request.
+type SNMP struct {
+	// Addr is the address of the printer, "host" or "host:port", on port
+	// 161 when no port is given.
+	Addr string
+	// Community is the SNMP community, "public" when empty.
+	Community string
+	// Timeout bounds a request. Zero means 2 seconds.
+	Timeout time.Duration
+}
+
+// Objects of the first device of the Host Resources MIB.
+var (
+	oidDeviceStatus       = []int{1, 3, 6, 1, 2, 1, 25, 3, 2, 1, 5, 1}
+	oidPrinterStatus      = []int{1, 3, 6, 1, 2, 1, 25, 3, 5, 1, 1, 1}
+	oidDetectedErrorState = []int{1, 3, 6, 1, 2, 1, 25, 3, 5, 1, 2, 1}
+)
+
+// Values of hrDeviceStatus and hrPrinterStatus.
+const (
+	deviceDown      = 5
+	printerPrinting = 4
+	printerWarmup   = 5
+)
+
+// errorStates maps the bits of hrPrinterDetectedErrorState, from the most
+// significant bit of its first byte, to flags.
+var errorStates = []Flags{
+	PaperLow,         // lowPaper
+	PaperOut,         // noPaper
+	TonerLow,         // lowToner
+	TonerLow | Error, // noToner
+	DoorOpen,         // doorOpen
+	PaperJam,         // jammed
+	Offline,          // offline
+	Error,            // serviceRequested
+	PaperOut,         // inputTrayMissing
+	Error,            // outputTrayMissing
+	Error,            // markerSupplyMissing
+	0,                // outputNearFull
+	Error,            // outputFull
+	PaperOut,         // inputTrayEmpty
+	0,                // overduePreventMaint
+}
+
+var errSNMPResponse = errors.New("status: invalid SNMP response")
+
+// Status queries hrDeviceStatus, hrPrinterStatus and
+// hrPrinterDetectedErrorState.
+func (s *SNMP) Status(ctx context.Context) (Flags, error) {
+	addr := s.Addr
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "161")
+	}
+	community := s.Community
+	if community == "" {
+		community = "public"
+	}
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", addr)
+	if err != nil {
+		return 0, fmt.Errorf("status: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(deadline)
+
+	id := rand.Int31()
+	if _, err := conn.Write(getRequest(community, id, oidDeviceStatus, oidPrinterStatus, oidDetectedErrorState)); err != nil {
+		return 0, fmt.Errorf("status: %w", err)
+	}
+	buf := make([]byte, 1500)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return 0, fmt.Errorf("status: %w", err)
+		}
+		values, respID, err := parseResponse(buf[:n])
+		if err != nil {
+			return 0, err
+		}
+		if respID != id || len(values) != 3 {
+			// A late answer to a previous request.
+			continue
+		}
+		var f Flags
+		if v, ok := values[0].(int); ok && v == deviceDown {
+			f |= Offline
+		}
+		if v, ok := values[1].(int); ok && (v == printerPrinting || v == printerWarmup) {
+			f |= Busy
+		}
+		if v, ok := values[2].([]byte); ok {
+			for i, flag := range errorStates {
+				if i/8 < len(v) && v[i/8]&(0x80>>(i%8)) != 0 {
+					f |= flag
+				}
+			}
+		}
+		return f, nil
+	}
+}
+
+// BER tags of SNMPv1 messages.
+const (
+	berInteger     = 0x02
+	berOctetString = 0x04
+	berNull        = 0x05
+	berOID         = 0x06
+	berSequence    = 0x30
+	berGetRequest  = 0xA0
+	berGetResponse = 0xA2
+)
+
+// tlv encodes a BER element.
+func tlv(tag byte, value []byte) []byte {
+	b := []byte{tag}
+	switch n := len(value); {
+	case n < 0x80:
+		b = append(b, byte(n))
+	case n < 0x100:
+		b = append(b, 0x81, byte(n))
+	default:
+		b = append(b, 0x82, byte(n>>8), byte(n))
+	}
+	return append(b, value...)
+}
+
+func berInt(v int32) []byte {
+	b := []byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}
+	// Drop the leading bytes that only extend the sign.
+	for len(b) > 1 && ((b[0] == 0 && b[1]&0x80 == 0) || (b[0] == 0xFF && b[1]&0x80 != 0)) {
+		b = b[1:]
+	}
+	return tlv(berInteger, b)
+}
+
+func berOIDValue(oid []int) []byte {
+	b := []byte{byte(40*oid[0] + oid[1])}
+	for _, n := range oid[2:] {
+		var enc []byte
+		for enc = []byte{byte(n & 0x7F)}; n >= 0x80; {
+			n >>= 7
+			enc = append([]byte{byte(n&0x7F) | 0x80}, enc...)
+		}
+		b = append(b, enc...)
+	}
+	return tlv(berOID, b)
+}
+
+// getRequest encodes an SNMPv1 get request of oids.
+func getRequest(community string, id int32, oids ...[]int) []byte {
+	var binds []byte
+	for _, oid := range oids {
+		binds = append(binds, tlv(berSequence, append(berOIDValue(oid), berNull, 0))...)
+	}
+	pdu := append(berInt(id), berInt(0)...)
+	pdu = append(pdu, berInt(0)...)
+	pdu = append(pdu, tlv(berSequence, binds)...)
+	msg := append(berInt(0), tlv(berOctetString, []byte(community))...)
+	msg = append(msg, tlv(berGetRequest, pdu)...)
+	return tlv(berSequence, msg)
+}
+
+// readTLV splits the first BER element of b.
+func readTLV(b []byte) (tag byte, value, rest []byte, err error) {
+	if len(b) < 2 {
+		return 0, nil, nil, errSNMPResponse
+	}
+	tag, n, b := b[0], int(b[1]), b[2:]
+	if n&0x80 != 0 {
+		k := n & 0x7F
+		if k == 0 || k > 2 || len(b) < k {
+			return 0, nil, nil, errSNMPResponse
+		}
+		n = 0
+		for _, c := range b[:k] {
+			n = n<<8 | int(c)
+		}
+		b = b[k:]
+	}
+	if len(b) < n {
+		return 0, nil, nil, errSNMPResponse
+	}
+	return tag, b[:n], b[n:], nil
+}
+
+// expect reads an element of b that must have tag.
+func expect(b []byte, tag byte) (value, rest []byte, err error) {
+	t, value, rest, err := readTLV(b)
+	if err == nil && t != tag {
+		err = errSNMPResponse
+	}
+	return value, rest, err
+}
+
+func parseInt(b []byte) int {
+	v := 0
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		v = -1
+	}
+	for _, c := range b {
+		v = v<<8 | int(c)
+	}
+	return v
+}
+
+// parseResponse decodes an SNMP get response and returns its request id
+// and the values of its variable bindings: an int for integers, a []byte
+// for octet strings and nil for the others.
+func parseResponse(b []byte) ([]any, int32, error) {
+	msg, _, err := expect(b, berSequence)
+	if err != nil {
+		return nil, 0, err
+	}
+	if _, msg, err = expect(msg, berInteger); err != nil {
+		return nil, 0, err
+	}
+	if _, msg, err = expect(msg, berOctetString); err != nil {
+		return nil, 0, err
+	}
+	pdu, _, err := expect(msg, berGetResponse)
+	if err != nil {
+		return nil, 0, err
+	}
+	var fields [3][]byte
+	for i := range fields {
+		if fields[i], pdu, err = expect(pdu, berInteger); err != nil {
+			return nil, 0, err
+		}
+	}
+	if status := parseInt(fields[1]); status != 0 {
+		return nil, 0, fmt.Errorf("status: SNMP error status %d", status)
+	}
+	binds, _, err := expect(pdu, berSequence)
+	if err != nil {
+		return nil, 0, err
+	}
+	var values []any
+	for len(binds) > 0 {
+		var bind []byte
+		if bind, binds, err = expect(binds, berSequence); err != nil {
+			return nil, 0, err
+		}
+		if _, bind, err = expect(bind, berOID); err != nil {
+			return nil, 0, err
+		}
+		tag, value, _, err := readTLV(bind)
+		if err != nil {
+			return nil, 0, err
+		}
+		switch tag {
+		case berInteger:
+			values = append(values, parseInt(value))
+		case berOctetString:
+			values = append(values, value)
+		default:
+			values = append(values, nil)
+		}
+	}
+	return values, int32(parseInt(fields[0])), nil
+}
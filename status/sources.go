@@ -0,0 +1,212 @@
+package status
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/sipkg/golang-win32-printer/escpos"
+	"github.com/sipkg/golang-win32-printer/ipp"
+	"github.com/sipkg/golang-win32-printer/win32"
+)
+
+// Spooler reads the state of a Windows printer from the spooler, woken by
+// printer change notifications.
+type Spooler struct {
+	handle win32.Printer
+	notify win32.ChangeNotification
+}
+
+// NewSpooler opens the printer name. Elsewhere than on Windows it returns
+// win32.ErrUnsupportedPlatform.
+func NewSpooler(name string) (*Spooler, error) {
+	h, err := win32.OpenPrinter(name)
+	if err != nil {
+		return nil, err
+	}
+	n, err := win32.FindFirstPrinterChangeNotification(h, win32.PRINTER_CHANGE_PRINTER|win32.PRINTER_CHANGE_JOB)
+	if err != nil {
+		win32.ClosePrinter(h)
+		return nil, err
+	}
+	return &Spooler{handle: h, notify: n}, nil
+}
+
+// Status returns the status the spooler reports in PRINTER_INFO_2. A
+// printer used offline is Offline.
+func (s *Spooler) Status(ctx context.Context) (Flags, error) {
+	info, err := win32.GetPrinter2(s.handle)
+	if err != nil {
+		return 0, err
+	}
+	f := FromPrinterStatus(info.Status)
+	if info.Attributes&win32.PRINTER_ATTRIBUTE_WORK_OFFLINE != 0 {
+		f |= Offline
+	}
+	return f, nil
+}
+
+// waitSlice bounds each wait for a notification, so that Wait notices ctx
+// being done.
+const waitSlice = 250 * time.Millisecond
+
+// Wait waits for a change notification, at most timeout and until ctx is
+// done.
+func (s *Spooler) Wait(ctx context.Context, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		left := time.Until(deadline)
+		if left <= 0 {
+			return nil
+		}
+		changed, err := win32.WaitPrinterChange(s.notify, min(left, waitSlice))
+		if err != nil || changed != 0 {
+			return err
+		}
+	}
+}
+
+// Close closes the notification and the printer.
+func (s *Spooler) Close() error {
+	err := win32.FindClosePrinterChangeNotification(s.notify)
+	if cerr := win32.ClosePrinter(s.handle); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// ESCPOS reads the state of an ESC/POS printer with DLE EOT real time
+// status requests, which it answers even while offline.
+type ESCPOS struct {
+	// Conn is a bidirectional connection to the printer, such as a
+	// printer.NetJob or a printer.FileJob on a serial port. It should not
+	// carry print jobs while the source is polled, as the answers would
+	// interleave with them.
+	Conn io.ReadWriter
+}
+
+// Status queries the printer, offline cause and paper sensor statuses, and
+// the error status when the printer reports an error. It returns when ctx
+// is done even though a read from Conn blocks, which then ends in the
+// background.
+func (s *ESCPOS) Status(ctx context.Context) (Flags, error) {
+	type result struct {
+		f   Flags
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		f, err := s.query()
+		done <- result{f, err}
+	}()
+	select {
+	case r := <-done:
+		return r.f, r.err
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+func (s *ESCPOS) query() (Flags, error) {
+	var f Flags
+	for _, kind := range []escpos.StatusKind{escpos.PrinterStatus, escpos.OfflineStatus, escpos.PaperStatus} {
+		st, err := escpos.QueryStatus(s.Conn, kind)
+		if err != nil {
+			return 0, err
+		}
+		if st.Offline {
+			f |= Offline
+		}
+		if st.CoverOpen {
+			f |= DoorOpen
+		}
+		if st.PaperOut || st.PaperEnd {
+			f |= PaperOut
+		}
+		if st.PaperNearEnd {
+			f |= PaperLow
+		}
+		if st.Error {
+			f |= Error
+		}
+	}
+	if f&Error != 0 {
+		st, err := escpos.QueryStatus(s.Conn, escpos.ErrorStatus)
+		if err != nil {
+			return 0, err
+		}
+		if st.CutterError {
+			f |= PaperJam
+		}
+	}
+	return f, nil
+}
+
+// IPP reads the state of an IPP printer from its printer-state and
+// printer-state-reasons attributes.
+type IPP struct {
+	Client *ipp.Client
+	URI    string
+}
+
+// reasons maps printer-state-reasons keywords, without their severity
+// suffix, to flags.
+var reasons = map[string]Flags{
+	"offline":              Offline,
+	"shutdown":             Offline,
+	"timed-out":            Offline,
+	"paused":               Paused,
+	"moving-to-paused":     Paused,
+	"media-empty":          PaperOut,
+	"media-needed":         PaperOut,
+	"input-tray-missing":   PaperOut,
+	"media-low":            PaperLow,
+	"media-jam":            PaperJam,
+	"toner-low":            TonerLow,
+	"marker-supply-low":    TonerLow,
+	"toner-empty":          TonerLow | Error,
+	"marker-supply-empty":  TonerLow | Error,
+	"door-open":            DoorOpen,
+	"cover-open":           DoorOpen,
+	"interlock-open":       DoorOpen,
+	"output-area-full":     Error,
+	"other":                Error,
+	"connecting-to-device": Offline,
+}
+
+// Status returns the state of the printer. Reasons reported with the
+// "-error" suffix and not known otherwise set Error.
+func (s *IPP) Status(ctx context.Context) (Flags, error) {
+	c := s.Client
+	if c == nil {
+		c = &ipp.Client{}
+	}
+	as, err := c.GetPrinterAttributesContext(ctx, s.URI, "printer-state", "printer-state-reasons")
+	if err != nil {
+		return 0, err
+	}
+	var f Flags
+	switch as.Get("printer-state").Int() {
+	case ipp.PrinterProcessing:
+		f |= Busy
+	case ipp.PrinterStopped:
+		f |= Paused
+	}
+	for _, r := range as.Get("printer-state-reasons").Strings() {
+		key := r
+		for _, suffix := range []string{"-report", "-warning", "-error"} {
+			key = strings.TrimSuffix(key, suffix)
+		}
+		switch flag, ok := reasons[key]; {
+		case ok:
+			f |= flag
+		case strings.HasSuffix(r, "-error"):
+			f |= Error
+		}
+	}
+	return f, nil
+}
@@ -0,0 +1,158 @@
+// Package status watches the state of printers, so that a point of sale can
+// warn the cashier before a receipt is lost.
+//
+// A Source reads the state of one printer: the Windows spooler, an ESC/POS
+// printer answering DLE EOT, an IPP printer or any printer speaking SNMP.
+// Watch polls it and reports each change on a channel:
+//
+//	src, _ := status.NewSpooler("EPSON TM-T20")
+//	defer src.Close()
+//	for ev := range status.Watch(ctx, src, 0) {
+//		if ev.Changed&status.PaperOut != 0 && ev.Status&status.PaperOut != 0 {
+//			warn("plus de papier")
+//		}
+//	}
+package status
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/sipkg/golang-win32-printer/win32"
+)
+
+// Flags is a set of printer conditions. No flag set means the printer is
+// ready.
+type Flags uint32
+
+const (
+	Offline Flags = 1 << iota
+	Paused
+	PaperOut
+	PaperLow
+	PaperJam
+	TonerLow
+	DoorOpen
+	Busy
+	Error
+)
+
+var flagNames = []string{"offline", "paused", "paper out", "paper low", "paper jam",
+	"toner low", "door open", "busy", "error"}
+
+// String returns the names of the flags separated by '|', "ready" when
+// none is set.
+func (f Flags) String() string {
+	var names []string
+	for i, name := range flagNames {
+		if f&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "ready"
+	}
+	return strings.Join(names, "|")
+}
+
+// Source reads the state of a printer.
+type Source interface {
+	Status(ctx context.Context) (Flags, error)
+}
+
+// Waiter is implemented by sources notified of changes, such as the
+// Windows spooler, so that Watch does not need to poll them.
+type Waiter interface {
+	// Wait returns when the state of the printer may have changed, or
+	// after timeout.
+	Wait(ctx context.Context, timeout time.Duration) error
+}
+
+// DefaultInterval is the polling interval of Watch when none is given.
+const DefaultInterval = 5 * time.Second
+
+// Event reports a change of state.
+type Event struct {
+	Time   time.Time
+	Status Flags
+	// Changed holds the flags set or cleared since the previous event.
+	Changed Flags
+	// Err is the error met reading the state, Status then being the last
+	// state known.
+	Err error
+}
+
+var now = time.Now
+
+// Watch reads the state of src every interval, or as soon as it changes
+// for sources implementing Waiter, and sends an event with the first state
+// read and then with every change, including errors and recoveries. The
+// channel is closed when ctx is done.
+func Watch(ctx context.Context, src Source, interval time.Duration) <-chan Event {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ch := make(chan Event)
+	go func() {
+		defer close(ch)
+		var last Event
+		for first := true; ; first = false {
+			flags, err := src.Status(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			ev := Event{Time: now(), Status: flags, Err: err}
+			if err != nil {
+				ev.Status = last.Status
+			}
+			ev.Changed = ev.Status ^ last.Status
+			if first || ev.Changed != 0 || errString(err) != errString(last.Err) {
+				select {
+				case ch <- ev:
+				case <-ctx.Done():
+					return
+				}
+			}
+			last = ev
+			if w, ok := src.(Waiter); ok && w.Wait(ctx, interval) == nil {
+				continue
+			}
+			select {
+			case <-time.After(interval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// FromPrinterStatus converts the PRINTER_STATUS flags of the Windows
+// spooler.
+func FromPrinterStatus(status uint32) Flags {
+	var f Flags
+	set := func(flag Flags, mask uint32) {
+		if status&mask != 0 {
+			f |= flag
+		}
+	}
+	set(Offline, win32.PRINTER_STATUS_OFFLINE|win32.PRINTER_STATUS_NOT_AVAILABLE|win32.PRINTER_STATUS_SERVER_UNKNOWN)
+	set(Paused, win32.PRINTER_STATUS_PAUSED)
+	set(PaperOut, win32.PRINTER_STATUS_PAPER_OUT)
+	set(PaperJam, win32.PRINTER_STATUS_PAPER_JAM)
+	set(TonerLow, win32.PRINTER_STATUS_TONER_LOW|win32.PRINTER_STATUS_NO_TONER)
+	set(DoorOpen, win32.PRINTER_STATUS_DOOR_OPEN)
+	set(Busy, win32.PRINTER_STATUS_BUSY|win32.PRINTER_STATUS_PRINTING|win32.PRINTER_STATUS_PROCESSING|
+		win32.PRINTER_STATUS_INITIALIZING|win32.PRINTER_STATUS_WARMING_UP)
+	set(Error, win32.PRINTER_STATUS_ERROR|win32.PRINTER_STATUS_NO_TONER|win32.PRINTER_STATUS_PAPER_PROBLEM|
+		win32.PRINTER_STATUS_USER_INTERVENTION|win32.PRINTER_STATUS_OUT_OF_MEMORY|win32.PRINTER_STATUS_OUTPUT_BIN_FULL)
+	return f
+}
@@ -0,0 +1,225 @@
+package status
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipkg/golang-win32-printer/escpos"
+	"github.com/sipkg/golang-win32-printer/ipp"
+	"github.com/sipkg/golang-win32-printer/win32"
+)
+
+// script is a source returning its states in turn, then the last one.
+type script struct {
+	states []Flags
+	errs   []error
+	i      int
+}
+
+func (s *script) Status(ctx context.Context) (Flags, error) {
+	i := min(s.i, len(s.states)-1)
+	s.i++
+	return s.states[i], s.errs[i]
+}
+
+func TestWatch(t *testing.T) {
+	broken := errors.New("connection refused")
+	src := &script{
+		states: []Flags{0, 0, PaperLow, PaperLow, 0, PaperOut | Offline, PaperOut | Offline},
+		errs:   []error{nil, nil, nil, nil, broken, nil, nil},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var got []string
+	for ev := range Watch(ctx, src, time.Millisecond) {
+		s := ev.Status.String() + " changed " + ev.Changed.String()
+		if ev.Err != nil {
+			s += " " + ev.Err.Error()
+		}
+		got = append(got, s)
+		if len(got) == 4 {
+			cancel()
+		}
+	}
+	want := []string{
+		"ready changed ready",
+		"paper low changed paper low",
+		"paper low changed ready connection refused",
+		"offline|paper out changed offline|paper out|paper low",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("events:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestFromPrinterStatus(t *testing.T) {
+	got := FromPrinterStatus(win32.PRINTER_STATUS_PAPER_OUT | win32.PRINTER_STATUS_NO_TONER | win32.PRINTER_STATUS_PRINTING)
+	if want := PaperOut | TonerLow | Busy | Error; got != want {
+		t.Errorf("FromPrinterStatus() = %v, want %v", got, want)
+	}
+	if got := FromPrinterStatus(0); got != 0 || got.String() != "ready" {
+		t.Errorf("FromPrinterStatus(0) = %v", got)
+	}
+}
+
+// escposPrinter answers DLE EOT requests with the status bytes of each
+// kind.
+type escposPrinter struct {
+	answers map[byte]byte
+	out     bytes.Buffer
+}
+
+func (p *escposPrinter) Write(b []byte) (int, error) {
+	if len(b) != 3 || b[0] != escpos.DLE || b[1] != escpos.EOT {
+		return 0, errors.New("not a status request")
+	}
+	p.out.WriteByte(p.answers[b[2]])
+	return len(b), nil
+}
+
+func (p *escposPrinter) Read(b []byte) (int, error) {
+	return p.out.Read(b)
+}
+
+func TestESCPOS(t *testing.T) {
+	p := &escposPrinter{answers: map[byte]byte{
+		1: 0x12 | 0x08, // offline
+		2: 0x12 | 0x04, // cover open
+		3: 0x12,
+		4: 0x12 | 0x0C, // paper near end
+	}}
+	src := &ESCPOS{Conn: p}
+	f, err := src.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := Offline | DoorOpen | PaperLow; f != want {
+		t.Errorf("Status() = %v, want %v", f, want)
+	}
+	p.answers[2] = 0x12 | 0x40
+	p.answers[3] = 0x12 | 0x08
+	if f, _ = src.Status(context.Background()); f != Offline|PaperLow|Error|PaperJam {
+		t.Errorf("Status() with a cutter error = %v", f)
+	}
+}
+
+// silentPrinter accepts requests and never answers them.
+type silentPrinter struct{ closed chan struct{} }
+
+func (p silentPrinter) Write(b []byte) (int, error) { return len(b), nil }
+
+func (p silentPrinter) Read(b []byte) (int, error) {
+	<-p.closed
+	return 0, io.EOF
+}
+
+func TestStatusCanceled(t *testing.T) {
+	closed := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-closed
+	}))
+	defer s.Close()
+	defer close(closed)
+	for _, src := range []Source{
+		&ESCPOS{Conn: silentPrinter{closed}},
+		&IPP{URI: strings.Replace(s.URL, "http://", "ipp://", 1)},
+	} {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		_, err := src.Status(ctx)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%T.Status() of a printer not answering: error %v", src, err)
+		}
+	}
+}
+
+func TestIPP(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := ipp.Decode(r.Body)
+		if err != nil || req.Code != ipp.OpGetPrinterAttributes {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		resp := ipp.NewRequest(0, req.RequestID)
+		resp.Add(ipp.TagPrinter, ipp.NewAttribute("printer-state", ipp.TagEnum, int32(ipp.PrinterStopped)))
+		resp.Add(ipp.TagPrinter, ipp.NewAttribute("printer-state-reasons", ipp.TagKeyword,
+			"media-empty-error", "toner-low-report", "fuser-over-temp-error"))
+		b, _ := resp.MarshalBinary()
+		w.Header().Set("Content-Type", "application/ipp")
+		w.Write(b)
+	}))
+	defer s.Close()
+	src := &IPP{URI: strings.Replace(s.URL, "http://", "ipp://", 1)}
+	f, err := src.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := Paused | PaperOut | TonerLow | Error; f != want {
+		t.Errorf("Status() = %v, want %v", f, want)
+	}
+}
+
+func TestSNMP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	go func() {
+		buf := make([]byte, 1500)
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		// Take the request id from the request, and check its objects.
+		msg, _, _ := expect(buf[:n], berSequence)
+		_, msg, _ = expect(msg, berInteger)
+		community, msg, _ := expect(msg, berOctetString)
+		pdu, _, _ := expect(msg, berGetRequest)
+		id, _, _ := expect(pdu, berInteger)
+		if string(community) != "public" || !bytes.Contains(pdu, berOIDValue(oidDetectedErrorState)) {
+			return
+		}
+		binds := tlv(berSequence, append(berOIDValue(oidDeviceStatus), berInt(3)...))
+		binds = append(binds, tlv(berSequence, append(berOIDValue(oidPrinterStatus), berInt(4)...))...)
+		binds = append(binds, tlv(berSequence, append(berOIDValue(oidDetectedErrorState),
+			tlv(berOctetString, []byte{0x48, 0x04})...))...)
+		stale := append(berInt(0), tlv(berOctetString, community)...)
+		stale = append(stale, tlv(berGetResponse, append(append(append(berInt(1), berInt(0)...), berInt(0)...), berSequence, 0))...)
+		conn.WriteTo(tlv(berSequence, stale), addr)
+		resp := append(tlv(berInteger, id), berInt(0)...)
+		resp = append(resp, berInt(0)...)
+		resp = append(resp, tlv(berSequence, binds)...)
+		m := append(berInt(0), tlv(berOctetString, community)...)
+		m = append(m, tlv(berGetResponse, resp)...)
+		conn.WriteTo(tlv(berSequence, m), addr)
+	}()
+	src := &SNMP{Addr: conn.LocalAddr().String()}
+	f, err := src.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := PaperOut | DoorOpen | Busy; f != want {
+		t.Errorf("Status() = %v, want %v", f, want)
+	}
+	if got := berOIDValue([]int{1, 3, 6, 1, 2, 1, 25, 200}); !bytes.Equal(got, []byte{6, 8, 0x2b, 6, 1, 2, 1, 25, 0x81, 0x48}) {
+		t.Errorf("OID encoded as % x", got)
+	}
+}
+
+func TestSpoolerWaitCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var s Spooler
+	if err := s.Wait(ctx, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait() = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,150 @@
+package ticket
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sipkg/golang-win32-printer/escpos"
+	"github.com/sipkg/golang-win32-printer/layout"
+	"github.com/sipkg/golang-win32-printer/printer"
+)
+
+// Characters per line of font A on common receipt printers.
+const (
+	Columns80mm       = 48
+	Columns80mmNarrow = 42
+	Columns58mm       = 32
+)
+
+// Widths of the unit price and total price columns. Narrower papers print
+// the name of an article on its own line, above its prices.
+const (
+	unitColumn  = 12
+	totalColumn = 10
+	minName     = 16
+)
+
+// PrintESCPOS prints the ticket as raw ESC/POS on the receipt printer named
+// printName, which prints columns characters per line.
+func PrintESCPOS(printName string, columns int, pdv Pdv, ticket Ticket) error {
+	job, err := printer.NewRawJob(printName, "ticket", printer.DatatypeRAW)
+	if err != nil {
+		return err
+	}
+	if err := RenderESCPOS(job, columns, pdv, ticket); err != nil {
+		job.Close()
+		return err
+	}
+	return job.Close()
+}
+
+// RenderESCPOS writes the ticket to w as an ESC/POS stream for a printer
+// printing columns characters per line. The layout follows RenderA4: a bold
+// double size header, the articles in columns, the total right aligned and
+// the footer, and the paper is cut at the end.
+func RenderESCPOS(w io.Writer, columns int, pdv Pdv, ticket Ticket) error {
+	if columns < Columns58mm {
+		return fmt.Errorf("ticket: %d columns is too narrow", columns)
+	}
+	p := escpos.NewWriter(w)
+	p.Init()
+	// PC858 is PC850 with the euro sign.
+	p.CodePage(escpos.PC858)
+	separator := strings.Repeat("-", columns)
+
+	// En-tête
+	p.Align(escpos.Center)
+	p.Bold(true)
+	p.Size(2, 2)
+	for _, line := range wrap(pdv.Nom, columns/2) {
+		p.Line(line)
+	}
+	p.Size(1, 1)
+	p.Bold(false)
+	for _, info := range []string{pdv.Adresse, pdv.Tel, pdv.Mail} {
+		for _, line := range wrap(info, columns) {
+			p.Line(line)
+		}
+	}
+	p.Line(now().Format("02/01/2006 15:04:05"))
+	p.Align(escpos.Left)
+	p.Line(separator)
+
+	// Articles
+	totalArticles := 0
+	name := columns - unitColumn - totalColumn
+	for _, article := range ticket.Articles {
+		totalArticles += article.Quantite
+		cols := articleColumns(article)
+		if name >= minName {
+			p.Line(pad(cols[0], name) + pad(cols[1], unitColumn) + padLeft(cols[2], totalColumn))
+		} else {
+			p.Line(layout.TruncateString(cols[0], columns))
+			p.Line("  " + pad(cols[1], columns-2-totalColumn) + padLeft(cols[2], totalColumn))
+		}
+	}
+	p.Line(separator)
+
+	// Total
+	p.Bold(true)
+	p.DoubleHeight(true)
+	total := formatPrice(ticket.Total)
+	p.Line(pad(totalLabel, columns-len([]rune(total))) + total)
+	p.DoubleHeight(false)
+	p.Bold(false)
+	p.Line(separator)
+
+	// Pied de page
+	p.Align(escpos.Center)
+	for _, text := range []string{thanks, countLine(totalArticles)} {
+		for _, line := range wrap(text, columns) {
+			p.Line(line)
+		}
+	}
+	p.FeedAndCut(escpos.PartialCut, 0)
+	return p.Err()
+}
+
+// pad truncates or pads text with spaces to width characters.
+func pad(text string, width int) string {
+	text = layout.TruncateString(text, width)
+	return text + strings.Repeat(" ", width-len([]rune(text)))
+}
+
+// padLeft is pad aligning text to the right.
+func padLeft(text string, width int) string {
+	text = layout.TruncateString(text, width)
+	return strings.Repeat(" ", width-len([]rune(text))) + text
+}
+
+// wrap splits text into lines of at most width characters, breaking at
+// spaces when possible.
+func wrap(text string, width int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		for len([]rune(word)) > width {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			r := []rune(word)
+			lines = append(lines, string(r[:width]))
+			word = string(r[width:])
+		}
+		switch {
+		case line == "":
+			line = word
+		case len([]rune(line))+1+len([]rune(word)) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
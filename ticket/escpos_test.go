@@ -0,0 +1,81 @@
+package ticket
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sipkg/golang-win32-printer/escpos"
+	"github.com/sipkg/golang-win32-printer/escpos/emulator"
+)
+
+func TestRenderESCPOS(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderESCPOS(&buf, Columns80mm, testPdv, testTicket); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.Bytes()
+	// PC858 encodes 'à' as 0x85 and '€' as 0xD5.
+	row := "2 x Article 1             \x85 150.00 \xd5    300.00 \xd5\n"
+	if !bytes.Contains(out, []byte(row)) {
+		t.Errorf("article row %q not found in %q", row, out)
+	}
+	if !bytes.Contains(out, []byte("01/03/2024 12:30:00\n")) {
+		t.Error("timestamp not found")
+	}
+	total := "Total \x85 payer:" + strings.Repeat(" ", 26) + "305.00 \xd5\n"
+	if !bytes.Contains(out, []byte(total)) {
+		t.Errorf("total line %q not found in %q", total, out)
+	}
+	if !bytes.HasSuffix(out, []byte{0x1d, 'V', 66, 0}) {
+		t.Errorf("output does not end with a partial cut: % x", out[len(out)-4:])
+	}
+}
+
+func TestRenderESCPOSNarrow(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderESCPOS(&buf, Columns58mm, testPdv, testTicket); err != nil {
+		t.Fatal(err)
+	}
+	rows := "2 x Article 1\n  \x85 150.00 \xd5            300.00 \xd5\n"
+	if !bytes.Contains(buf.Bytes(), []byte(rows)) {
+		t.Errorf("article rows %q not found in %q", rows, buf.Bytes())
+	}
+	if err := RenderESCPOS(&buf, 20, testPdv, testTicket); err == nil {
+		t.Error("RenderESCPOS accepted 20 columns")
+	}
+}
+
+func TestRenderESCPOSPrints(t *testing.T) {
+	e := emulator.New(escpos.Dots80mm)
+	if err := RenderESCPOS(e, Columns80mm, testPdv, testTicket); err != nil {
+		t.Fatal(err)
+	}
+	if u := e.Unsupported(); len(u) != 0 {
+		t.Errorf("unsupported commands %v", u)
+	}
+	paper := e.Paper()
+	if cuts := e.Cuts(); len(cuts) != 1 || cuts[0] != paper.Rect.Dy() {
+		t.Errorf("cuts at %v, paper %d dots long", cuts, paper.Rect.Dy())
+	}
+	// 13 lines, of which the header and the total are twice as high.
+	if h, want := paper.Rect.Dy(), 11*emulator.DefaultLineSpacing+2*48; h != want {
+		t.Errorf("paper is %d dots long, want %d", h, want)
+	}
+	// Article rows fill the whole width.
+	row := 48 + 5*emulator.DefaultLineSpacing
+	left, right := -1, -1
+	for y := row; y < row+emulator.DefaultLineSpacing; y++ {
+		for x := 0; x < paper.Rect.Dx(); x++ {
+			if paper.Black(x, y) {
+				if left < 0 || x < left {
+					left = x
+				}
+				right = max(right, x)
+			}
+		}
+	}
+	if left > 4 || right < escpos.Dots80mm-8 {
+		t.Errorf("article row spans %d to %d", left, right)
+	}
+}
@@ -2,14 +2,19 @@ package ticket
 
 import (
 	"fmt"
+	"image/color"
 	"log"
-	"syscall"
 	"time"
 
+	"github.com/sipkg/golang-win32-printer/device"
 	"github.com/sipkg/golang-win32-printer/layout"
+	"github.com/sipkg/golang-win32-printer/printer"
 	"github.com/sipkg/golang-win32-printer/win32"
 )
 
+// now returns the time printed in the header, replaced by tests.
+var now = time.Now
+
 type Article struct {
 	Nom      string
 	Prix     float64
@@ -30,37 +35,63 @@ type Pdv struct {
 	Mail    string
 }
 
+// Texts shared by the A4 and ESC/POS layouts.
+const (
+	totalLabel = "Total à payer:"
+	thanks     = "Nous vous remercions de votre visite !"
+	ticketNum  = 123456789
+)
+
+func formatPrice(price float64) string {
+	return fmt.Sprintf("%.2f €", price)
+}
+
+// articleColumns returns the quantity and name, unit price and total price
+// columns of an article.
+func articleColumns(article Article) [3]string {
+	return [3]string{
+		fmt.Sprintf("%d x %s", article.Quantite, article.Nom),
+		"à " + formatPrice(article.Prix),
+		formatPrice(article.Prix * float64(article.Quantite)),
+	}
+}
+
+func countLine(totalArticles int) string {
+	return fmt.Sprintf("Nombre d'articles: %d, Ticket n° %d", totalArticles, ticketNum)
+}
+
 func formatArticles(ticket Ticket) ([][]string, int) {
 	var formattedArticles [][]string
 	totalArticles := 0
 	for _, article := range ticket.Articles {
 		totalArticles += article.Quantite
+		cols := articleColumns(article)
 		formattedArticle := []string{
-			fmt.Sprintf("%-35s", layout.TruncateString(fmt.Sprintf("%d x %s", article.Quantite, article.Nom), 35)),        // Première colonne, largeur fixe de 35 caractères
-			fmt.Sprintf("%-15s", layout.TruncateString(fmt.Sprintf("à %.2f €", article.Prix), 15)),                        // Deuxième colonne, largeur fixe de 15 caractères
-			fmt.Sprintf("%10s", layout.TruncateString(fmt.Sprintf("%.2f €", article.Prix*float64(article.Quantite)), 10)), // Troisième colonne, largeur fixe de 10 caractères
+			fmt.Sprintf("%-35s", layout.TruncateString(cols[0], 35)), // Première colonne, largeur fixe de 35 caractères
+			fmt.Sprintf("%-15s", layout.TruncateString(cols[1], 15)), // Deuxième colonne, largeur fixe de 15 caractères
+			fmt.Sprintf("%10s", layout.TruncateString(cols[2], 10)),  // Troisième colonne, largeur fixe de 10 caractères
 		}
 		formattedArticles = append(formattedArticles, formattedArticle)
 	}
 	return formattedArticles, totalArticles
 }
 
-func DrawSeparator(dc win32.HDC, pageWidth, startY uint32) uint32 {
+func DrawSeparator(c device.Canvas, pageWidth, startY uint32) uint32 {
 	// Dessiner la ligne de séparation
-	_, _ = win32.MoveTo(dc, 0, startY)
-	win32.LineTo(dc, pageWidth, startY)
+	c.MoveTo(0, startY)
+	c.LineTo(pageWidth, startY)
 
 	return startY + 100
 }
 
-func DrawArticlesTab(dc win32.HDC, pageWidth, margin, startY uint32, ticket Ticket) (uint32, int, float64) {
+func DrawArticlesTab(c device.Canvas, pageWidth, margin, startY uint32, ticket Ticket) (uint32, int, float64) {
 	formattedArticles, totalArticles := formatArticles(ticket)
 
 	for _, article := range formattedArticles {
 		// Dessine la première colonne
 		text := article[0]
 		x := layout.AlignLeft() + margin
-		err := win32.TextOut(dc, x, startY, text, uint32(len(text)))
+		err := c.TextOut(x, startY, text)
 		if err != nil {
 			log.Printf("TextOut failed: %s", err)
 		}
@@ -68,20 +99,20 @@ func DrawArticlesTab(dc win32.HDC, pageWidth, margin, startY uint32, ticket Tick
 		// Dessine la deuxième colonne
 		text = article[1]
 		x = layout.AlignLeftFrom(4 * pageWidth / 7)
-		err = win32.TextOut(dc, x, startY, text, uint32(len(text)))
+		err = c.TextOut(x, startY, text)
 		if err != nil {
 			log.Printf("TextOut failed: %s", err)
 		}
 
 		// Dessine la troisième colonne
 		text = article[2]
-		textWidth, textHeight, err := win32.GetTextExtentPoint32(syscall.Handle(dc), text)
+		textWidth, textHeight, err := c.GetTextExtentPoint32(text)
 		if err != nil {
 			log.Printf("Get text dimensions failed: %s", err)
 			continue
 		}
 		x = layout.AlignRight(pageWidth-margin, textWidth)
-		err = win32.TextOut(dc, x, startY, text, uint32(len(text)))
+		err = c.TextOut(x, startY, text)
 		if err != nil {
 			log.Printf("TextOut failed: %s", err)
 		}
@@ -89,38 +120,38 @@ func DrawArticlesTab(dc win32.HDC, pageWidth, margin, startY uint32, ticket Tick
 		startY += textHeight + 20 // Incrémente la position verticale pour le prochain article
 	}
 
-	startY = DrawSeparator(dc, pageWidth, startY)
+	startY = DrawSeparator(c, pageWidth, startY)
 
 	return startY, totalArticles, ticket.Total
 }
 
-func DrawHeader(dc win32.HDC, pageWidth, startY uint32, pdv Pdv) uint32 {
+func DrawHeader(c device.Canvas, pageWidth, startY uint32, pdv Pdv) uint32 {
 	// Dessiner le nom du PDV en gras
-	win32.SetBoldFont(dc, true)
+	c.SetBoldFont(true)
 
 	text := pdv.Nom
-	textWidth, textHeight, err := win32.GetTextExtentPoint32(syscall.Handle(dc), text)
+	textWidth, textHeight, err := c.GetTextExtentPoint32(text)
 	if err != nil {
 		log.Printf("Get text dimensions failed: %s", err)
 	}
-	originalTextHeight, err := win32.SetTextSize(dc, int32(5*textHeight/3))
+	originalTextHeight, err := c.SetTextSize(int32(5 * textHeight / 3))
 	if err != nil {
 		log.Printf("SetTextSize failed: %s", err)
 	}
 	x := layout.CenterElement(pageWidth, 5*textWidth/3)
-	err = win32.TextOut(dc, x, startY, text, uint32(len(text)))
+	err = c.TextOut(x, startY, text)
 	if err != nil {
 		log.Printf("TextOut failed: %s", err)
 	}
 	startY += 5*textHeight/3 + 50
 
-	_, err = win32.SetTextSize(dc, originalTextHeight)
+	_, err = c.SetTextSize(originalTextHeight)
 	if err != nil {
 		log.Printf("SetTextSize failed: %s", err)
 	}
 
 	// Dessiner les autres informations du PDV
-	win32.SetBoldFont(dc, false)
+	c.SetBoldFont(false)
 
 	infos := []string{
 		pdv.Adresse,
@@ -129,12 +160,12 @@ func DrawHeader(dc win32.HDC, pageWidth, startY uint32, pdv Pdv) uint32 {
 	}
 
 	for _, info := range infos {
-		textWidth, textHeight, err := win32.GetTextExtentPoint32(syscall.Handle(dc), info)
+		textWidth, textHeight, err := c.GetTextExtentPoint32(info)
 		if err != nil {
 			log.Printf("Get text dimensions failed: %s", err)
 		}
 		x = layout.CenterElement(pageWidth, textWidth)
-		err = win32.TextOut(dc, x, startY, info, uint32(len(info)))
+		err = c.TextOut(x, startY, info)
 		if err != nil {
 			log.Printf("TextOut failed: %s", err)
 		}
@@ -142,140 +173,150 @@ func DrawHeader(dc win32.HDC, pageWidth, startY uint32, pdv Pdv) uint32 {
 	}
 	startY += 20
 
-	timestamp := time.Now().Format("02/01/2006 15:04:05")
-	textWidth, textHeight, err = win32.GetTextExtentPoint32(syscall.Handle(dc), timestamp)
+	timestamp := now().Format("02/01/2006 15:04:05")
+	textWidth, textHeight, err = c.GetTextExtentPoint32(timestamp)
 	if err != nil {
 		log.Printf("Get text dimensions failed: %s", err)
 	}
 	x = layout.CenterElement(pageWidth, textWidth)
-	err = win32.TextOut(dc, x, startY, timestamp, uint32(len(timestamp)))
+	err = c.TextOut(x, startY, timestamp)
 	if err != nil {
 		log.Printf("TextOut failed: %s", err)
 	}
 	startY += textHeight + 30
 
-	startY = DrawSeparator(dc, pageWidth, startY)
+	startY = DrawSeparator(c, pageWidth, startY)
 
 	return startY
 }
 
-func DrawFooter(dc win32.HDC, pageWidth, margin, startY uint32, totalArticles int, total float64) {
+func DrawFooter(c device.Canvas, pageWidth, margin, startY uint32, totalArticles int, total float64) {
 	totalLine := []string{
-		"Total à payer:",
-		fmt.Sprintf("%10s", fmt.Sprintf("%2.f €", total)),
+		totalLabel,
+		fmt.Sprintf("%10s", formatPrice(total)),
 	}
 
-	win32.SetBoldFont(dc, true)
+	c.SetBoldFont(true)
 
-	textWidth, textHeight, err := win32.GetTextExtentPoint32(syscall.Handle(dc), totalLine[0])
+	textWidth, textHeight, err := c.GetTextExtentPoint32(totalLine[0])
 	if err != nil {
 		log.Printf("Get text dimensions failed: %s", err)
 	}
-	originalTextHeight, err := win32.SetTextSize(dc, int32(6*textHeight/5))
+	originalTextHeight, err := c.SetTextSize(int32(6 * textHeight / 5))
 	if err != nil {
 		log.Printf("SetTextSize failed: %s", err)
 	}
 	x := layout.CenterElement(pageWidth/2, textWidth)
-	err = win32.TextOut(dc, x, startY, totalLine[0], uint32(len(totalLine[0])))
+	err = c.TextOut(x, startY, totalLine[0])
 	if err != nil {
 		log.Printf("TextOut failed: %s", err)
 	}
 
-	textWidth, textHeight, err = win32.GetTextExtentPoint32(syscall.Handle(dc), totalLine[1])
+	textWidth, textHeight, err = c.GetTextExtentPoint32(totalLine[1])
 	if err != nil {
 		log.Printf("Get text dimensions failed: %s", err)
 	}
 	x = layout.AlignRight(pageWidth-margin, textWidth)
 
-	err = win32.TextOut(dc, x, startY, totalLine[1], uint32(len(totalLine[1])))
+	err = c.TextOut(x, startY, totalLine[1])
 	if err != nil {
 		log.Printf("TextOut failed: %s", err)
 	}
 
 	startY += textHeight + 100
 
-	win32.SetBoldFont(dc, false)
-	win32.SetTextSize(dc, int32(originalTextHeight))
+	c.SetBoldFont(false)
+	c.SetTextSize(int32(originalTextHeight))
 
-	startY = DrawSeparator(dc, pageWidth, startY)
+	startY = DrawSeparator(c, pageWidth, startY)
 
-	text := "Nous vous remercions de votre visite !"
-	textWidth, textHeight, err = win32.GetTextExtentPoint32(syscall.Handle(dc), text)
+	text := thanks
+	textWidth, textHeight, err = c.GetTextExtentPoint32(text)
 	if err != nil {
 		log.Printf("Get text dimensions failed: %s", err)
 	}
 	x = layout.CenterElement(pageWidth, textWidth)
-	err = win32.TextOut(dc, x, startY, text, uint32(len(text)))
+	err = c.TextOut(x, startY, text)
 	if err != nil {
 		log.Printf("TextOut failed: %s", err)
 	}
 
 	startY += textHeight + 20
 
-	ticketNum := 123456789
-	text = fmt.Sprintf("Nombre d'articles: %d, Ticket n° %d\n", totalArticles, ticketNum)
-	textWidth, _, err = win32.GetTextExtentPoint32(syscall.Handle(dc), text)
+	text = countLine(totalArticles)
+	textWidth, _, err = c.GetTextExtentPoint32(text)
 	if err != nil {
 		log.Printf("Get text dimensions failed: %s", err)
 	}
 	x = layout.CenterElement(pageWidth, textWidth)
-	err = win32.TextOut(dc, x, startY, text, uint32(len(text)))
+	err = c.TextOut(x, startY, text)
 	if err != nil {
 		log.Printf("TextOut failed: %s", err)
 	}
 }
 
+// PrintA4 prints the ticket on the printer named printName.
 func PrintA4(printName string, margin uint32, textHeight int32, pdv Pdv, ticket Ticket) {
-	dc, err := win32.CreateDC(printName)
+	p := &printer.Printer{}
+	err := p.InitPrinter(printName)
 	if err != nil {
 		log.Fatalf("CreateDC failed: %s", err)
 	}
-	err = win32.StartDCPrinter(dc, "gdiDoc")
+	err = RenderA4(p, margin, textHeight, pdv, ticket)
 	if err != nil {
-		log.Fatalf("StartDCPrinter failed: %s", err)
+		log.Fatalf("RenderA4 failed: %s", err)
 	}
-	err = win32.StartPage(dc)
+	err = p.Close()
 	if err != nil {
-		log.Fatalf("StartPage failed: %s", err)
+		log.Printf("DeleteDC failed: %s", err)
+	}
+}
+
+// RenderA4 draws the ticket as a one page document on d.
+func RenderA4(d device.Device, margin uint32, textHeight int32, pdv Pdv, ticket Ticket) error {
+	err := d.StartDoc("gdiDoc")
+	if err != nil {
+		return fmt.Errorf("StartDoc: %w", err)
+	}
+	err = d.StartPage()
+	if err != nil {
+		return fmt.Errorf("StartPage: %w", err)
 	}
 
-	win32.SetFont(dc, win32.CourierNew)
-	width, err := win32.GetDeviceCaps(dc, win32.HORZRES)
+	d.SetFont(win32.CourierNew)
+	width, err := d.GetDeviceCaps(device.HorzRes)
 	if err != nil {
-		log.Fatalf("Retreiving page width failed: %s", err)
+		return fmt.Errorf("retreiving page width: %w", err)
 	}
-	_, err = win32.GetDeviceCaps(dc, win32.VERTRES)
+	_, err = d.GetDeviceCaps(device.VertRes)
 	if err != nil {
-		log.Fatalf("Retreiving page height failed: %s", err)
+		return fmt.Errorf("retreiving page height: %w", err)
 	}
 
-	oldcol, err := win32.SetTextColor(dc, win32.RGB(0, 0, 0))
+	oldcol, err := d.SetTextColor(color.Black)
 	if err != nil {
 		log.Printf("SetTextColor failed: %s", err)
 	}
 	log.Printf("Before color was %v", oldcol)
 
-	_, err = win32.SetTextSize(dc, textHeight)
+	_, err = d.SetTextSize(textHeight)
 	if err != nil {
 		log.Printf("SetTextSize failed: %s", err)
 	}
 
-	headerStopsY := DrawHeader(dc, width, margin, pdv)
+	headerStopsY := DrawHeader(d, width, margin, pdv)
 
-	tabStopsY, totalArticles, aPayer := DrawArticlesTab(dc, width, margin, headerStopsY, ticket)
+	tabStopsY, totalArticles, aPayer := DrawArticlesTab(d, width, margin, headerStopsY, ticket)
 
-	DrawFooter(dc, width, margin, tabStopsY, totalArticles, aPayer)
+	DrawFooter(d, width, margin, tabStopsY, totalArticles, aPayer)
 
-	err = win32.EndPage(dc)
+	err = d.EndPage()
 	if err != nil {
 		log.Printf("EndPage failed: %s", err)
 	}
-	err = win32.EndDoc(dc)
+	err = d.EndDoc()
 	if err != nil {
 		log.Printf("EndDoc failed: %s", err)
 	}
-	err = win32.DeleteDC(dc)
-	if err != nil {
-		log.Printf("DeleteDC failed: %s", err)
-	}
+	return nil
 }
@@ -0,0 +1,93 @@
+package ticket
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipkg/golang-win32-printer/device"
+	"github.com/sipkg/golang-win32-printer/device/raster"
+	"github.com/sipkg/golang-win32-printer/device/record"
+)
+
+var testPdv = Pdv{
+	ID:      1,
+	Nom:     "Mon Magasin",
+	Adresse: "123 Rue Exemple, 75000 Paris",
+	Tel:     "01 23 45 67 89",
+	Mail:    "contact@monmagasin.fr",
+}
+
+var testTicket = Ticket{
+	PdvID: 1,
+	Articles: []Article{
+		{Nom: "Article 1", Quantite: 2, Prix: 150.0},
+		{Nom: "Article 2", Quantite: 1, Prix: 5.0},
+	},
+	Total: 305,
+}
+
+func init() {
+	now = func() time.Time { return time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC) }
+}
+
+func TestFormatArticles(t *testing.T) {
+	rows, total := formatArticles(testTicket)
+	if total != 3 {
+		t.Errorf("total articles = %d, want 3", total)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("len(rows) = %d, want 2", len(rows))
+	}
+	want := []string{
+		"2 x Article 1                      ",
+		"à 150.00 €     ",
+		"  300.00 €",
+	}
+	for i, col := range rows[0] {
+		if col != want[i] {
+			t.Errorf("column %d = %q, want %q", i, col, want[i])
+		}
+	}
+}
+
+func TestRenderA4(t *testing.T) {
+	r := record.New(device.A4, 300)
+	if err := RenderA4(r, 350, 64, testPdv, testTicket); err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	lastY := uint32(0)
+	for _, op := range r.List().Ops {
+		if op.Kind != record.TextOut {
+			continue
+		}
+		if op.Y < lastY {
+			t.Errorf("%q drawn above the previous line", op.Text)
+		}
+		if op.Font.Name != "Courier New" {
+			t.Errorf("%q drawn with %q", op.Text, op.Font.Name)
+		}
+		lastY = op.Y
+		texts = append(texts, strings.TrimSpace(op.Text))
+	}
+	for _, want := range []string{"Mon Magasin", "01/03/2024 12:30:00", "à 150.00 €", "Total à payer:"} {
+		found := false
+		for _, text := range texts {
+			found = found || text == want
+		}
+		if !found {
+			t.Errorf("%q not drawn, got %q", want, texts)
+		}
+	}
+}
+
+func TestRenderA4Raster(t *testing.T) {
+	r := raster.New(device.A4, 300)
+	if err := RenderA4(r, 350, 64, testPdv, testTicket); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Pages) != 1 {
+		t.Fatalf("len(Pages) = %d, want 1", len(r.Pages))
+	}
+}
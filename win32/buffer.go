@@ -0,0 +1,140 @@
+package win32
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unicode/utf16"
+)
+
+// ErrInvalidBuffer is returned when decoding a spooler buffer whose
+// structures point outside of it.
+var ErrInvalidBuffer = errors.New("win32: invalid spooler buffer")
+
+// Buffer is a buffer filled by a spooler function such as EnumPrinters: an
+// array of structures, followed by the strings and DEVMODEs they point to.
+// Its decoders check every offset, so that they can be tested on fixtures
+// and never read past the data.
+type Buffer struct {
+	Data []byte
+	// Addr is the address of Data[0] when the spooler filled it, which the
+	// pointers of the structures are relative to.
+	Addr uint64
+	// PtrSize is the size of pointers, 8 on 64 bit Windows and 4 on 32 bit.
+	PtrSize int
+}
+
+// Kinds of the fields of a spooler structure, for layout.
+const (
+	fieldPtr        = 'p'
+	fieldUint32     = 'u'
+	fieldSYSTEMTIME = 't'
+)
+
+// layout returns the offsets of fields, a string of field kinds, and the
+// size of the structure, aligned as by the C compiler.
+func (b *Buffer) layout(fields string) ([]int, int) {
+	offsets := make([]int, len(fields))
+	off, align := 0, 4
+	for i, f := range fields {
+		size, a := 4, 4
+		switch f {
+		case fieldPtr:
+			size, a = b.PtrSize, b.PtrSize
+		case fieldSYSTEMTIME:
+			size, a = 16, 2
+		}
+		off = (off + a - 1) / a * a
+		offsets[i] = off
+		off += size
+		align = max(align, a)
+	}
+	return offsets, (off + align - 1) / align * align
+}
+
+// structs returns the offsets of the fields of count structures.
+func (b *Buffer) structs(count int, fields string) ([][]int, error) {
+	if b.PtrSize != 4 && b.PtrSize != 8 {
+		return nil, fmt.Errorf("%w: pointer size %d", ErrInvalidBuffer, b.PtrSize)
+	}
+	offsets, size := b.layout(fields)
+	if count < 0 || count > len(b.Data)/size {
+		return nil, fmt.Errorf("%w: %d structures of %d bytes in %d bytes", ErrInvalidBuffer, count, size, len(b.Data))
+	}
+	all := make([][]int, count)
+	for i := range all {
+		all[i] = make([]int, len(offsets))
+		for j, off := range offsets {
+			all[i][j] = i*size + off
+		}
+	}
+	return all, nil
+}
+
+func (b *Buffer) uint32(off int) uint32 {
+	return binary.LittleEndian.Uint32(b.Data[off:])
+}
+
+// target returns the offset in Data of the pointer at off, -1 for NULL.
+func (b *Buffer) target(off int) (int, error) {
+	var p uint64
+	if b.PtrSize == 8 {
+		p = binary.LittleEndian.Uint64(b.Data[off:])
+	} else {
+		p = uint64(binary.LittleEndian.Uint32(b.Data[off:]))
+	}
+	if p == 0 {
+		return -1, nil
+	}
+	if p < b.Addr || p-b.Addr >= uint64(len(b.Data)) {
+		return 0, fmt.Errorf("%w: pointer %#x at offset %d", ErrInvalidBuffer, p, off)
+	}
+	return int(p - b.Addr), nil
+}
+
+// string returns the NUL terminated UTF-16 string pointed to by the
+// pointer at off, empty for NULL.
+func (b *Buffer) string(off int) (string, error) {
+	start, err := b.target(off)
+	if err != nil || start < 0 {
+		return "", err
+	}
+	var u []uint16
+	for i := start; ; i += 2 {
+		if i+2 > len(b.Data) {
+			return "", fmt.Errorf("%w: string at offset %d not terminated", ErrInvalidBuffer, start)
+		}
+		c := binary.LittleEndian.Uint16(b.Data[i:])
+		if c == 0 {
+			break
+		}
+		u = append(u, c)
+	}
+	return string(utf16.Decode(u)), nil
+}
+
+// strings decodes the strings pointed to at offs into dst, stopping at the
+// first error.
+func (b *Buffer) strings(offs []int, dst ...*string) error {
+	for i, s := range dst {
+		var err error
+		if *s, err = b.string(offs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// devMode returns the DEVMODE pointed to by the pointer at off, nil for
+// NULL.
+func (b *Buffer) devMode(off int) (*DEVMODE, error) {
+	start, err := b.target(off)
+	if err != nil || start < 0 {
+		return nil, err
+	}
+	dm := &DEVMODE{}
+	if err := dm.UnmarshalBinary(b.Data[start:]); err != nil {
+		return nil, fmt.Errorf("%w: DEVMODE at offset %d: %v", ErrInvalidBuffer, start, err)
+	}
+	return dm, nil
+}
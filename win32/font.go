@@ -0,0 +1,78 @@
+package win32
+
+import (
+	"encoding/binary"
+	"unicode/utf16"
+)
+
+// Constants
+const (
+	HFONT = 6 // Object type for getCurrentFont
+)
+
+const (
+	Arial         = "Arial"
+	TimesNewRoman = "Times New Roman"
+	CourierNew    = "Courier New"
+	Verdana       = "Verdana"
+)
+
+// LOGFONT contains information about a logical font
+type LOGFONT struct {
+	Height         int32
+	Width          int32
+	Escapement     int32
+	Orientation    int32
+	Weight         int32
+	Italic         byte
+	Underline      byte
+	StrikeOut      byte
+	CharSet        byte
+	OutPrecision   byte
+	ClipPrecision  byte
+	Quality        byte
+	PitchAndFamily byte
+	FaceName       [32]uint16
+}
+
+// COLORREF represents a Windows color value in BGR format
+type COLORREF uint32
+
+// RGB creates a COLORREF value from red, green, and blue components
+func RGB(r, g, b byte) COLORREF {
+	return COLORREF(uint32(b)<<16 | uint32(g)<<8 | uint32(r))
+}
+
+// SetFaceName sets the face name, truncated to the 31 characters LOGFONT
+// can hold.
+func (lf *LOGFONT) SetFaceName(name string) {
+	lf.FaceName = [32]uint16{}
+	copy(lf.FaceName[:31], utf16.Encode([]rune(name)))
+}
+
+// FaceNameString returns the face name.
+func (lf *LOGFONT) FaceNameString() string {
+	n := 0
+	for n < len(lf.FaceName) && lf.FaceName[n] != 0 {
+		n++
+	}
+	return string(utf16.Decode(lf.FaceName[:n]))
+}
+
+// MarshalBinary encodes the font as the 92 bytes LOGFONTW found in
+// metafiles.
+func (lf *LOGFONT) MarshalBinary() ([]byte, error) {
+	le := binary.LittleEndian
+	b := make([]byte, 0, 92)
+	b = le.AppendUint32(b, uint32(lf.Height))
+	b = le.AppendUint32(b, uint32(lf.Width))
+	b = le.AppendUint32(b, uint32(lf.Escapement))
+	b = le.AppendUint32(b, uint32(lf.Orientation))
+	b = le.AppendUint32(b, uint32(lf.Weight))
+	b = append(b, lf.Italic, lf.Underline, lf.StrikeOut, lf.CharSet,
+		lf.OutPrecision, lf.ClipPrecision, lf.Quality, lf.PitchAndFamily)
+	for _, c := range lf.FaceName {
+		b = le.AppendUint16(b, c)
+	}
+	return b, nil
+}
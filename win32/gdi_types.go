@@ -0,0 +1,191 @@
+package win32
+
+import (
+	"encoding/binary"
+	"unsafe"
+)
+
+// HDC is a device context handle returned by CreateDC.
+type HDC uintptr
+
+type DOCINFOA struct {
+	Size     uintptr
+	DocName  *uint16
+	Output   *uint16
+	DataType *uint16
+	Type     uint32
+}
+
+// NewDOCINFO returns the DOCINFO of a document. When output is not empty,
+// the driver output is written to that file instead of the printer.
+func NewDOCINFO(docName, output, datatype string) *DOCINFOA {
+	return &DOCINFOA{
+		Size:     unsafe.Sizeof(DOCINFOA{}),
+		DocName:  utf16Ptr(docName),
+		Output:   utf16Ptr(output),
+		DataType: utf16Ptr(datatype),
+	}
+}
+
+/* Device Parameters for GetDeviceCaps() */
+
+type PropType uint32
+
+const (
+	DRIVERVERSION         PropType = 0  /* Device driver version                    */
+	TECHNOLOGYPropType    PropType = 2  /* Device classification                    */
+	HORZSIZE              PropType = 4  /* Horizontal size in millimeters           */
+	VERTSIZE              PropType = 6  /* Vertical size in millimeters             */
+	HORZRES               PropType = 8  /* Horizontal width in pixels               */
+	VERTRES               PropType = 10 /* Vertical height in pixels                */
+	BITSPIXEL             PropType = 12 /* Number of bits per pixel                 */
+	PLANES                PropType = 14 /* Number of planes                         */
+	NUMBRUSHES            PropType = 16 /* Number of brushes the device has         */
+	NUMPENS               PropType = 18 /* Number of pens the device has            */
+	NUMMARKERS            PropType = 20 /* Number of markers the device has         */
+	NUMFONTS              PropType = 22 /* Number of fonts the device has           */
+	NUMCOLORS             PropType = 24 /* Number of colors the device supports     */
+	PDEVICESIZE           PropType = 26 /* Size required for device descriptor      */
+	CURVECAPS             PropType = 28 /* Curve capabilities                       */
+	LINECAPSPropType      PropType = 30 /* Line capabilities                        */
+	POLYGONALCAPSPropType PropType = 32 /* Polygonal capabilities                   */
+	TEXTCAPSPropType      PropType = 34 /* Text capabilities                        */
+	CLIPCAPSPropType      PropType = 36 /* Clipping capabilities                    */
+	RASTERCAPS            PropType = 38 /* Bitblt capabilities                      */
+	ASPECTX               PropType = 40 /* Length of the X leg                      */
+	ASPECTY               PropType = 42 /* Length of the Y leg                      */
+	ASPECTXYPropType      PropType = 44 /* Length of the hypotenuse                 */
+
+	LOGPIXELSX PropType = 88 /* Logical pixels/inch in X                 */
+	LOGPIXELSY PropType = 90 /* Logical pixels/inch in Y                 */
+
+	SIZEPALETTE PropType = 104 /* Number of entries in physical palette    */
+	NUMRESERVED PropType = 106 /* Number of reserved entries in palette    */
+	COLORRES    PropType = 108 /* Actual color resolution                  */
+
+	// Printing related DeviceCaps. These replace the appropriate Escapes
+
+	PHYSICALWIDTH   PropType = 110 /* Physical Width in device units           */
+	PHYSICALHEIGHT  PropType = 111 /* Physical Height in device units          */
+	PHYSICALOFFSETX PropType = 112 /* Physical Printable Area x margin         */
+	PHYSICALOFFSETY PropType = 113 /* Physical Printable Area y margin         */
+	SCALINGFACTORX  PropType = 114 /* Scaling factor x                         */
+	SCALINGFACTORY  PropType = 115 /* Scaling factor y                         */
+
+	// Display driver specific
+
+	VREFRESH PropType = 116 /* Current vertical refresh rate of the    */
+	/* display device (for displays only) in Hz*/
+	DESKTOPVERTRES PropType = 117 /* Horizontal width of entire desktop in   */
+	/* pixels                                  */
+	DESKTOPHORZRES PropType = 118 /* Vertical height of entire desktop in    */
+	/* pixels                                  */
+	BLTALIGNMENT PropType = 119 /* Preferred blt alignment                 */
+
+	SHADEBLENDCAPS PropType = 120 /* Shading and blending caps               */
+	COLORMGMTCAPS  PropType = 121 /* Color Management caps                   */
+)
+
+type BITMAPINFOHEADER struct {
+	biSize          uint32
+	biWidth         int32
+	biHeight        int32
+	biPlanes        uint16
+	biBitCount      uint16
+	biCompression   BiCompression
+	biSizeImage     uint32
+	biXPelsPerMeter uint32
+	biYPelsPerMeter uint32
+	biClrUsed       uint32
+	biClrImportant  uint32
+}
+
+type RGBQUAD struct {
+	RgbBlue     uint8
+	RgbGreen    uint8
+	RgbRed      uint8
+	RgbReserved uint8
+}
+
+type BITMAPINFO struct {
+	BmiHeader BITMAPINFOHEADER
+	BmiColors RGBQUAD
+}
+
+type DIBColors uint32
+
+const (
+	DIB_RGB_COLORS  DIBColors = 0x00
+	DIB_PAL_COLORS  DIBColors = 0x01
+	DIB_PAL_INDICES DIBColors = 0x02
+)
+
+type RasterOperationCode uint32
+
+const (
+	SRCCOPY     RasterOperationCode = 0x00CC0020 /* dest = source                   */
+	SRCPAINT    RasterOperationCode = 0x00EE0086 /* dest = source OR dest           */
+	SRCAND      RasterOperationCode = 0x008800C6 /* dest = source AND dest          */
+	SRCINVERT   RasterOperationCode = 0x00660046 /* dest = source XOR dest          */
+	SRCERASE    RasterOperationCode = 0x00440328 /* dest = source AND (NOT dest )   */
+	NOTSRCCOPY  RasterOperationCode = 0x00330008 /* dest = (NOT source)             */
+	NOTSRCERASE RasterOperationCode = 0x001100A6 /* dest = (NOT src) AND (NOT dest) */
+	MERGECOPY   RasterOperationCode = 0x00C000CA /* dest = (source AND pattern)     */
+	MERGEPAINT  RasterOperationCode = 0x00BB0226 /* dest = (NOT source) OR dest     */
+	PATCOPY     RasterOperationCode = 0x00F00021 /* dest = pattern                  */
+	PATPAINT    RasterOperationCode = 0x00FB0A09 /* dest = DPSnoo                   */
+	PATINVERT   RasterOperationCode = 0x005A0049 /* dest = pattern XOR dest         */
+	DSTINVERT   RasterOperationCode = 0x00550009 /* dest = (NOT dest)               */
+	BLACKNESS   RasterOperationCode = 0x00000042 /* dest = BLACK                    */
+	WHITENESS   RasterOperationCode = 0x00FF0062 /* dest = WHITE                    */
+)
+
+type BiCompression uint32
+
+const (
+	BI_RGB       BiCompression = 0
+	BI_RLE8      BiCompression = 1
+	BI_RLE4      BiCompression = 2
+	BI_BITFIELDS BiCompression = 3
+	BI_JPEG      BiCompression = 4
+	BI_PNG       BiCompression = 5
+)
+
+// NewBITMAPINFO returns the header of an uncompressed DIB. A negative height
+// describes a top-down bitmap.
+func NewBITMAPINFO(width, height int32, bitCount uint16) *BITMAPINFO {
+	// Rows are padded to 32 bits.
+	stride := (int64(width)*int64(bitCount) + 31) / 32 * 4
+	rows := int64(height)
+	if rows < 0 {
+		rows = -rows
+	}
+	header := BITMAPINFOHEADER{
+		biWidth:       width,
+		biHeight:      height,
+		biPlanes:      1,
+		biBitCount:    bitCount,
+		biCompression: BI_RGB,
+		biSizeImage:   uint32(stride * rows),
+	}
+	header.biSize = uint32(unsafe.Sizeof(header))
+	return &BITMAPINFO{BmiHeader: header}
+}
+
+// MarshalBinary encodes the header as stored in .bmp files and metafiles.
+func (h *BITMAPINFOHEADER) MarshalBinary() ([]byte, error) {
+	le := binary.LittleEndian
+	b := make([]byte, 0, 40)
+	b = le.AppendUint32(b, 40)
+	b = le.AppendUint32(b, uint32(h.biWidth))
+	b = le.AppendUint32(b, uint32(h.biHeight))
+	b = le.AppendUint16(b, h.biPlanes)
+	b = le.AppendUint16(b, h.biBitCount)
+	b = le.AppendUint32(b, uint32(h.biCompression))
+	b = le.AppendUint32(b, h.biSizeImage)
+	b = le.AppendUint32(b, h.biXPelsPerMeter)
+	b = le.AppendUint32(b, h.biYPelsPerMeter)
+	b = le.AppendUint32(b, h.biClrUsed)
+	b = le.AppendUint32(b, h.biClrImportant)
+	return b, nil
+}
@@ -0,0 +1,164 @@
+package win32
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// Job status flags of JOB_INFO_1 and JOB_INFO_2.
+const (
+	JOB_STATUS_PAUSED            uint32 = 0x00000001
+	JOB_STATUS_ERROR             uint32 = 0x00000002
+	JOB_STATUS_DELETING          uint32 = 0x00000004
+	JOB_STATUS_SPOOLING          uint32 = 0x00000008
+	JOB_STATUS_PRINTING          uint32 = 0x00000010
+	JOB_STATUS_OFFLINE           uint32 = 0x00000020
+	JOB_STATUS_PAPEROUT          uint32 = 0x00000040
+	JOB_STATUS_PRINTED           uint32 = 0x00000080
+	JOB_STATUS_DELETED           uint32 = 0x00000100
+	JOB_STATUS_BLOCKED_DEVQ      uint32 = 0x00000200
+	JOB_STATUS_USER_INTERVENTION uint32 = 0x00000400
+	JOB_STATUS_RESTART           uint32 = 0x00000800
+	JOB_STATUS_COMPLETE          uint32 = 0x00001000
+	JOB_STATUS_RETAINED          uint32 = 0x00002000
+	JOB_STATUS_RENDERING_LOCALLY uint32 = 0x00004000
+)
+
+// Commands of SetJob.
+const (
+	JOB_CONTROL_PAUSE   uint32 = 1
+	JOB_CONTROL_RESUME  uint32 = 2
+	JOB_CONTROL_CANCEL  uint32 = 3
+	JOB_CONTROL_RESTART uint32 = 4
+	JOB_CONTROL_DELETE  uint32 = 5
+	JOB_CONTROL_RETAIN  uint32 = 8
+	JOB_CONTROL_RELEASE uint32 = 9
+)
+
+// JobInfo1 is a decoded JOB_INFO_1, the summary of a print job.
+//
+// https://learn.microsoft.com/en-us/windows/win32/printdocs/job-info-1
+type JobInfo1 struct {
+	JobID       uint32
+	PrinterName string
+	// MachineName and UserName tell who submitted the job.
+	MachineName string
+	UserName    string
+	Document    string
+	Datatype    string
+	// StatusText is the status set by the port monitor, which takes
+	// precedence over Status when not empty.
+	StatusText string
+	// Status holds JOB_STATUS flags.
+	Status       uint32
+	Priority     uint32
+	Position     uint32
+	TotalPages   uint32
+	PagesPrinted uint32
+	// Submitted is the time the job was submitted, in UTC.
+	Submitted time.Time
+}
+
+// JobInfo2 is a decoded JOB_INFO_2, the full description of a print job.
+// The security descriptor is left out.
+//
+// https://learn.microsoft.com/en-us/windows/win32/printdocs/job-info-2
+type JobInfo2 struct {
+	JobID          uint32
+	PrinterName    string
+	MachineName    string
+	UserName       string
+	Document       string
+	NotifyName     string
+	Datatype       string
+	PrintProcessor string
+	Parameters     string
+	DriverName     string
+	DevMode        *DEVMODE
+	StatusText     string
+	Status         uint32
+	Priority       uint32
+	Position       uint32
+	// StartTime and UntilTime bound the hours the job may print at, in
+	// minutes from midnight UTC.
+	StartTime  uint32
+	UntilTime  uint32
+	TotalPages uint32
+	// Size is the size of the job in bytes.
+	Size      uint32
+	Submitted time.Time
+	// Time is the time spent printing the job, in milliseconds.
+	Time         uint32
+	PagesPrinted uint32
+}
+
+// systemTime decodes the SYSTEMTIME at off, the zero time when unset.
+func (b *Buffer) systemTime(off int) time.Time {
+	var st [8]int
+	for i := range st {
+		st[i] = int(binary.LittleEndian.Uint16(b.Data[off+2*i:]))
+	}
+	if st[0] == 0 {
+		return time.Time{}
+	}
+	// st[2] is the day of the week.
+	return time.Date(st[0], time.Month(st[1]), st[3], st[4], st[5], st[6], st[7]*int(time.Millisecond), time.UTC)
+}
+
+// JobInfo1 decodes count JOB_INFO_1 structures.
+func (b *Buffer) JobInfo1(count int) ([]JobInfo1, error) {
+	all, err := b.structs(count, "upppppp"+"uuuuu"+"t")
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]JobInfo1, count)
+	for i, f := range all {
+		j := &jobs[i]
+		j.JobID = b.uint32(f[0])
+		if err := b.strings(f[1:], &j.PrinterName, &j.MachineName, &j.UserName, &j.Document,
+			&j.Datatype, &j.StatusText); err != nil {
+			return nil, err
+		}
+		j.Status = b.uint32(f[7])
+		j.Priority = b.uint32(f[8])
+		j.Position = b.uint32(f[9])
+		j.TotalPages = b.uint32(f[10])
+		j.PagesPrinted = b.uint32(f[11])
+		j.Submitted = b.systemTime(f[12])
+	}
+	return jobs, nil
+}
+
+// JobInfo2 decodes count JOB_INFO_2 structures.
+func (b *Buffer) JobInfo2(count int) ([]JobInfo2, error) {
+	all, err := b.structs(count, "u"+"pppppppppppp"+"uuuuuuu"+"t"+"uu")
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]JobInfo2, count)
+	for i, f := range all {
+		j := &jobs[i]
+		j.JobID = b.uint32(f[0])
+		if err := b.strings(f[1:], &j.PrinterName, &j.MachineName, &j.UserName, &j.Document,
+			&j.NotifyName, &j.Datatype, &j.PrintProcessor, &j.Parameters, &j.DriverName); err != nil {
+			return nil, err
+		}
+		if j.DevMode, err = b.devMode(f[10]); err != nil {
+			return nil, err
+		}
+		if j.StatusText, err = b.string(f[11]); err != nil {
+			return nil, err
+		}
+		j.Status = b.uint32(f[13])
+		j.Priority = b.uint32(f[14])
+		j.Position = b.uint32(f[15])
+		j.StartTime = b.uint32(f[16])
+		j.UntilTime = b.uint32(f[17])
+		j.TotalPages = b.uint32(f[18])
+		j.Size = b.uint32(f[19])
+		j.Submitted = b.systemTime(f[20])
+		j.Time = b.uint32(f[21])
+		j.PagesPrinted = b.uint32(f[22])
+	}
+	return jobs, nil
+}
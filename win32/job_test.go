@@ -0,0 +1,81 @@
+package win32
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJobInfo1(t *testing.T) {
+	submitted := [8]uint16{2024, 3, 5, 15, 9, 41, 7, 250}
+	for _, ptrSize := range []int{4, 8} {
+		b := newFixture(ptrSize, 0x40000).build("upppppp"+"uuuuu"+"t",
+			[]any{uint32(12), "Caisse", "\\\\POS-01", "caissier", "Ticket 4711", "RAW", "",
+				JOB_STATUS_ERROR | JOB_STATUS_PRINTING, uint32(1), uint32(1), uint32(2), uint32(1), submitted},
+			[]any{uint32(13), "Caisse", nil, "caissier", "Ticket 4712", "RAW", "Paper jam",
+				JOB_STATUS_PAUSED, uint32(1), uint32(2)},
+		)
+		jobs, err := b.JobInfo1(2)
+		if err != nil {
+			t.Fatalf("%d bit: %v", 8*ptrSize, err)
+		}
+		j := jobs[0]
+		if j.JobID != 12 || j.PrinterName != "Caisse" || j.MachineName != "\\\\POS-01" || j.UserName != "caissier" ||
+			j.Document != "Ticket 4711" || j.Datatype != "RAW" || j.StatusText != "" {
+			t.Errorf("%d bit: strings of %+v", 8*ptrSize, j)
+		}
+		if j.Status != JOB_STATUS_ERROR|JOB_STATUS_PRINTING || j.Position != 1 || j.TotalPages != 2 || j.PagesPrinted != 1 {
+			t.Errorf("%d bit: numbers of %+v", 8*ptrSize, j)
+		}
+		if want := time.Date(2024, 3, 15, 9, 41, 7, 250e6, time.UTC); !j.Submitted.Equal(want) {
+			t.Errorf("%d bit: Submitted = %v, want %v", 8*ptrSize, j.Submitted, want)
+		}
+		if k := jobs[1]; k.JobID != 13 || k.MachineName != "" || k.StatusText != "Paper jam" ||
+			k.Status != JOB_STATUS_PAUSED || k.Position != 2 || !k.Submitted.IsZero() {
+			t.Errorf("%d bit: second job = %+v", 8*ptrSize, k)
+		}
+	}
+}
+
+func TestJobInfo2(t *testing.T) {
+	for _, ptrSize := range []int{4, 8} {
+		b := newFixture(ptrSize, 0x40000).build("u"+"pppppppppppp"+"uuuuuuu"+"t"+"uu",
+			[]any{uint32(7), "Caisse", "\\\\POS-01", "caissier", "Ticket", "caissier", "RAW", "winprint", nil,
+				"EPSON TM-T20", devModeFixture(), "Offline", nil,
+				JOB_STATUS_OFFLINE, uint32(1), uint32(1), uint32(0), uint32(0), uint32(1), uint32(2048),
+				[8]uint16{2024, 12, 2, 31, 23, 59, 59, 999}, uint32(1500), uint32(0)},
+		)
+		jobs, err := b.JobInfo2(1)
+		if err != nil {
+			t.Fatalf("%d bit: %v", 8*ptrSize, err)
+		}
+		j := jobs[0]
+		if j.JobID != 7 || j.NotifyName != "caissier" || j.PrintProcessor != "winprint" || j.Parameters != "" ||
+			j.DriverName != "EPSON TM-T20" || j.StatusText != "Offline" {
+			t.Errorf("%d bit: strings of %+v", 8*ptrSize, j)
+		}
+		if j.DevMode == nil || j.DevMode.DeviceName != "Receipt" {
+			t.Errorf("%d bit: DevMode = %+v", 8*ptrSize, j.DevMode)
+		}
+		if j.Status != JOB_STATUS_OFFLINE || j.TotalPages != 1 || j.Size != 2048 || j.Time != 1500 ||
+			j.Submitted.Year() != 2024 || j.Submitted.Day() != 31 || j.Submitted.Hour() != 23 {
+			t.Errorf("%d bit: numbers of %+v", 8*ptrSize, j)
+		}
+	}
+}
+
+func TestJobInfoLayout(t *testing.T) {
+	for _, tt := range []struct {
+		fields  string
+		ptrSize int
+		size    int
+	}{
+		{"upppppp" + "uuuuu" + "t", 4, 64},
+		{"upppppp" + "uuuuu" + "t", 8, 96},
+		{"u" + "pppppppppppp" + "uuuuuuu" + "t" + "uu", 4, 104},
+		{"u" + "pppppppppppp" + "uuuuuuu" + "t" + "uu", 8, 160},
+	} {
+		if _, size := (&Buffer{PtrSize: tt.ptrSize}).layout(tt.fields); size != tt.size {
+			t.Errorf("%d bit %q: %d bytes, want %d", 8*tt.ptrSize, tt.fields, size, tt.size)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package win32
+
+import (
+	"encoding/binary"
+	"errors"
+	"unicode/utf16"
+)
+
+// Printer status flags of PRINTER_INFO_2.
+const (
+	PRINTER_STATUS_PAUSED            uint32 = 0x00000001
+	PRINTER_STATUS_ERROR             uint32 = 0x00000002
+	PRINTER_STATUS_PENDING_DELETION  uint32 = 0x00000004
+	PRINTER_STATUS_PAPER_JAM         uint32 = 0x00000008
+	PRINTER_STATUS_PAPER_OUT         uint32 = 0x00000010
+	PRINTER_STATUS_MANUAL_FEED       uint32 = 0x00000020
+	PRINTER_STATUS_PAPER_PROBLEM     uint32 = 0x00000040
+	PRINTER_STATUS_OFFLINE           uint32 = 0x00000080
+	PRINTER_STATUS_IO_ACTIVE         uint32 = 0x00000100
+	PRINTER_STATUS_BUSY              uint32 = 0x00000200
+	PRINTER_STATUS_PRINTING          uint32 = 0x00000400
+	PRINTER_STATUS_OUTPUT_BIN_FULL   uint32 = 0x00000800
+	PRINTER_STATUS_NOT_AVAILABLE     uint32 = 0x00001000
+	PRINTER_STATUS_WAITING           uint32 = 0x00002000
+	PRINTER_STATUS_PROCESSING        uint32 = 0x00004000
+	PRINTER_STATUS_INITIALIZING      uint32 = 0x00008000
+	PRINTER_STATUS_WARMING_UP        uint32 = 0x00010000
+	PRINTER_STATUS_TONER_LOW         uint32 = 0x00020000
+	PRINTER_STATUS_NO_TONER          uint32 = 0x00040000
+	PRINTER_STATUS_PAGE_PUNT         uint32 = 0x00080000
+	PRINTER_STATUS_USER_INTERVENTION uint32 = 0x00100000
+	PRINTER_STATUS_OUT_OF_MEMORY     uint32 = 0x00200000
+	PRINTER_STATUS_DOOR_OPEN         uint32 = 0x00400000
+	PRINTER_STATUS_SERVER_UNKNOWN    uint32 = 0x00800000
+	PRINTER_STATUS_POWER_SAVE        uint32 = 0x01000000
+)
+
+// Flags of PRINTER_INFO_1.
+const (
+	PRINTER_ENUM_EXPAND    uint32 = 0x00004000
+	PRINTER_ENUM_CONTAINER uint32 = 0x00008000
+	PRINTER_ENUM_ICONMASK  uint32 = 0x00ff0000
+)
+
+// Bits of DEVMODE.Fields telling which members are set.
+const (
+	DM_ORIENTATION   uint32 = 0x00000001
+	DM_PAPERSIZE     uint32 = 0x00000002
+	DM_PAPERLENGTH   uint32 = 0x00000004
+	DM_PAPERWIDTH    uint32 = 0x00000008
+	DM_SCALE         uint32 = 0x00000010
+	DM_COPIES        uint32 = 0x00000100
+	DM_DEFAULTSOURCE uint32 = 0x00000200
+	DM_PRINTQUALITY  uint32 = 0x00000400
+	DM_COLOR         uint32 = 0x00000800
+	DM_DUPLEX        uint32 = 0x00001000
+	DM_YRESOLUTION   uint32 = 0x00002000
+	DM_COLLATE       uint32 = 0x00008000
+	DM_FORMNAME      uint32 = 0x00010000
+	DM_NUP           uint32 = 0x00000040
+	DM_MEDIATYPE     uint32 = 0x02000000
+)
+
+const (
+	DMORIENT_PORTRAIT  int16 = 1
+	DMORIENT_LANDSCAPE int16 = 2
+)
+
+// DEVMODE holds the printer members of a DEVMODEW, the device settings of
+// a printer or a job. Members missing from an older, shorter, DEVMODE are
+// zero.
+//
+// https://learn.microsoft.com/en-us/windows/win32/api/wingdi/ns-wingdi-devmodew
+type DEVMODE struct {
+	DeviceName    string
+	SpecVersion   uint16
+	DriverVersion uint16
+	// Size is the size of the public members, DriverExtra the size of the
+	// private data of the driver that follows them.
+	Size          uint16
+	DriverExtra   uint16
+	Fields        uint32
+	Orientation   int16
+	PaperSize     int16
+	PaperLength   int16
+	PaperWidth    int16
+	Scale         int16
+	Copies        int16
+	DefaultSource int16
+	PrintQuality  int16
+	Color         int16
+	Duplex        int16
+	YResolution   int16
+	TTOption      int16
+	Collate       int16
+	FormName      string
+	Nup           uint32
+	MediaType     uint32
+	// DriverData is the private data of the driver.
+	DriverData []byte
+}
+
+// Offsets of the members of DEVMODEW.
+const (
+	dmSpecVersion = 64
+	dmFields      = 72
+	dmOrientation = 76
+	dmFormName    = 102
+	dmNup         = 180
+	dmMediaType   = 196
+)
+
+var errShortDEVMODE = errors.New("DEVMODE too short")
+
+// UnmarshalBinary decodes a DEVMODEW followed by the private data of the
+// driver.
+func (dm *DEVMODE) UnmarshalBinary(b []byte) error {
+	le := binary.LittleEndian
+	if len(b) < dmFields+4 {
+		return errShortDEVMODE
+	}
+	size, extra := int(le.Uint16(b[68:])), int(le.Uint16(b[70:]))
+	if size < dmFields+4 || size+extra > len(b) {
+		return errShortDEVMODE
+	}
+	pub := make([]byte, max(size, 220))
+	copy(pub, b[:size])
+	name := func(off int) string {
+		u := make([]uint16, 32)
+		for i := range u {
+			u[i] = le.Uint16(pub[off+2*i:])
+		}
+		for i, c := range u {
+			if c == 0 {
+				u = u[:i]
+				break
+			}
+		}
+		return string(utf16.Decode(u))
+	}
+	short := func(i int) int16 {
+		return int16(le.Uint16(pub[dmOrientation+2*i:]))
+	}
+	*dm = DEVMODE{
+		DeviceName:    name(0),
+		SpecVersion:   le.Uint16(pub[dmSpecVersion:]),
+		DriverVersion: le.Uint16(pub[dmSpecVersion+2:]),
+		Size:          uint16(size),
+		DriverExtra:   uint16(extra),
+		Fields:        le.Uint32(pub[dmFields:]),
+		Orientation:   short(0),
+		PaperSize:     short(1),
+		PaperLength:   short(2),
+		PaperWidth:    short(3),
+		Scale:         short(4),
+		Copies:        short(5),
+		DefaultSource: short(6),
+		PrintQuality:  short(7),
+		Color:         short(8),
+		Duplex:        short(9),
+		YResolution:   short(10),
+		TTOption:      short(11),
+		Collate:       short(12),
+		FormName:      name(dmFormName),
+		Nup:           le.Uint32(pub[dmNup:]),
+		MediaType:     le.Uint32(pub[dmMediaType:]),
+		DriverData:    append([]byte(nil), b[size:size+extra]...),
+	}
+	return nil
+}
+
+// PrinterInfo1 is a decoded PRINTER_INFO_1, which describes printers and
+// the print servers or domains containing them.
+//
+// https://learn.microsoft.com/en-us/windows/win32/printdocs/printer-info-1
+type PrinterInfo1 struct {
+	// Flags holds PRINTER_ENUM_EXPAND, PRINTER_ENUM_CONTAINER and an icon
+	// in PRINTER_ENUM_ICONMASK.
+	Flags       uint32
+	Description string
+	Name        string
+	Comment     string
+}
+
+// PrinterInfo2 is a decoded PRINTER_INFO_2, the full description of a
+// printer. The security descriptor is left out.
+//
+// https://learn.microsoft.com/en-us/windows/win32/printdocs/printer-info-2
+type PrinterInfo2 struct {
+	ServerName     string
+	PrinterName    string
+	ShareName      string
+	PortName       string
+	DriverName     string
+	Comment        string
+	Location       string
+	DevMode        *DEVMODE
+	SepFile        string
+	PrintProcessor string
+	Datatype       string
+	Parameters     string
+	Attributes     uint32
+	Priority       uint32
+	// DefaultPriority is the priority of new jobs.
+	DefaultPriority uint32
+	// StartTime and UntilTime bound the hours the printer prints at, in
+	// minutes from midnight UTC.
+	StartTime uint32
+	UntilTime uint32
+	// Status holds PRINTER_STATUS flags.
+	Status     uint32
+	Jobs       uint32
+	AveragePPM uint32
+}
+
+// PrinterInfo5 is a decoded PRINTER_INFO_5.
+//
+// https://learn.microsoft.com/en-us/windows/win32/printdocs/printer-info-5
+type PrinterInfo5 struct {
+	PrinterName string
+	PortName    string
+	Attributes  uint32
+	// The timeouts are in milliseconds.
+	DeviceNotSelectedTimeout uint32
+	TransmissionRetryTimeout uint32
+}
+
+// PrinterInfo1 decodes count PRINTER_INFO_1 structures.
+func (b *Buffer) PrinterInfo1(count int) ([]PrinterInfo1, error) {
+	all, err := b.structs(count, "uppp")
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]PrinterInfo1, count)
+	for i, f := range all {
+		p := &infos[i]
+		p.Flags = b.uint32(f[0])
+		if err := b.strings(f[1:], &p.Description, &p.Name, &p.Comment); err != nil {
+			return nil, err
+		}
+	}
+	return infos, nil
+}
+
+// PrinterInfo2 decodes count PRINTER_INFO_2 structures.
+func (b *Buffer) PrinterInfo2(count int) ([]PrinterInfo2, error) {
+	all, err := b.structs(count, "ppppppppppppp"+"uuuuuuuu")
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]PrinterInfo2, count)
+	for i, f := range all {
+		p := &infos[i]
+		if err := b.strings(f, &p.ServerName, &p.PrinterName, &p.ShareName, &p.PortName,
+			&p.DriverName, &p.Comment, &p.Location); err != nil {
+			return nil, err
+		}
+		if p.DevMode, err = b.devMode(f[7]); err != nil {
+			return nil, err
+		}
+		if err := b.strings(f[8:], &p.SepFile, &p.PrintProcessor, &p.Datatype, &p.Parameters); err != nil {
+			return nil, err
+		}
+		p.Attributes = b.uint32(f[13])
+		p.Priority = b.uint32(f[14])
+		p.DefaultPriority = b.uint32(f[15])
+		p.StartTime = b.uint32(f[16])
+		p.UntilTime = b.uint32(f[17])
+		p.Status = b.uint32(f[18])
+		p.Jobs = b.uint32(f[19])
+		p.AveragePPM = b.uint32(f[20])
+	}
+	return infos, nil
+}
+
+// PrinterInfo4 decodes count PRINT_INFO_4 structures.
+func (b *Buffer) PrinterInfo4(count int) ([]PrinterInfo, error) {
+	all, err := b.structs(count, "ppu")
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]PrinterInfo, count)
+	for i, f := range all {
+		p := &infos[i]
+		if err := b.strings(f, &p.PrinterName, &p.ServerName); err != nil {
+			return nil, err
+		}
+		p.Attributes = b.uint32(f[2])
+	}
+	return infos, nil
+}
+
+// PrinterInfo5 decodes count PRINTER_INFO_5 structures.
+func (b *Buffer) PrinterInfo5(count int) ([]PrinterInfo5, error) {
+	all, err := b.structs(count, "ppuuu")
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]PrinterInfo5, count)
+	for i, f := range all {
+		p := &infos[i]
+		if err := b.strings(f, &p.PrinterName, &p.PortName); err != nil {
+			return nil, err
+		}
+		p.Attributes = b.uint32(f[2])
+		p.DeviceNotSelectedTimeout = b.uint32(f[3])
+		p.TransmissionRetryTimeout = b.uint32(f[4])
+	}
+	return infos, nil
+}
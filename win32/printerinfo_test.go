@@ -0,0 +1,174 @@
+package win32
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+	"unicode/utf16"
+)
+
+// fixture builds a spooler buffer at addr: structures of fields, whose
+// values are uint32, [8]uint16 for SYSTEMTIME or, for pointers, strings or
+// byte slices stored after the structures.
+type fixture struct {
+	b    *Buffer
+	tail []byte
+}
+
+func newFixture(ptrSize int, addr uint64) *fixture {
+	return &fixture{b: &Buffer{Addr: addr, PtrSize: ptrSize}}
+}
+
+func (f *fixture) build(fields string, structs ...[]any) *Buffer {
+	offsets, size := f.b.layout(fields)
+	head := make([]byte, size*len(structs))
+	le := binary.LittleEndian
+	for i, values := range structs {
+		for j, v := range values {
+			off := i*size + offsets[j]
+			var data []byte
+			switch v := v.(type) {
+			case uint32:
+				le.PutUint32(head[off:], v)
+				continue
+			case [8]uint16:
+				for k, w := range v {
+					le.PutUint16(head[off+2*k:], w)
+				}
+				continue
+			case string:
+				for _, c := range utf16.Encode([]rune(v + "\x00")) {
+					data = le.AppendUint16(data, c)
+				}
+			case []byte:
+				data = v
+			case nil:
+				continue
+			}
+			p := f.b.Addr + uint64(len(head)+len(f.tail))
+			if f.b.PtrSize == 8 {
+				le.PutUint64(head[off:], p)
+			} else {
+				le.PutUint32(head[off:], uint32(p))
+			}
+			f.tail = append(f.tail, data...)
+		}
+	}
+	f.b.Data = append(head, f.tail...)
+	return f.b
+}
+
+func devModeFixture() []byte {
+	b := make([]byte, 220+3)
+	le := binary.LittleEndian
+	for i, c := range "Receipt" {
+		le.PutUint16(b[2*i:], uint16(c))
+	}
+	le.PutUint16(b[64:], 0x0401)
+	le.PutUint16(b[68:], 220)
+	le.PutUint16(b[70:], 3)
+	le.PutUint32(b[72:], DM_ORIENTATION|DM_PAPERSIZE|DM_COPIES|DM_FORMNAME)
+	le.PutUint16(b[76:], uint16(DMORIENT_LANDSCAPE))
+	le.PutUint16(b[78:], 9)
+	le.PutUint16(b[86:], 2)
+	for i, c := range "A4" {
+		le.PutUint16(b[102+2*i:], uint16(c))
+	}
+	copy(b[220:], "drv")
+	return b
+}
+
+func TestPrinterInfo2(t *testing.T) {
+	for _, ptrSize := range []int{4, 8} {
+		f := newFixture(ptrSize, 0x1000)
+		b := f.build("ppppppppppppp"+"uuuuuuuu",
+			[]any{"\\\\srv", "\\\\srv\\Caisse", "Caisse", "USB001", "EPSON TM-T20", "Comptoir", "Magasin 1",
+				devModeFixture(), nil, "winprint", "RAW", nil, nil,
+				PRINTER_ATTRIBUTE_SHARED | PRINTER_ATTRIBUTE_NETWORK, uint32(1), uint32(2), uint32(0), uint32(0),
+				PRINTER_STATUS_PAPER_OUT | PRINTER_STATUS_OFFLINE, uint32(3), uint32(12)},
+			[]any{nil, "PDF", nil, "PORTPROMPT:", "Microsoft Print To PDF"},
+		)
+		infos, err := b.PrinterInfo2(2)
+		if err != nil {
+			t.Fatalf("%d bit: %v", 8*ptrSize, err)
+		}
+		p := infos[0]
+		if p.PrinterName != "\\\\srv\\Caisse" || p.ShareName != "Caisse" || p.PortName != "USB001" ||
+			p.DriverName != "EPSON TM-T20" || p.Comment != "Comptoir" || p.Location != "Magasin 1" ||
+			p.SepFile != "" || p.PrintProcessor != "winprint" || p.Datatype != "RAW" {
+			t.Errorf("%d bit: strings of %+v", 8*ptrSize, p)
+		}
+		if p.Attributes != PRINTER_ATTRIBUTE_SHARED|PRINTER_ATTRIBUTE_NETWORK || p.Priority != 1 ||
+			p.DefaultPriority != 2 || p.Status != PRINTER_STATUS_PAPER_OUT|PRINTER_STATUS_OFFLINE ||
+			p.Jobs != 3 || p.AveragePPM != 12 {
+			t.Errorf("%d bit: numbers of %+v", 8*ptrSize, p)
+		}
+		dm := p.DevMode
+		if dm == nil || dm.DeviceName != "Receipt" || dm.SpecVersion != 0x0401 || dm.Orientation != DMORIENT_LANDSCAPE ||
+			dm.PaperSize != 9 || dm.Copies != 2 || dm.FormName != "A4" || string(dm.DriverData) != "drv" {
+			t.Errorf("%d bit: DevMode = %+v", 8*ptrSize, dm)
+		}
+		if q := infos[1]; q.PrinterName != "PDF" || q.DevMode != nil || q.Status != 0 {
+			t.Errorf("%d bit: second printer = %+v", 8*ptrSize, q)
+		}
+	}
+}
+
+func TestPrinterInfo1And5(t *testing.T) {
+	b := newFixture(8, 0x7ff0000).build("uppp",
+		[]any{PRINTER_ENUM_CONTAINER, "Windows NT Remote Printers", "Microsoft Windows Network", ""})
+	infos1, err := b.PrinterInfo1(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := infos1[0]; p.Flags != PRINTER_ENUM_CONTAINER || p.Name != "Microsoft Windows Network" || p.Comment != "" {
+		t.Errorf("PrinterInfo1 = %+v", p)
+	}
+
+	b = newFixture(4, 0x10000).build("ppuuu",
+		[]any{"Caisse", "COM1:", PRINTER_ATTRIBUTE_LOCAL, uint32(15000), uint32(45000)})
+	infos5, err := b.PrinterInfo5(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := infos5[0]; p.PrinterName != "Caisse" || p.PortName != "COM1:" || p.Attributes != PRINTER_ATTRIBUTE_LOCAL ||
+		p.DeviceNotSelectedTimeout != 15000 || p.TransmissionRetryTimeout != 45000 {
+		t.Errorf("PrinterInfo5 = %+v", p)
+	}
+	if _, size := b.layout("ppuuu"); size != 20 {
+		t.Errorf("32 bit PRINTER_INFO_5 of %d bytes, want 20", size)
+	}
+	if _, size := (&Buffer{PtrSize: 8}).layout("ppuuu"); size != 32 {
+		t.Errorf("64 bit PRINTER_INFO_5 of %d bytes, want 32", size)
+	}
+}
+
+func TestInvalidBuffer(t *testing.T) {
+	good := func() *Buffer {
+		return newFixture(8, 0x1000).build("ppu", []any{"Caisse", "srv", uint32(0)})
+	}
+	if infos, err := good().PrinterInfo4(1); err != nil || infos[0].PrinterName != "Caisse" {
+		t.Fatalf("PrinterInfo4 = %+v, %v", infos, err)
+	}
+	for name, b := range map[string]*Buffer{
+		"too many structures": good(),
+		"pointer before":      func() *Buffer { b := good(); b.Addr = 0x2000; return b }(),
+		"pointer after":       func() *Buffer { b := good(); b.Data[0] = 0xff; return b }(),
+		"unterminated string": func() *Buffer { b := good(); b.Data = b.Data[:len(b.Data)-2]; return b }(),
+		"pointer size":        func() *Buffer { b := good(); b.PtrSize = 2; return b }(),
+	} {
+		count := 1
+		if name == "too many structures" {
+			count = 2
+		}
+		if _, err := b.PrinterInfo4(count); !errors.Is(err, ErrInvalidBuffer) {
+			t.Errorf("%s: error %v", name, err)
+		}
+	}
+	dm := devModeFixture()
+	binary.LittleEndian.PutUint16(dm[70:], 100)
+	b := newFixture(8, 0x1000).build("ppppppppppppp"+"uuuuuuuu", []any{nil, "p", nil, nil, nil, nil, nil, dm})
+	if _, err := b.PrinterInfo2(1); !errors.Is(err, ErrInvalidBuffer) {
+		t.Errorf("DEVMODE overflowing the buffer: error %v", err)
+	}
+}
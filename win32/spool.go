@@ -0,0 +1,96 @@
+// Package win32 wraps the winspool and gdi32 APIs used to print on Windows.
+//
+// The types, constants and struct encoders are available on every OS, so
+// that code handling spooler data builds anywhere. The system calls
+// themselves only exist on Windows: elsewhere they return
+// ErrUnsupportedPlatform.
+package win32
+
+import (
+	"errors"
+	"unicode/utf16"
+)
+
+// ErrUnsupportedPlatform is returned by the system calls of this package on
+// operating systems other than Windows.
+var ErrUnsupportedPlatform = errors.New("win32: unsupported platform")
+
+// Printer is a printer handle returned by OpenPrinter.
+type Printer uintptr
+
+// https://learn.microsoft.com/en-us/windows/win32/printdocs/doc-info-1
+type DOC_INFO_1 struct {
+	DocName    *uint16
+	OutputFile *uint16
+	Datatype   *uint16
+}
+
+// NewDOC_INFO_1 returns the DOC_INFO_1 of a job. Empty strings are left
+// NULL, so that the spooler uses its defaults.
+func NewDOC_INFO_1(docName, outputFile, datatype string) *DOC_INFO_1 {
+	return &DOC_INFO_1{
+		DocName:    utf16Ptr(docName),
+		OutputFile: utf16Ptr(outputFile),
+		Datatype:   utf16Ptr(datatype),
+	}
+}
+
+// utf16Ptr returns s as a NUL terminated UTF-16 string, nil when empty.
+func utf16Ptr(s string) *uint16 {
+	if s == "" {
+		return nil
+	}
+	u := utf16.Encode([]rune(s + "\x00"))
+	return &u[0]
+}
+
+type EnumFlag uint32
+
+const (
+	PRINTER_ENUM_DEFAULT     EnumFlag = 0x00000001
+	PRINTER_ENUM_LOCAL       EnumFlag = 0x00000002
+	PRINTER_ENUM_CONNECTIONS EnumFlag = 0x00000004
+	PRINTER_ENUM_FAVORITE    EnumFlag = 0x00000004
+	PRINTER_ENUM_NAME        EnumFlag = 0x00000008
+	PRINTER_ENUM_REMOTE      EnumFlag = 0x00000010
+	PRINTER_ENUM_SHARED      EnumFlag = 0x00000020
+	PRINTER_ENUM_NETWORK     EnumFlag = 0x00000040
+)
+
+type PRINT_INFO_4 struct {
+	PrinterName *uint16
+	ServerName  *uint16
+	Attributes  uint32
+}
+
+type PrinterInfo struct {
+	PrinterName string
+	ServerName  string
+	Attributes  uint32
+}
+
+const (
+	PRINTER_ATTRIBUTE_SHARED  uint32 = 0x00000008
+	PRINTER_ATTRIBUTE_NETWORK uint32 = 0x00000010
+	PRINTER_ATTRIBUTE_LOCAL   uint32 = 0x00000040
+
+	PRINTER_ATTRIBUTE_WORK_OFFLINE uint32 = 0x00000400
+)
+
+// ChangeNotification is a printer change notification object returned by
+// FindFirstPrinterChangeNotification.
+type ChangeNotification uintptr
+
+// Changes watched by FindFirstPrinterChangeNotification.
+const (
+	PRINTER_CHANGE_ADD_PRINTER    uint32 = 0x00000001
+	PRINTER_CHANGE_SET_PRINTER    uint32 = 0x00000002
+	PRINTER_CHANGE_DELETE_PRINTER uint32 = 0x00000004
+	PRINTER_CHANGE_PRINTER        uint32 = 0x000000FF
+	PRINTER_CHANGE_ADD_JOB        uint32 = 0x00000100
+	PRINTER_CHANGE_SET_JOB        uint32 = 0x00000200
+	PRINTER_CHANGE_DELETE_JOB     uint32 = 0x00000400
+	PRINTER_CHANGE_WRITE_JOB      uint32 = 0x00000800
+	PRINTER_CHANGE_JOB            uint32 = 0x0000FF00
+	PRINTER_CHANGE_ALL            uint32 = 0x7777FFFF
+)
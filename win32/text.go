@@ -1,54 +1,15 @@
-// win32/textsize.go
+//go:build windows
 
 package win32
 
 import (
 	"fmt"
-	"log"
 	"syscall"
 	"unsafe"
 
 	"golang.org/x/sys/windows"
 )
 
-// Constants
-const (
-	HFONT = 6 // Object type for getCurrentFont
-)
-
-const (
-	Arial         = "Arial"
-	TimesNewRoman = "Times New Roman"
-	CourierNew    = "Courier New"
-	Verdana       = "Verdana"
-)
-
-// LOGFONT contains information about a logical font
-type LOGFONT struct {
-	Height         int32
-	Width          int32
-	Escapement     int32
-	Orientation    int32
-	Weight         int32
-	Italic         byte
-	Underline      byte
-	StrikeOut      byte
-	CharSet        byte
-	OutPrecision   byte
-	ClipPrecision  byte
-	Quality        byte
-	PitchAndFamily byte
-	FaceName       [32]uint16
-}
-
-// COLORREF represents a Windows color value in BGR format
-type COLORREF uint32
-
-// RGB creates a COLORREF value from red, green, and blue components
-func RGB(r, g, b byte) COLORREF {
-	return COLORREF(uint32(b)<<16 | uint32(g)<<8 | uint32(r))
-}
-
 // SetTextColor sets the text color for the specified device context
 // Parameters:
 //   - hdc: Handle to the device context
@@ -284,12 +245,7 @@ func SetFont(hdc HDC, fontName string) (err error) {
 	}
 
 	// Update font properties
-	f, er := syscall.UTF16FromString(fontName)
-	if er != nil {
-		// Gérer l'erreur ici
-		log.Fatalf("Erreur lors de la conversion de la chaîne en UTF-16 : %v", err)
-	}
-	copy(lf.FaceName[:], f)
+	lf.SetFaceName(fontName)
 
 	// Create new font
 	newFont, _, errno := syscall.SyscallN(procCreateFontIndirect.Addr(), uintptr(unsafe.Pointer(&lf)))
@@ -0,0 +1,51 @@
+package win32
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestRGB(t *testing.T) {
+	if got := RGB(0x12, 0x34, 0x56); got != 0x563412 {
+		t.Errorf("RGB() = %#x, want 0x563412", got)
+	}
+}
+
+func TestBITMAPINFOHEADER(t *testing.T) {
+	bmi := NewBITMAPINFO(3, -2, 24)
+	b, err := bmi.BmiHeader.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	le := binary.LittleEndian
+	if len(b) != 40 || le.Uint32(b) != 40 || bmi.BmiHeader.biSize != 40 {
+		t.Fatalf("header of %d bytes, biSize %d", len(b), bmi.BmiHeader.biSize)
+	}
+	if int32(le.Uint32(b[4:])) != 3 || int32(le.Uint32(b[8:])) != -2 || le.Uint16(b[14:]) != 24 ||
+		le.Uint32(b[20:]) != 2*12 {
+		t.Errorf("header = % x", b)
+	}
+}
+
+func TestLOGFONT(t *testing.T) {
+	lf := LOGFONT{Height: -64, Weight: 700, Italic: 1}
+	lf.SetFaceName(CourierNew)
+	if got := lf.FaceNameString(); got != CourierNew {
+		t.Errorf("FaceNameString() = %q", got)
+	}
+	lf.SetFaceName("Arial")
+	if got := lf.FaceNameString(); got != "Arial" {
+		t.Errorf("FaceNameString() = %q after a shorter name", got)
+	}
+	b, err := lf.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	le := binary.LittleEndian
+	if len(b) != 92 || int32(le.Uint32(b)) != -64 || le.Uint32(b[16:]) != 700 || b[20] != 1 {
+		t.Errorf("LOGFONTW = % x", b)
+	}
+	if le.Uint16(b[28:]) != 'A' || le.Uint16(b[28+2*5:]) != 0 {
+		t.Errorf("face name = % x", b[28:40])
+	}
+}
@@ -1,9 +1,13 @@
+//go:build windows
+
 package win32
 
 import (
-	"golang.org/x/sys/windows"
 	"syscall"
+	"time"
 	"unsafe"
+
+	"golang.org/x/sys/windows"
 )
 
 var (
@@ -19,9 +23,15 @@ var (
 	procEnumPrintersW      = printspool32.NewProc("EnumPrintersW")
 	procGetDefaultPrinterW = printspool32.NewProc("GetDefaultPrinterW")
 	procSetDefaultPrinter  = printspool32.NewProc("SetDefaultPrinterW")
-)
+	procGetPrinterW        = printspool32.NewProc("GetPrinterW")
+	procEnumJobsW          = printspool32.NewProc("EnumJobsW")
+	procGetJobW            = printspool32.NewProc("GetJobW")
+	procSetJobW            = printspool32.NewProc("SetJobW")
 
-type Printer syscall.Handle
+	procFindFirstPrinterChangeNotification = printspool32.NewProc("FindFirstPrinterChangeNotification")
+	procFindNextPrinterChangeNotification  = printspool32.NewProc("FindNextPrinterChangeNotification")
+	procFindClosePrinterChangeNotification = printspool32.NewProc("FindClosePrinterChangeNotification")
+)
 
 func OpenPrinter(name string) (Printer, error) {
 	var printHandler Printer
@@ -50,13 +60,6 @@ func ClosePrinter(handle Printer) (err error) {
 	return err
 }
 
-// https://learn.microsoft.com/en-us/windows/win32/printdocs/doc-info-1
-type DOC_INFO_1 struct {
-	DocName    *uint16
-	OutputFile *uint16
-	Datatype   *uint16
-}
-
 // https://learn.microsoft.com/en-us/windows/win32/printdocs/startdocprinter
 func StartDocPrinter(handle Printer, level uint32, doc *DOC_INFO_1) (err error) {
 	r1, _, e1 := syscall.SyscallN(procStartDocPrinter.Addr(), uintptr(handle), uintptr(level), uintptr(unsafe.Pointer(doc)))
@@ -119,36 +122,6 @@ func WritePrinter(handle Printer, buf *byte, bufN uint32, written *uint32) (err
 	return err
 }
 
-type EnumFlag uint32
-
-const (
-	PRINTER_ENUM_DEFAULT     EnumFlag = 0x00000001
-	PRINTER_ENUM_LOCAL       EnumFlag = 0x00000002
-	PRINTER_ENUM_CONNECTIONS EnumFlag = 0x00000004
-	PRINTER_ENUM_FAVORITE    EnumFlag = 0x00000004
-	PRINTER_ENUM_NAME        EnumFlag = 0x00000008
-	PRINTER_ENUM_REMOTE      EnumFlag = 0x00000010
-	PRINTER_ENUM_SHARED      EnumFlag = 0x00000020
-	PRINTER_ENUM_NETWORK     EnumFlag = 0x00000040
-)
-
-type PRINT_INFO_4 struct {
-	PrinterName *uint16
-	ServerName  *uint16
-	Attributes  uint32
-}
-
-type PrinterInfo struct {
-	PrinterName string
-	ServerName  string
-	Attributes  uint32
-}
-
-const (
-	PRINTER_ATTRIBUTE_LOCAL   uint32 = 0x00000040
-	PRINTER_ATTRIBUTE_NETWORK uint32 = 0x00000010
-)
-
 // EnumPrinter https://learn.microsoft.com/zh-cn/windows/win32/printdocs/enumprinters
 func EnumPrinter(flag EnumFlag, name *uint16, level uint32, info *byte, bufLen uint32, bufSize *uint32, returnLen *uint32) (err error) {
 	r1, _, e1 := syscall.SyscallN(procEnumPrintersW.Addr(),
@@ -166,6 +139,68 @@ func EnumPrinter(flag EnumFlag, name *uint16, level uint32, info *byte, bufLen u
 	return err
 }
 
+// enumPrinters returns the structures of the given level describing the
+// printers matching flags, see EnumPrinter.
+func enumPrinters(flags EnumFlag, level uint32) (*Buffer, int, error) {
+	var need, returned uint32
+	err := EnumPrinter(flags, nil, level, nil, 0, &need, &returned)
+	if err != nil {
+		return nil, 0, err
+	}
+	if need == 0 {
+		return &Buffer{PtrSize: int(unsafe.Sizeof(uintptr(0)))}, 0, nil
+	}
+	buf := make([]byte, need)
+	err = EnumPrinter(flags, nil, level, &buf[0], need, &need, &returned)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &Buffer{
+		Data:    buf,
+		Addr:    uint64(uintptr(unsafe.Pointer(&buf[0]))),
+		PtrSize: int(unsafe.Sizeof(uintptr(0))),
+	}, int(returned), nil
+}
+
+// EnumPrinters lists the printers matching flags, see EnumPrinter.
+func EnumPrinters(flags EnumFlag) ([]PrinterInfo, error) {
+	b, n, err := enumPrinters(flags, 4)
+	if err != nil {
+		return nil, err
+	}
+	return b.PrinterInfo4(n)
+}
+
+// EnumPrinters1 lists the printers, print servers and domains matching
+// flags with PRINTER_INFO_1.
+func EnumPrinters1(flags EnumFlag) ([]PrinterInfo1, error) {
+	b, n, err := enumPrinters(flags, 1)
+	if err != nil {
+		return nil, err
+	}
+	return b.PrinterInfo1(n)
+}
+
+// EnumPrinters2 describes in full the printers matching flags with
+// PRINTER_INFO_2.
+func EnumPrinters2(flags EnumFlag) ([]PrinterInfo2, error) {
+	b, n, err := enumPrinters(flags, 2)
+	if err != nil {
+		return nil, err
+	}
+	return b.PrinterInfo2(n)
+}
+
+// EnumPrinters5 lists the printers matching flags with their ports and
+// timeouts with PRINTER_INFO_5.
+func EnumPrinters5(flags EnumFlag) ([]PrinterInfo5, error) {
+	b, n, err := enumPrinters(flags, 5)
+	if err != nil {
+		return nil, err
+	}
+	return b.PrinterInfo5(n)
+}
+
 func Default() (string, error) {
 	b := make([]uint16, 3)
 	n := uint32(len(b))
@@ -206,3 +241,215 @@ func SetDefaultPrinter(buf *uint16) (err error) {
 	}
 	return
 }
+
+// GetPrinter returns the structure of the given level describing the
+// printer.
+//
+// https://learn.microsoft.com/en-us/windows/win32/printdocs/getprinter
+func GetPrinter(handle Printer, level uint32) (*Buffer, error) {
+	var need uint32
+	r1, _, e1 := syscall.SyscallN(procGetPrinterW.Addr(), uintptr(handle), uintptr(level), 0, 0, uintptr(unsafe.Pointer(&need)))
+	if r1 == 0 && e1 != syscall.ERROR_INSUFFICIENT_BUFFER {
+		if e1 != 0 {
+			return nil, error(e1)
+		}
+		return nil, syscall.EINVAL
+	}
+	if need == 0 {
+		return nil, syscall.EINVAL
+	}
+	buf := make([]byte, need)
+	r1, _, e1 = syscall.SyscallN(procGetPrinterW.Addr(), uintptr(handle), uintptr(level),
+		uintptr(unsafe.Pointer(&buf[0])), uintptr(need), uintptr(unsafe.Pointer(&need)))
+	if r1 == 0 {
+		if e1 != 0 {
+			return nil, error(e1)
+		}
+		return nil, syscall.EINVAL
+	}
+	return &Buffer{
+		Data:    buf,
+		Addr:    uint64(uintptr(unsafe.Pointer(&buf[0]))),
+		PtrSize: int(unsafe.Sizeof(uintptr(0))),
+	}, nil
+}
+
+// GetPrinter2 describes the printer in full with PRINTER_INFO_2.
+func GetPrinter2(handle Printer) (*PrinterInfo2, error) {
+	b, err := GetPrinter(handle, 2)
+	if err != nil {
+		return nil, err
+	}
+	infos, err := b.PrinterInfo2(1)
+	if err != nil {
+		return nil, err
+	}
+	return &infos[0], nil
+}
+
+// EnumJobs returns the structures of the given level describing the jobs
+// of the printer, and their count.
+//
+// https://learn.microsoft.com/en-us/windows/win32/printdocs/enumjobs
+func EnumJobs(handle Printer, level uint32) (*Buffer, int, error) {
+	var need, returned uint32
+	r1, _, e1 := syscall.SyscallN(procEnumJobsW.Addr(), uintptr(handle), 0, 0xFFFFFFFF, uintptr(level),
+		0, 0, uintptr(unsafe.Pointer(&need)), uintptr(unsafe.Pointer(&returned)))
+	if r1 == 0 && e1 != syscall.ERROR_INSUFFICIENT_BUFFER {
+		if e1 != 0 {
+			return nil, 0, error(e1)
+		}
+		return nil, 0, syscall.EINVAL
+	}
+	if need == 0 {
+		return &Buffer{PtrSize: int(unsafe.Sizeof(uintptr(0)))}, 0, nil
+	}
+	buf := make([]byte, need)
+	r1, _, e1 = syscall.SyscallN(procEnumJobsW.Addr(), uintptr(handle), 0, 0xFFFFFFFF, uintptr(level),
+		uintptr(unsafe.Pointer(&buf[0])), uintptr(need), uintptr(unsafe.Pointer(&need)), uintptr(unsafe.Pointer(&returned)))
+	if r1 == 0 {
+		if e1 != 0 {
+			return nil, 0, error(e1)
+		}
+		return nil, 0, syscall.EINVAL
+	}
+	return &Buffer{
+		Data:    buf,
+		Addr:    uint64(uintptr(unsafe.Pointer(&buf[0]))),
+		PtrSize: int(unsafe.Sizeof(uintptr(0))),
+	}, int(returned), nil
+}
+
+// EnumJobs1 lists the jobs of the printer with JOB_INFO_1.
+func EnumJobs1(handle Printer) ([]JobInfo1, error) {
+	b, n, err := EnumJobs(handle, 1)
+	if err != nil {
+		return nil, err
+	}
+	return b.JobInfo1(n)
+}
+
+// EnumJobs2 describes in full the jobs of the printer with JOB_INFO_2.
+func EnumJobs2(handle Printer) ([]JobInfo2, error) {
+	b, n, err := EnumJobs(handle, 2)
+	if err != nil {
+		return nil, err
+	}
+	return b.JobInfo2(n)
+}
+
+// GetJob returns the structure of the given level describing the job id of
+// the printer.
+//
+// https://learn.microsoft.com/en-us/windows/win32/printdocs/getjob
+func GetJob(handle Printer, id, level uint32) (*Buffer, error) {
+	var need uint32
+	r1, _, e1 := syscall.SyscallN(procGetJobW.Addr(), uintptr(handle), uintptr(id), uintptr(level), 0, 0, uintptr(unsafe.Pointer(&need)))
+	if r1 == 0 && e1 != syscall.ERROR_INSUFFICIENT_BUFFER {
+		if e1 != 0 {
+			return nil, error(e1)
+		}
+		return nil, syscall.EINVAL
+	}
+	if need == 0 {
+		return nil, syscall.EINVAL
+	}
+	buf := make([]byte, need)
+	r1, _, e1 = syscall.SyscallN(procGetJobW.Addr(), uintptr(handle), uintptr(id), uintptr(level),
+		uintptr(unsafe.Pointer(&buf[0])), uintptr(need), uintptr(unsafe.Pointer(&need)))
+	if r1 == 0 {
+		if e1 != 0 {
+			return nil, error(e1)
+		}
+		return nil, syscall.EINVAL
+	}
+	return &Buffer{
+		Data:    buf,
+		Addr:    uint64(uintptr(unsafe.Pointer(&buf[0]))),
+		PtrSize: int(unsafe.Sizeof(uintptr(0))),
+	}, nil
+}
+
+// GetJob2 describes the job id of the printer in full with JOB_INFO_2.
+func GetJob2(handle Printer, id uint32) (*JobInfo2, error) {
+	b, err := GetJob(handle, id, 2)
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := b.JobInfo2(1)
+	if err != nil {
+		return nil, err
+	}
+	return &jobs[0], nil
+}
+
+// SetJob sends command, one of the JOB_CONTROL constants, to the job id of
+// the printer. Controlling the jobs of other users takes the right to
+// manage documents on the printer.
+//
+// https://learn.microsoft.com/en-us/windows/win32/printdocs/setjob
+func SetJob(handle Printer, id, command uint32) error {
+	r1, _, e1 := syscall.SyscallN(procSetJobW.Addr(), uintptr(handle), uintptr(id), 0, 0, uintptr(command))
+	if r1 == 0 {
+		if e1 != 0 {
+			return error(e1)
+		}
+		return syscall.EINVAL
+	}
+	return nil
+}
+
+// FindFirstPrinterChangeNotification returns an object signaled when the
+// printer or its jobs change as selected by filter, a combination of
+// PRINTER_CHANGE flags.
+//
+// https://learn.microsoft.com/en-us/windows/win32/printdocs/findfirstprinterchangenotification
+func FindFirstPrinterChangeNotification(handle Printer, filter uint32) (ChangeNotification, error) {
+	r1, _, e1 := syscall.SyscallN(procFindFirstPrinterChangeNotification.Addr(), uintptr(handle), uintptr(filter), 0, 0)
+	if windows.Handle(r1) == windows.InvalidHandle {
+		if e1 != 0 {
+			return 0, error(e1)
+		}
+		return 0, syscall.EINVAL
+	}
+	return ChangeNotification(r1), nil
+}
+
+// FindNextPrinterChangeNotification returns the PRINTER_CHANGE flags of the
+// changes that signaled n, and resets it.
+func FindNextPrinterChangeNotification(n ChangeNotification) (uint32, error) {
+	var change uint32
+	r1, _, e1 := syscall.SyscallN(procFindNextPrinterChangeNotification.Addr(), uintptr(n),
+		uintptr(unsafe.Pointer(&change)), 0, 0)
+	if r1 == 0 {
+		if e1 != 0 {
+			return 0, error(e1)
+		}
+		return 0, syscall.EINVAL
+	}
+	return change, nil
+}
+
+func FindClosePrinterChangeNotification(n ChangeNotification) error {
+	r1, _, e1 := syscall.SyscallN(procFindClosePrinterChangeNotification.Addr(), uintptr(n))
+	if r1 == 0 {
+		if e1 != 0 {
+			return error(e1)
+		}
+		return syscall.EINVAL
+	}
+	return nil
+}
+
+// WaitPrinterChange waits at most timeout for n to be signaled, and
+// returns the PRINTER_CHANGE flags of the changes, 0 on timeout.
+func WaitPrinterChange(n ChangeNotification, timeout time.Duration) (uint32, error) {
+	ev, err := windows.WaitForSingleObject(windows.Handle(n), uint32(timeout/time.Millisecond))
+	if err != nil {
+		return 0, err
+	}
+	if ev == uint32(windows.WAIT_TIMEOUT) {
+		return 0, nil
+	}
+	return FindNextPrinterChangeNotification(n)
+}
@@ -0,0 +1,204 @@
+//go:build !windows
+
+package win32
+
+import (
+	"image"
+	"time"
+)
+
+func OpenPrinter(name string) (Printer, error) {
+	return 0, ErrUnsupportedPlatform
+}
+
+func ClosePrinter(handle Printer) error {
+	return ErrUnsupportedPlatform
+}
+
+func StartDocPrinter(handle Printer, level uint32, doc *DOC_INFO_1) error {
+	return ErrUnsupportedPlatform
+}
+
+func EndDocPrinter(handle Printer) error {
+	return ErrUnsupportedPlatform
+}
+
+func StartPagePrinter(handle Printer) error {
+	return ErrUnsupportedPlatform
+}
+
+func EndPagePrinter(handle Printer) error {
+	return ErrUnsupportedPlatform
+}
+
+func WritePrinter(handle Printer, buf *byte, bufN uint32, written *uint32) error {
+	return ErrUnsupportedPlatform
+}
+
+func EnumPrinter(flag EnumFlag, name *uint16, level uint32, info *byte, bufLen uint32, bufSize *uint32, returnLen *uint32) error {
+	return ErrUnsupportedPlatform
+}
+
+func EnumPrinters(flags EnumFlag) ([]PrinterInfo, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func EnumPrinters1(flags EnumFlag) ([]PrinterInfo1, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func EnumPrinters2(flags EnumFlag) ([]PrinterInfo2, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func EnumPrinters5(flags EnumFlag) ([]PrinterInfo5, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func GetPrinter(handle Printer, level uint32) (*Buffer, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func GetPrinter2(handle Printer) (*PrinterInfo2, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func EnumJobs(handle Printer, level uint32) (*Buffer, int, error) {
+	return nil, 0, ErrUnsupportedPlatform
+}
+
+func EnumJobs1(handle Printer) ([]JobInfo1, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func EnumJobs2(handle Printer) ([]JobInfo2, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func GetJob(handle Printer, id, level uint32) (*Buffer, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func GetJob2(handle Printer, id uint32) (*JobInfo2, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func SetJob(handle Printer, id, command uint32) error {
+	return ErrUnsupportedPlatform
+}
+
+func FindFirstPrinterChangeNotification(handle Printer, filter uint32) (ChangeNotification, error) {
+	return 0, ErrUnsupportedPlatform
+}
+
+func FindNextPrinterChangeNotification(n ChangeNotification) (uint32, error) {
+	return 0, ErrUnsupportedPlatform
+}
+
+func FindClosePrinterChangeNotification(n ChangeNotification) error {
+	return ErrUnsupportedPlatform
+}
+
+func WaitPrinterChange(n ChangeNotification, timeout time.Duration) (uint32, error) {
+	return 0, ErrUnsupportedPlatform
+}
+
+func Default() (string, error) {
+	return "", ErrUnsupportedPlatform
+}
+
+func GetDefaultPrinter(buf *uint16, bufN *uint32) error {
+	return ErrUnsupportedPlatform
+}
+
+func SetDefaultPrinter(buf *uint16) error {
+	return ErrUnsupportedPlatform
+}
+
+func CreateDC(printerName string) (HDC, error) {
+	return 0, ErrUnsupportedPlatform
+}
+
+func ResetDC(dc HDC) error {
+	return ErrUnsupportedPlatform
+}
+
+func DeleteDC(dc HDC) error {
+	return ErrUnsupportedPlatform
+}
+
+func SetPixel(dc HDC, x, y uint32, color uint32) error {
+	return ErrUnsupportedPlatform
+}
+
+func GetPixel(dc HDC, x, y uint32) (uint32, error) {
+	return 0, ErrUnsupportedPlatform
+}
+
+func TextOut(dc HDC, x, y uint32, text string, len uint32) error {
+	return ErrUnsupportedPlatform
+}
+
+func StartDCPrinter(dc HDC, docName string) error {
+	return ErrUnsupportedPlatform
+}
+
+func StartDoc(dc HDC, doc *DOCINFOA) error {
+	return ErrUnsupportedPlatform
+}
+
+func StartPage(dc HDC) error {
+	return ErrUnsupportedPlatform
+}
+
+func EndDoc(dc HDC) error {
+	return ErrUnsupportedPlatform
+}
+
+func EndPage(dc HDC) error {
+	return ErrUnsupportedPlatform
+}
+
+func GetDeviceCaps(dc HDC, index PropType) (uint32, error) {
+	return 0, ErrUnsupportedPlatform
+}
+
+func DrawDIImage(dc HDC, dx, dy, dw, dh, sx, sy uint32, sw, sh int32, image []byte) error {
+	return ErrUnsupportedPlatform
+}
+
+func StretchDIBits(dc HDC, dx, dy, dw, dh, sx, sy uint32, sw, sh int32, image []byte, bitmap *BITMAPINFO, color DIBColors, operation RasterOperationCode) error {
+	return ErrUnsupportedPlatform
+}
+
+func MoveTo(dc HDC, x, y uint32) (*image.Point, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func LineTo(dc HDC, x, y uint32) error {
+	return ErrUnsupportedPlatform
+}
+
+func GetTextExtentPoint32(hdc HDC, text string) (uint32, uint32, error) {
+	return 0, 0, ErrUnsupportedPlatform
+}
+
+func SetTextColor(hdc HDC, color COLORREF) (COLORREF, error) {
+	return 0, ErrUnsupportedPlatform
+}
+
+func SetTextSize(hdc HDC, size int32) (int32, error) {
+	return 0, ErrUnsupportedPlatform
+}
+
+func SetBoldFont(hdc HDC, bold bool) error {
+	return ErrUnsupportedPlatform
+}
+
+func SetItalicFont(hdc HDC, italic bool) error {
+	return ErrUnsupportedPlatform
+}
+
+func SetFont(hdc HDC, fontName string) error {
+	return ErrUnsupportedPlatform
+}
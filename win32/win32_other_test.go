@@ -0,0 +1,17 @@
+//go:build !windows
+
+package win32
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUnsupportedPlatform(t *testing.T) {
+	if _, err := CreateDC(""); !errors.Is(err, ErrUnsupportedPlatform) {
+		t.Errorf("CreateDC() error = %v", err)
+	}
+	if _, err := OpenPrinter(""); !errors.Is(err, ErrUnsupportedPlatform) {
+		t.Errorf("OpenPrinter() error = %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package zpl
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+
+	"github.com/sipkg/golang-win32-printer/image/mono"
+)
+
+// compressGraphic returns the rows of m in the compressed ASCII hexadecimal
+// of ^GF: runs of a digit are prefixed with their length, a row ending with
+// zeros or ones is cut short with ',' or '!', and ':' repeats the previous
+// row.
+func compressGraphic(m *mono.Image) string {
+	b := m.Bounds()
+	stride := (b.Dx() + 7) / 8
+	var out strings.Builder
+	var prev []byte
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := m.Row(y)[:stride]
+		if prev != nil && bytes.Equal(row, prev) {
+			out.WriteByte(':')
+			continue
+		}
+		prev = row
+		compressRow(&out, hex.EncodeToString(row))
+	}
+	return out.String()
+}
+
+func compressRow(out *strings.Builder, digits string) {
+	digits = strings.ToUpper(digits)
+	switch end := strings.TrimRight(digits, "0"); {
+	case end == "":
+		out.WriteByte(',')
+		return
+	case len(end) < len(digits)-1:
+		defer out.WriteByte(',')
+		digits = end
+	default:
+		if end := strings.TrimRight(digits, "F"); len(end) < len(digits)-1 {
+			defer out.WriteByte('!')
+			digits = end
+		}
+	}
+	for i := 0; i < len(digits); {
+		n := 1
+		for i+n < len(digits) && digits[i+n] == digits[i] {
+			n++
+		}
+		writeCount(out, n)
+		out.WriteByte(digits[i])
+		i += n
+	}
+}
+
+// writeCount writes the repeat count n of a digit: 'G' to 'Y' for 1 to 19,
+// 'g' to 'z' for 20 to 400 by steps of 20, added together.
+func writeCount(out *strings.Builder, n int) {
+	if n == 1 {
+		return
+	}
+	for n >= 20 {
+		k := min(n/20, 20)
+		out.WriteByte(byte('f' + k))
+		n -= 20 * k
+	}
+	if n > 0 {
+		out.WriteByte(byte('F' + n))
+	}
+}
@@ -0,0 +1,254 @@
+// Package zpl composes labels in ZPL II, the label language of Zebra
+// printers.
+//
+// A Writer turns method calls into ZPL commands on any io.Writer, usually a
+// printer.RawJob or a printer.NetJob. Positions and sizes are in dots, 8 per
+// millimeter on 203 dpi printers and 12 on 300 dpi ones. Like escpos.Writer,
+// errors are sticky and can be checked once with Err.
+package zpl
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"strings"
+
+	"github.com/sipkg/golang-win32-printer/image/mono"
+)
+
+// Orientation is the rotation of a field, clockwise.
+type Orientation byte
+
+const (
+	Normal   Orientation = 'N'
+	Rotated  Orientation = 'R'
+	Inverted Orientation = 'I'
+	Bottom   Orientation = 'B'
+)
+
+func (o Orientation) orDefault() Orientation {
+	if o == 0 {
+		return Normal
+	}
+	return o
+}
+
+// Font selects a printer font with ^A.
+type Font struct {
+	// Name is the font letter or digit, '0' (the scalable font) when zero.
+	Name byte
+	// Orientation is the rotation of the text, Normal when zero.
+	Orientation Orientation
+	// Height and Width are the size of the characters in dots. A zero Width
+	// keeps the proportions of the font.
+	Height, Width int
+}
+
+// Writer writes ZPL commands to an io.Writer.
+type Writer struct {
+	w   io.Writer
+	err error
+}
+
+// NewWriter returns a Writer on w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Err returns the first error met while writing.
+func (z *Writer) Err() error {
+	return z.err
+}
+
+// Write sends b to the printer unchanged.
+func (z *Writer) Write(b []byte) (int, error) {
+	if z.err != nil {
+		return 0, z.err
+	}
+	n, err := z.w.Write(b)
+	if err != nil {
+		z.err = err
+	}
+	return n, err
+}
+
+func (z *Writer) printf(format string, a ...any) error {
+	_, err := fmt.Fprintf(z, format, a...)
+	return err
+}
+
+// Start begins a label with ^XA and selects UTF-8 field data with ^CI28.
+func (z *Writer) Start() error {
+	return z.printf("^XA^CI28\n")
+}
+
+// End ends the label with ^XZ, printing it.
+func (z *Writer) End() error {
+	return z.printf("^XZ\n")
+}
+
+// LabelHome moves the origin of the fields of the label with ^LH.
+func (z *Writer) LabelHome(x, y int) error {
+	return z.printf("^LH%d,%d\n", x, y)
+}
+
+// LabelLength sets the length of the label with ^LL.
+func (z *Writer) LabelLength(dots int) error {
+	return z.printf("^LL%d\n", dots)
+}
+
+// PrintWidth sets the width of the label with ^PW.
+func (z *Writer) PrintWidth(dots int) error {
+	return z.printf("^PW%d\n", dots)
+}
+
+// Quantity prints n copies of the label with ^PQ.
+func (z *Writer) Quantity(n int) error {
+	return z.printf("^PQ%d\n", n)
+}
+
+// fieldData returns the ^FD command of s, preceded by ^FH when s holds the
+// characters starting commands, which are then sent in hexadecimal.
+func fieldData(s string) string {
+	if !strings.ContainsAny(s, "^~_") {
+		return "^FD" + s
+	}
+	s = strings.NewReplacer("_", "_5F", "^", "_5E", "~", "_7E").Replace(s)
+	return "^FH^FD" + s
+}
+
+// Text prints s at x, y with font.
+func (z *Writer) Text(x, y int, font Font, s string) error {
+	name := font.Name
+	if name == 0 {
+		name = '0'
+	}
+	return z.printf("^FO%d,%d^A%c%c,%d,%d%s^FS\n", x, y, name,
+		font.Orientation.orDefault(), font.Height, font.Width, fieldData(s))
+}
+
+// TextBlock prints s at x, y wrapped in a block width dots wide, of at most
+// lines lines, aligned by justify ('L', 'C', 'R' or 'J').
+func (z *Writer) TextBlock(x, y int, font Font, width, lines int, justify byte, s string) error {
+	name := font.Name
+	if name == 0 {
+		name = '0'
+	}
+	return z.printf("^FO%d,%d^A%c%c,%d,%d^FB%d,%d,0,%c,0%s^FS\n", x, y, name,
+		font.Orientation.orDefault(), font.Height, font.Width, width, lines, justify, fieldData(s))
+}
+
+// Box draws a rectangle with ^GB, its top left corner at x, y, with borders
+// thickness dots wide. A thickness of half the smaller side fills it.
+func (z *Writer) Box(x, y, width, height, thickness int) error {
+	return z.printf("^FO%d,%d^GB%d,%d,%d^FS\n", x, y, width, height, thickness)
+}
+
+// HLine draws a horizontal line from x, y.
+func (z *Writer) HLine(x, y, length, thickness int) error {
+	return z.Box(x, y, length, thickness, thickness)
+}
+
+// VLine draws a vertical line from x, y.
+func (z *Writer) VLine(x, y, length, thickness int) error {
+	return z.Box(x, y, thickness, length, thickness)
+}
+
+// BarcodeOptions tells how to print a barcode.
+type BarcodeOptions struct {
+	// Orientation is the rotation of the barcode, Normal when zero.
+	Orientation Orientation
+	// Height is the height of the bars in dots. Zero means 100.
+	Height int
+	// ModuleWidth is the width of the narrowest bar in dots. Zero means 2.
+	ModuleWidth int
+	// HideText leaves out the human readable interpretation.
+	HideText bool
+}
+
+func (o *BarcodeOptions) orDefault() BarcodeOptions {
+	opts := BarcodeOptions{}
+	if o != nil {
+		opts = *o
+	}
+	opts.Orientation = opts.Orientation.orDefault()
+	if opts.Height <= 0 {
+		opts.Height = 100
+	}
+	if opts.ModuleWidth <= 0 {
+		opts.ModuleWidth = 2
+	}
+	return opts
+}
+
+func yesNo(b bool) byte {
+	if b {
+		return 'Y'
+	}
+	return 'N'
+}
+
+// barcode prints a barcode of symbology cmd, such as "BC".
+func (z *Writer) barcode(x, y int, cmd string, data string, opts *BarcodeOptions) error {
+	o := opts.orDefault()
+	return z.printf("^FO%d,%d^BY%d^%s%c,%d,%c,N%s^FS\n", x, y, o.ModuleWidth, cmd,
+		o.Orientation, o.Height, yesNo(!o.HideText), fieldData(data))
+}
+
+// Code128 prints data as a Code 128 barcode with ^BC, the printer choosing
+// the code sets. opts may be nil.
+func (z *Writer) Code128(x, y int, data string, opts *BarcodeOptions) error {
+	return z.barcode(x, y, "BC", data, opts)
+}
+
+// EAN13 prints an EAN-13 barcode with ^BE. data holds 12 digits, the printer
+// adding the check digit. opts may be nil.
+func (z *Writer) EAN13(x, y int, data string, opts *BarcodeOptions) error {
+	return z.barcode(x, y, "BE", data, opts)
+}
+
+// EAN8 prints an EAN-8 barcode with ^B8. data holds 7 digits, the printer
+// adding the check digit. opts may be nil.
+func (z *Writer) EAN8(x, y int, data string, opts *BarcodeOptions) error {
+	return z.barcode(x, y, "B8", data, opts)
+}
+
+// QRLevel is the error correction level of a QR code.
+type QRLevel byte
+
+const (
+	QRLevelL QRLevel = 'L'
+	QRLevelM QRLevel = 'M'
+	QRLevelQ QRLevel = 'Q'
+	QRLevelH QRLevel = 'H'
+)
+
+// QRCode prints data as a QR code with ^BQ, its modules magnification dots
+// wide (1 to 10), with error correction level, QRLevelM when zero.
+func (z *Writer) QRCode(x, y int, data string, magnification int, level QRLevel) error {
+	if level == 0 {
+		level = QRLevelM
+	}
+	return z.printf("^FO%d,%d^BQN,2,%d%s^FS\n", x, y, magnification, fieldData(fmt.Sprintf("%cA,%s", level, data)))
+}
+
+var errEmptyImage = errors.New("zpl: empty image")
+
+// Graphic prints img at x, y with ^GF. Images other than *mono.Image, such
+// as a bgr.BGRImage, are thresholded at mid gray: convert them with
+// mono.Convert beforehand to dither them. The data is sent as compressed
+// ASCII hexadecimal.
+func (z *Writer) Graphic(x, y int, img image.Image) error {
+	m, ok := img.(*mono.Image)
+	if !ok {
+		m = mono.Convert(img, nil)
+	}
+	b := m.Bounds()
+	if b.Empty() {
+		return errEmptyImage
+	}
+	stride := (b.Dx() + 7) / 8
+	total := stride * b.Dy()
+	return z.printf("^FO%d,%d^GFA,%d,%d,%d,%s^FS\n", x, y, total, total, stride, compressGraphic(m))
+}
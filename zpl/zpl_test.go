@@ -0,0 +1,133 @@
+package zpl
+
+import (
+	"bytes"
+	"encoding/hex"
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/sipkg/golang-win32-printer/image/bgr"
+	"github.com/sipkg/golang-win32-printer/image/mono"
+)
+
+func TestLabel(t *testing.T) {
+	var buf bytes.Buffer
+	z := NewWriter(&buf)
+	z.Start()
+	z.LabelHome(10, 20)
+	z.LabelLength(400)
+	z.Text(30, 40, Font{Height: 30}, "Café ^1_2")
+	z.Text(30, 80, Font{Name: 'D', Orientation: Rotated, Height: 18, Width: 10}, "Lot 42")
+	z.Box(0, 0, 780, 380, 4)
+	z.HLine(0, 120, 780, 2)
+	z.Code128(30, 150, "ABC-123", nil)
+	z.EAN13(400, 150, "400638133393", &BarcodeOptions{Height: 60, ModuleWidth: 3, HideText: true})
+	z.QRCode(600, 20, "https://example.com", 4, 0)
+	z.Quantity(2)
+	z.End()
+	if err := z.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := "^XA^CI28\n" +
+		"^LH10,20\n" +
+		"^LL400\n" +
+		"^FO30,40^A0N,30,0^FH^FDCafé _5E1_5F2^FS\n" +
+		"^FO30,80^ADR,18,10^FDLot 42^FS\n" +
+		"^FO0,0^GB780,380,4^FS\n" +
+		"^FO0,120^GB780,2,2^FS\n" +
+		"^FO30,150^BY2^BCN,100,Y,N^FDABC-123^FS\n" +
+		"^FO400,150^BY3^BEN,60,N,N^FD400638133393^FS\n" +
+		"^FO600,20^BQN,2,4^FDMA,https://example.com^FS\n" +
+		"^PQ2\n" +
+		"^XZ\n"
+	if got := buf.String(); got != want {
+		t.Errorf("label is\n%s\nwant\n%s", got, want)
+	}
+}
+
+// decompress expands the compressed ASCII hexadecimal of ^GF.
+func decompress(t *testing.T, data string, stride int) []byte {
+	var out, row []byte
+	prev := ""
+	count := 0
+	endRow := func(fill byte) {
+		digits := string(row)
+		for len(digits) < 2*stride {
+			digits += string(fill)
+		}
+		prev = digits
+		b, err := hex.DecodeString(digits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, b...)
+		row = row[:0]
+	}
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c >= 'G' && c <= 'Y':
+			count += int(c-'G') + 1
+		case c >= 'g' && c <= 'z':
+			count += 20 * (int(c-'g') + 1)
+		case c == ',':
+			endRow('0')
+		case c == '!':
+			endRow('F')
+		case c == ':':
+			row = append(row, prev...)
+			endRow('0')
+		default:
+			n := max(count, 1)
+			row = append(row, bytes.Repeat([]byte{c}, n)...)
+			count = 0
+			if len(row) == 2*stride {
+				endRow('0')
+			}
+		}
+	}
+	return out
+}
+
+func TestGraphic(t *testing.T) {
+	img := bgr.NewBGRImage(image.Rect(0, 0, 500, 6))
+	for y := 0; y < 6; y++ {
+		for x := 0; x < 500; x++ {
+			c := color.White
+			switch {
+			case y == 1 && x < 100, y == 2 && x%2 == 0, y >= 4 && x >= 250:
+				c = color.Black
+			}
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	z := NewWriter(&buf)
+	if err := z.Graphic(5, 6, img); err != nil {
+		t.Fatal(err)
+	}
+	fields := strings.SplitN(strings.TrimSuffix(buf.String(), "^FS\n"), ",", 6)
+	if fields[0] != "^FO5" || fields[1] != "6^GFA" {
+		t.Fatalf("graphic starts with %q", buf.String()[:20])
+	}
+	stride, _ := strconv.Atoi(fields[4])
+	total, _ := strconv.Atoi(fields[2])
+	if stride != 63 || total != 63*6 {
+		t.Fatalf("graphic of %d bytes, %d per row", total, stride)
+	}
+	data := fields[5]
+	if len(data) > 120 {
+		t.Errorf("graphic data not compressed: %q", data)
+	}
+	want := mono.Convert(img, nil)
+	got := decompress(t, data, stride)
+	if len(got) != total || !bytes.Equal(got, want.Pix) {
+		t.Errorf("graphic data %q decompresses to\n%x\nwant\n%x", data, got, want.Pix)
+	}
+	if err := z.Graphic(0, 0, mono.New(image.Rect(0, 0, 0, 0))); err == nil {
+		t.Error("empty graphic accepted")
+	}
+}